@@ -4,6 +4,7 @@
 // Usage:
 //   ./test-convert -input video.mp4 -output thumb.jpg
 //   ./test-convert -input document.pdf -output thumb.png -size 1024
+//   ./test-convert -input slides.pptx -output thumb.png
 //   ./test-convert -input video.mp4 -probe  # Show metadata only
 package main
 
@@ -150,6 +151,34 @@ func detectMIMEType(path string) (string, error) {
 		return "application/pdf", nil
 	}
 
+	// SVGs sniff as XML
+	if strings.EqualFold(filepath.Ext(path), ".svg") {
+		return "image/svg+xml", nil
+	}
+
+	// Camera RAW files sniff as TIFF or not at all, so trust the extension
+	if raw := converters.RawMimeType(path); raw != "" {
+		return raw, nil
+	}
+
+	// Neither TIFF nor Photoshop documents are sniffed
+	if n >= 4 && (string(buffer[:4]) == "II*\x00" || string(buffer[:4]) == "MM\x00*") {
+		return "image/tiff", nil
+	}
+	if n >= 4 && string(buffer[:4]) == "8BPS" {
+		return "image/vnd.adobe.photoshop", nil
+	}
+
+	// HEIF and AVIF sniff as MP4-like containers, so check the brand
+	if info, err := converters.NewHEIFConverter().Probe(context.Background(), path); err == nil {
+		return info.MimeType, nil
+	}
+
+	// Office documents sniff as ZIP or OLE containers, so trust a known extension
+	if info, err := converters.NewLibreOfficeConverter().Probe(context.Background(), path); err == nil && info.MimeType != "" {
+		return info.MimeType, nil
+	}
+
 	return mimeType, nil
 }
 
@@ -4,14 +4,10 @@ package main
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
-	"mime"
 	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,6 +22,7 @@ import (
 	"github.com/tendant/simple-thumbnailer/internal/bus"
 	"github.com/tendant/simple-thumbnailer/internal/img"
 	"github.com/tendant/simple-thumbnailer/internal/upload"
+	"github.com/tendant/simple-thumbnailer/internal/worker"
 	"github.com/tendant/simple-thumbnailer/pkg/schema"
 )
 
@@ -78,125 +75,11 @@ type Config struct {
 	Environment        string `env:"ENVIRONMENT" env-default:"prod"`
 }
 
-type SizeConfig struct {
-	Name   string
-	Width  int
-	Height int
-}
-
-func parseThumbnailSizes(sizesEnv string) ([]SizeConfig, error) {
-	var sizes []SizeConfig
-	pairs := strings.Split(sizesEnv, ",")
-
-	for _, pair := range pairs {
-		parts := strings.Split(strings.TrimSpace(pair), ":")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid size format '%s', expected 'name:widthxheight'", pair)
-		}
-
-		name := strings.TrimSpace(parts[0])
-		dimParts := strings.Split(parts[1], "x")
-		if len(dimParts) != 2 {
-			return nil, fmt.Errorf("invalid dimensions '%s', expected 'widthxheight'", parts[1])
-		}
-
-		width, err := strconv.Atoi(strings.TrimSpace(dimParts[0]))
-		if err != nil || width <= 0 {
-			return nil, fmt.Errorf("invalid width in '%s'", pair)
-		}
-
-		height, err := strconv.Atoi(strings.TrimSpace(dimParts[1]))
-		if err != nil || height <= 0 {
-			return nil, fmt.Errorf("invalid height in '%s'", pair)
-		}
-
-		sizes = append(sizes, SizeConfig{
-			Name:   name,
-			Width:  width,
-			Height: height,
-		})
-	}
-
-	return sizes, nil
-}
-
-type ProcessingState struct {
-	JobID             string
-	ParentContentID   string
-	ParentStatus      string
-	ThumbnailSizes    []string
-	DerivedContentIDs map[string]uuid.UUID
-	StartTime         time.Time
-	Lifecycle         []schema.ThumbnailLifecycleEvent
-}
-
-func (ps *ProcessingState) AddLifecycleEvent(stage schema.ProcessingStage, err error, failureType schema.FailureType) {
-	event := schema.ThumbnailLifecycleEvent{
-		JobID:           ps.JobID,
-		ParentContentID: ps.ParentContentID,
-		ParentStatus:    ps.ParentStatus,
-		Stage:           stage,
-		ThumbnailSizes:  ps.ThumbnailSizes,
-		HappenedAt:      time.Now().Unix(),
-	}
-
-	if stage == schema.StageProcessing {
-		event.ProcessingStart = ps.StartTime.UnixMilli()
-	} else if stage == schema.StageCompleted || stage == schema.StageFailed {
-		event.ProcessingStart = ps.StartTime.UnixMilli()
-		event.ProcessingEnd = time.Now().UnixMilli()
-	}
-
-	if err != nil {
-		event.Error = err.Error()
-		event.FailureType = failureType
-	}
-
-	ps.Lifecycle = append(ps.Lifecycle, event)
-}
-
-func (ps *ProcessingState) GetProcessingDuration() int64 {
-	if ps.StartTime.IsZero() {
-		return 0
-	}
-	return time.Since(ps.StartTime).Milliseconds()
-}
-
-type ValidationError struct {
-	Type    schema.FailureType
-	Message string
-}
-
-func (e ValidationError) Error() string {
-	return e.Message
-}
-
-func classifyError(err error) schema.FailureType {
-	if err == nil {
-		return ""
-	}
-
-	var validationErr ValidationError
-	if errors.As(err, &validationErr) {
-		return validationErr.Type
-	}
-
-	errStr := err.Error()
-	if strings.Contains(errStr, "connection refused") ||
-		strings.Contains(errStr, "timeout") ||
-		strings.Contains(errStr, "temporary failure") ||
-		strings.Contains(errStr, "context deadline exceeded") {
-		return schema.FailureTypeRetryable
-	}
-
-	if strings.Contains(errStr, "no such file") ||
-		strings.Contains(errStr, "permission denied") ||
-		strings.Contains(errStr, "invalid image format") ||
-		strings.Contains(errStr, "unsupported") {
-		return schema.FailureTypePermanent
-	}
-
-	return schema.FailureTypeRetryable
+// pdfPassword returns the user password for encrypted PDFs, if the job
+// supplies one in the "pdf_password" file attribute.
+func pdfPassword(job contracts.Job) string {
+	password, _ := job.File.Attributes["pdf_password"].(string)
+	return password
 }
 
 func publishLifecycleEvent(nc *bus.Client, subject string, event schema.ThumbnailLifecycleEvent) {
@@ -205,104 +88,13 @@ func publishLifecycleEvent(nc *bus.Client, subject string, event schema.Thumbnai
 	}
 }
 
-func publishEventsStep(nc *bus.Client, subject string, state *ProcessingState, results []schema.ThumbnailResult, sourcePath string, cause error, failureType schema.FailureType) {
-	totalProcessed := len(results)
-	totalFailed := 0
-
-	for _, result := range results {
-		if result.Status != "processed" {
-			totalFailed++
-		}
-	}
-
-	done := schema.ThumbnailDone{
-		ID:               state.JobID,
-		SourcePath:       sourcePath,
-		ParentContentID:  state.ParentContentID,
-		ParentStatus:     state.ParentStatus,
-		TotalProcessed:   totalProcessed,
-		TotalFailed:      totalFailed,
-		ProcessingTimeMs: state.GetProcessingDuration(),
-		Results:          results,
-		Lifecycle:        state.Lifecycle,
-		HappenedAt:       time.Now().Unix(),
-	}
-
-	if cause != nil {
-		done.Error = cause.Error()
-		done.FailureType = failureType
-	}
-
+func publishEventsStep(nc *bus.Client, subject string, state *worker.ProcessingState, results []schema.ThumbnailResult, sourcePath string, cause error, failureType schema.FailureType) {
+	done := state.Done(results, sourcePath, cause, failureType)
 	if err := nc.PublishJSON(subject, done); err != nil {
 		slog.Error("publish result failed", "subject", subject, "id", state.JobID, "err", err)
 	}
 }
 
-func validateParentContentStep(ctx context.Context, parent *simplecontent.Content, contentSvc simplecontent.Service, logger *slog.Logger) error {
-	if parent.Status != string(simplecontent.ContentStatusUploaded) {
-		logger.Warn("parent content not ready for derivation", "status", parent.Status, "required", "uploaded")
-		return ValidationError{
-			Type:    schema.FailureTypeValidation,
-			Message: fmt.Sprintf("parent content status is '%s', expected 'uploaded'", parent.Status),
-		}
-	}
-
-	logger.Info("parent content validation passed", "content_id", parent.ID, "status", parent.Status)
-	return nil
-}
-
-func createDerivedContentRecords(ctx context.Context, parent *simplecontent.Content, thumbnailSizes []SizeConfig, contentSvc simplecontent.Service, logger *slog.Logger) (map[string]uuid.UUID, error) {
-	derivedContentIDs := make(map[string]uuid.UUID, len(thumbnailSizes))
-
-	for _, size := range thumbnailSizes {
-		variant := deriveSizeVariant(size.Width, size.Height)
-		metadata := map[string]interface{}{
-			"width":  size.Width,
-			"height": size.Height,
-		}
-
-		derived, err := contentSvc.CreateDerivedContent(ctx, simplecontent.CreateDerivedContentRequest{
-			ParentID:       parent.ID,
-			OwnerID:        parent.OwnerID,
-			TenantID:       parent.TenantID,
-			DerivationType: "thumbnail",
-			Variant:        variant,
-			Metadata:       metadata,
-			InitialStatus:  simplecontent.ContentStatusCreated,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("create derived content for size %s: %w", size.Name, err)
-		}
-
-		derivedContentIDs[size.Name] = derived.ID
-		logger.Info("created derived content placeholder",
-			"size", size.Name,
-			"content_id", derived.ID,
-			"status", derived.Status)
-	}
-
-	return derivedContentIDs, nil
-}
-
-func deriveSizeVariant(width, height int) string {
-	if width == height {
-		return fmt.Sprintf("thumbnail_%d", width)
-	}
-	return fmt.Sprintf("thumbnail_%dx%d", width, height)
-}
-
-func updateDerivedContentStatusAfterDownload(ctx context.Context, derivedContentIDs map[string]uuid.UUID, contentSvc simplecontent.Service, logger *slog.Logger) error {
-	for sizeName, contentID := range derivedContentIDs {
-		if err := contentSvc.UpdateContentStatus(ctx, contentID, simplecontent.ContentStatusProcessing); err != nil {
-			return fmt.Errorf("update status for size %s (content_id=%s): %w", sizeName, contentID, err)
-		}
-		logger.Info("updated derived content status to processing",
-			"size", sizeName,
-			"content_id", contentID)
-	}
-	return nil
-}
-
 func fetchSourceStep(ctx context.Context, contentID uuid.UUID, uploader *upload.Client, logger *slog.Logger) (*upload.Source, func() error, error) {
 	source, cleanup, err := uploader.FetchSource(ctx, contentID)
 	if err != nil {
@@ -313,163 +105,7 @@ func fetchSourceStep(ctx context.Context, contentID uuid.UUID, uploader *upload.
 	return source, cleanup, nil
 }
 
-func uploadResultsStep(ctx context.Context, parent *simplecontent.Content, thumbnails []img.ThumbnailOutput, source *upload.Source, uploader *upload.Client, state *ProcessingState, contentSvc simplecontent.Service, logger *slog.Logger) ([]schema.ThumbnailResult, error) {
-	var results []schema.ThumbnailResult
-
-	for _, thumb := range thumbnails {
-		processingStart := time.Now()
-
-		derivedContentID, ok := state.DerivedContentIDs[thumb.Name]
-		if !ok {
-			logger.Error("derived content ID not found for size", "size", thumb.Name)
-			return nil, fmt.Errorf("derived content ID not found for size %s", thumb.Name)
-		}
-
-		_, err := uploader.UploadThumbnailObject(ctx, derivedContentID, thumb.Path, upload.UploadOptions{
-			FileName: source.Filename,
-			MimeType: thumbnailUploadMimeType(thumb, source),
-			Width:    thumb.Width,
-			Height:   thumb.Height,
-		})
-
-		processingTime := time.Since(processingStart).Milliseconds()
-
-		if err != nil {
-			logger.Error("upload thumbnail failed", "size", thumb.Name, "err", err)
-
-			results = append(results, schema.ThumbnailResult{
-				Size:   thumb.Name,
-				Width:  thumb.Width,
-				Height: thumb.Height,
-				Status: "failed",
-				DerivationParams: &schema.DerivationParams{
-					SourceWidth:    thumb.SourceWidth,
-					SourceHeight:   thumb.SourceHeight,
-					TargetWidth:    thumb.Width,
-					TargetHeight:   thumb.Height,
-					Algorithm:      "lanczos",
-					ProcessingTime: processingTime,
-					GeneratedAt:    time.Now().Unix(),
-				},
-			})
-			continue
-		}
-
-		if err := contentSvc.UpdateContentStatus(ctx, derivedContentID, simplecontent.ContentStatusProcessed); err != nil {
-			logger.Error("update content status to processed failed", "size", thumb.Name, "content_id", derivedContentID, "err", err)
-			results = append(results, schema.ThumbnailResult{
-				Size:   thumb.Name,
-				Width:  thumb.Width,
-				Height: thumb.Height,
-				Status: "failed",
-				DerivationParams: &schema.DerivationParams{
-					SourceWidth:    thumb.SourceWidth,
-					SourceHeight:   thumb.SourceHeight,
-					TargetWidth:    thumb.Width,
-					TargetHeight:   thumb.Height,
-					Algorithm:      "lanczos",
-					ProcessingTime: processingTime,
-					GeneratedAt:    time.Now().Unix(),
-				},
-			})
-			continue
-		}
-
-		derivationParams := &schema.DerivationParams{
-			SourceWidth:    thumb.SourceWidth,
-			SourceHeight:   thumb.SourceHeight,
-			TargetWidth:    thumb.Width,
-			TargetHeight:   thumb.Height,
-			Algorithm:      "lanczos",
-			ProcessingTime: processingTime,
-			GeneratedAt:    time.Now().Unix(),
-		}
-
-		results = append(results, schema.ThumbnailResult{
-			Size:             thumb.Name,
-			ContentID:        derivedContentID.String(),
-			UploadURL:        "",
-			Width:            thumb.Width,
-			Height:           thumb.Height,
-			Status:           "processed",
-			DerivationParams: derivationParams,
-		})
-
-		logger.Info("thumbnail uploaded successfully", "size", thumb.Name, "content_id", derivedContentID, "processing_time_ms", processingTime)
-		os.Remove(thumb.Path)
-	}
-
-	return results, nil
-}
-
-func parseThumbnailSizesHint(hints map[string]string, availableSizes []SizeConfig) []SizeConfig {
-	if hints == nil {
-		return availableSizes
-	}
-
-	sizesHint := hints["thumbnail_sizes"]
-	if sizesHint == "" {
-		return availableSizes
-	}
-
-	requestedSizes := strings.Split(sizesHint, ",")
-	var selectedSizes []SizeConfig
-
-	for _, requested := range requestedSizes {
-		requested = strings.TrimSpace(requested)
-		for _, available := range availableSizes {
-			if available.Name == requested {
-				selectedSizes = append(selectedSizes, available)
-				break
-			}
-		}
-	}
-
-	if len(selectedSizes) == 0 {
-		return availableSizes
-	}
-
-	return selectedSizes
-}
-
-func buildThumbPath(baseDir, contentID, name string) string {
-	base := filepath.Base(name)
-	if base == "" || base == "." {
-		base = "source"
-	}
-	return filepath.Join(baseDir, contentID+"_thumb_"+base)
-}
-
-func generateThumbnailsForSource(ctx context.Context, source *upload.Source, basePath string, specs []img.ThumbnailSpec) ([]img.ThumbnailOutput, error) {
-	if source == nil {
-		return nil, errors.New("source is required")
-	}
-
-	mimeType := strings.TrimSpace(source.MimeType)
-	if mimeType == "" {
-		return img.GenerateThumbnails(source.Path, basePath, specs)
-	}
-
-	generator, err := img.GetGenerator(mimeType)
-	if err != nil {
-		return nil, fmt.Errorf("select thumbnail generator: %w", err)
-	}
-	return generator.Generate(ctx, source.Path, basePath, specs)
-}
-
-func thumbnailUploadMimeType(thumb img.ThumbnailOutput, source *upload.Source) string {
-	if ext := filepath.Ext(thumb.Path); ext != "" {
-		if mimeType := mime.TypeByExtension(ext); mimeType != "" {
-			return mimeType
-		}
-	}
-	if source != nil {
-		return source.MimeType
-	}
-	return ""
-}
-
-func handleJob(ctx context.Context, job contracts.Job, cfg WorkerConfig, thumbnailSizes []SizeConfig, contentSvc simplecontent.Service, uploader *upload.Client, nc *bus.Client, logger *slog.Logger) error {
+func handleJob(ctx context.Context, job contracts.Job, cfg WorkerConfig, thumbnailSizes []worker.SizeConfig, limits img.Limits, contentSvc simplecontent.Service, uploader *upload.Client, nc *bus.Client, logger *slog.Logger) error {
 	jobLogger := logger.With("job_id", job.JobID)
 	sourcePath := job.File.Blob.Location
 	jobLogger.Info("received job", "file_id", job.File.ID, "source", sourcePath)
@@ -488,7 +124,7 @@ func handleJob(ctx context.Context, job contracts.Job, cfg WorkerConfig, thumbna
 	if contentIDValue == "" {
 		err := fmt.Errorf("job %s missing content_id", job.JobID)
 		jobLogger.Warn("missing content identifier")
-		state := &ProcessingState{JobID: job.JobID}
+		state := &worker.ProcessingState{JobID: job.JobID}
 		publishEventsStep(nc, cfg.ResultSubject, state, nil, sourcePath, err, schema.FailureTypeValidation)
 		return err
 	}
@@ -496,19 +132,19 @@ func handleJob(ctx context.Context, job contracts.Job, cfg WorkerConfig, thumbna
 	contentID, err := uuid.Parse(contentIDValue)
 	if err != nil {
 		jobLogger.Warn("invalid content identifier", "content_id", contentIDValue, "err", err)
-		state := &ProcessingState{JobID: job.JobID}
+		state := &worker.ProcessingState{JobID: job.JobID}
 		publishEventsStep(nc, cfg.ResultSubject, state, nil, sourcePath, err, schema.FailureTypeValidation)
 		return fmt.Errorf("parse content id: %w", err)
 	}
 	contentLogger := jobLogger.With("content_id", contentID.String())
 
-	thumbnailSizesForJob := parseThumbnailSizesHint(job.Hints, thumbnailSizes)
+	thumbnailSizesForJob := worker.ParseThumbnailSizesHint(job.Hints, thumbnailSizes)
 	sizeNames := make([]string, len(thumbnailSizesForJob))
 	for i, size := range thumbnailSizesForJob {
 		sizeNames[i] = size.Name
 	}
 
-	state := &ProcessingState{
+	state := &worker.ProcessingState{
 		JobID:             job.JobID,
 		ParentContentID:   contentID.String(),
 		ThumbnailSizes:    sizeNames,
@@ -517,10 +153,19 @@ func handleJob(ctx context.Context, job contracts.Job, cfg WorkerConfig, thumbna
 		Lifecycle:         make([]schema.ThumbnailLifecycleEvent, 0),
 	}
 
+	specs, err := worker.BuildThumbnailSpecs(thumbnailSizesForJob, job.Hints)
+	if err != nil {
+		contentLogger.Warn("invalid thumbnail options", "err", err)
+		err = worker.ValidationError{Type: schema.FailureTypeValidation, Message: err.Error()}
+		state.AddLifecycleEvent(schema.StageFailed, err, schema.FailureTypeValidation)
+		publishEventsStep(nc, cfg.ResultSubject, state, nil, sourcePath, err, schema.FailureTypeValidation)
+		return err
+	}
+
 	parent, err := contentSvc.GetContent(ctx, contentID)
 	if err != nil {
 		contentLogger.Error("fetch content failed", "err", err)
-		failureType := classifyError(err)
+		failureType := worker.ClassifyError(err)
 		state.AddLifecycleEvent(schema.StageFailed, err, failureType)
 		publishEventsStep(nc, cfg.ResultSubject, state, nil, sourcePath, err, failureType)
 		return fmt.Errorf("fetch content: %w", err)
@@ -529,17 +174,17 @@ func handleJob(ctx context.Context, job contracts.Job, cfg WorkerConfig, thumbna
 	state.ParentStatus = parent.Status
 	state.AddLifecycleEvent(schema.StageValidation, nil, "")
 
-	if err := validateParentContentStep(ctx, parent, contentSvc, contentLogger); err != nil {
-		failureType := classifyError(err)
+	if err := worker.ValidateParentContent(parent, contentLogger); err != nil {
+		failureType := worker.ClassifyError(err)
 		state.AddLifecycleEvent(schema.StageFailed, err, failureType)
 		publishEventsStep(nc, cfg.ResultSubject, state, nil, sourcePath, err, failureType)
 		return err
 	}
 
-	derivedContentIDs, err := createDerivedContentRecords(ctx, parent, thumbnailSizesForJob, contentSvc, contentLogger)
+	derivedContentIDs, err := worker.CreateDerivedContentRecords(ctx, parent, specs, contentSvc, contentLogger)
 	if err != nil {
 		contentLogger.Error("create derived content records failed", "err", err)
-		failureType := classifyError(err)
+		failureType := worker.ClassifyError(err)
 		state.AddLifecycleEvent(schema.StageFailed, err, failureType)
 		publishEventsStep(nc, cfg.ResultSubject, state, nil, sourcePath, err, failureType)
 		return fmt.Errorf("create derived content records: %w", err)
@@ -549,16 +194,16 @@ func handleJob(ctx context.Context, job contracts.Job, cfg WorkerConfig, thumbna
 
 	source, cleanup, err := fetchSourceStep(ctx, contentID, uploader, contentLogger)
 	if err != nil {
-		failureType := classifyError(err)
+		failureType := worker.ClassifyError(err)
 		state.AddLifecycleEvent(schema.StageFailed, err, failureType)
 		publishEventsStep(nc, cfg.ResultSubject, state, nil, sourcePath, err, failureType)
 		return err
 	}
 	defer cleanup()
 
-	if err := updateDerivedContentStatusAfterDownload(ctx, state.DerivedContentIDs, contentSvc, contentLogger); err != nil {
+	if err := worker.UpdateDerivedContentStatusAfterDownload(ctx, state.DerivedContentIDs, contentSvc, contentLogger); err != nil {
 		contentLogger.Error("update derived content status failed", "err", err)
-		failureType := classifyError(err)
+		failureType := worker.ClassifyError(err)
 		state.AddLifecycleEvent(schema.StageFailed, err, failureType)
 		publishEventsStep(nc, cfg.ResultSubject, state, nil, sourcePath, err, failureType)
 		return fmt.Errorf("update derived content status: %w", err)
@@ -584,36 +229,41 @@ func handleJob(ctx context.Context, job contracts.Job, cfg WorkerConfig, thumbna
 	}
 	contentLogger.Info("resolved thumbnail filename", "name", name, "mime_type", source.MimeType)
 
-	basePath := buildThumbPath(cfg.ThumbDir, contentID.String(), name)
-	specs := make([]img.ThumbnailSpec, len(thumbnailSizesForJob))
-	for i, size := range thumbnailSizesForJob {
-		specs[i] = img.ThumbnailSpec{
-			Name:   size.Name,
-			Width:  size.Width,
-			Height: size.Height,
-		}
-	}
+	basePath := worker.BuildThumbPath(cfg.ThumbDir, contentID.String(), name)
 
-	thumbnails, err := generateThumbnailsForSource(ctx, source, basePath, specs)
+	thumbnails, err := worker.GenerateThumbnails(ctx, source, basePath, specs, limits, pdfPassword(job))
 	if err != nil {
 		contentLogger.Error("thumbnail generation failed", "err", err)
-		failureType := classifyError(err)
+		failureType := worker.ClassifyError(err)
 		state.AddLifecycleEvent(schema.StageFailed, err, failureType)
 		publishEventsStep(nc, cfg.ResultSubject, state, nil, sourcePath, err, failureType)
 		return fmt.Errorf("generate thumbnails: %w", err)
 	}
 	contentLogger.Info("thumbnails generated", "count", len(thumbnails))
 
+	// Source analyses read the generated files, so run them before upload removes them
+	worker.AnalyzeThumbnails(thumbnails, state, contentLogger)
+
+	if err := worker.CreateVariantContentRecords(ctx, parent, specs, thumbnails, state, contentSvc, contentLogger); err != nil {
+		contentLogger.Error("create variant content records failed", "err", err)
+		failureType := worker.ClassifyError(err)
+		state.AddLifecycleEvent(schema.StageFailed, err, failureType)
+		publishEventsStep(nc, cfg.ResultSubject, state, nil, sourcePath, err, failureType)
+		return err
+	}
+
 	state.AddLifecycleEvent(schema.StageUpload, nil, "")
 	publishLifecycleEvent(nc, cfg.ResultSubject, state.Lifecycle[len(state.Lifecycle)-1])
 
-	results, err := uploadResultsStep(ctx, parent, thumbnails, source, uploader, state, contentSvc, contentLogger)
+	results, err := worker.UploadResults(ctx, thumbnails, source, uploader, state, contentSvc, contentLogger)
 	if err != nil {
-		failureType := classifyError(err)
+		failureType := worker.ClassifyError(err)
 		state.AddLifecycleEvent(schema.StageFailed, err, failureType)
 		publishEventsStep(nc, cfg.ResultSubject, state, nil, sourcePath, err, failureType)
 		return err
 	}
+	worker.RecordSourceMetadata(ctx, parent.ID, results, state, uploader, contentLogger)
+	state.Storyboards = worker.StoryboardsFor(thumbnails, results)
 
 	state.AddLifecycleEvent(schema.StageCompleted, nil, "")
 	publishEventsStep(nc, cfg.ResultSubject, state, results, sourcePath, nil, "")
@@ -676,11 +326,17 @@ func main() {
 	// Map environment variables to simple-content format
 	mapEnvVarsForSimpleContent(cfg)
 
-	thumbnailSizes, err := parseThumbnailSizes(cfg.WorkerConfig.ThumbnailSizes)
+	thumbnailSizes, err := worker.ParseThumbnailSizes(cfg.WorkerConfig.ThumbnailSizes)
 	if err != nil {
 		fatal(logger, "parse thumbnail sizes", err)
 	}
 
+	// Source limits; 0 disables a limit (see img.Limits)
+	limits, err := worker.LoadLimits()
+	if err != nil {
+		fatal(logger, "load source limits", err)
+	}
+
 	logger.Info("worker starting",
 		"nats_url", cfg.WorkerConfig.NATSURL,
 		"job_subject", cfg.WorkerConfig.JobSubject,
@@ -742,7 +398,7 @@ func main() {
 	defer nc.Close()
 
 	_, err = natsbus.SubscribeWorker(nc.Conn(), cfg.WorkerConfig.JobSubject, cfg.WorkerConfig.WorkerQueue, func(jobCtx context.Context, job contracts.Job) error {
-		return handleJob(jobCtx, job, cfg.WorkerConfig, thumbnailSizes, contentSvc, uploader, nc, logger)
+		return handleJob(jobCtx, job, cfg.WorkerConfig, thumbnailSizes, limits, contentSvc, uploader, nc, logger)
 	})
 	if err != nil {
 		fatal(logger, "subscribe worker", err, "job_subject", cfg.WorkerConfig.JobSubject, "queue", cfg.WorkerConfig.WorkerQueue)
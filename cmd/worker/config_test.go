@@ -3,8 +3,9 @@
 package main
 
 import (
-	"path/filepath"
 	"testing"
+
+	"github.com/tendant/simple-thumbnailer/internal/img"
 )
 
 func TestLoadConfigDefaults(t *testing.T) {
@@ -44,15 +45,30 @@ func TestLoadConfigInvalidWidth(t *testing.T) {
 	}
 }
 
-func TestBuildThumbPath(t *testing.T) {
-	thumb := BuildThumbPath("/data/thumbs", "abc", filepath.Join("/tmp", "photo.jpg"))
-	expected := filepath.Join("/data/thumbs", "abc_thumb_photo.jpg")
-	if thumb != expected {
-		t.Fatalf("buildThumbPath mismatch: got %s want %s", thumb, expected)
+func TestLoadConfigLimits(t *testing.T) {
+	t.Setenv("MAX_SOURCE_PIXELS", "")
+	t.Setenv("MAX_PDF_PAGES", "")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if cfg.Limits != img.DefaultLimits {
+		t.Errorf("expected default limits, got %+v", cfg.Limits)
+	}
+
+	t.Setenv("MAX_SOURCE_PIXELS", "1000")
+	t.Setenv("MAX_PDF_PAGES", "0")
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if cfg.Limits.MaxPixels != 1000 || cfg.Limits.MaxPages != 0 {
+		t.Errorf("expected overridden limits, got %+v", cfg.Limits)
 	}
 
-	thumb = BuildThumbPath("/data/thumbs", "abc", "")
-	if filepath.Base(thumb) != "abc_thumb_source" {
-		t.Fatalf("expected fallback filename, got %s", thumb)
+	t.Setenv("MAX_SOURCE_PIXELS", "-1")
+	if _, err := LoadConfig(); err == nil {
+		t.Fatal("expected error for negative MAX_SOURCE_PIXELS")
 	}
 }
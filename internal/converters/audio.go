@@ -0,0 +1,355 @@
+package converters
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const (
+	// waveformSampleRate is the rate audio is decoded at for waveforms; the
+	// envelope needs far less than the source rate
+	waveformSampleRate = 8000
+	// waveformWindow is the number of samples per envelope window (10ms)
+	waveformWindow = waveformSampleRate / 100
+
+	// Default waveform size when Convert is called without one
+	defaultWaveformWidth  = 800
+	defaultWaveformHeight = 200
+)
+
+var (
+	// WaveformColor is the default colour of waveform bars
+	WaveformColor = color.NRGBA{R: 0x33, G: 0x33, B: 0x33, A: 0xff}
+	// WaveformBackground is the default colour behind waveform bars
+	WaveformBackground = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+// AudioConverter uses FFmpeg to thumbnail audio files. Files with embedded
+// cover art are thumbnailed with the art; the rest get a waveform rendered
+// from their samples.
+type AudioConverter struct{}
+
+// NewAudioConverter creates a new FFmpeg-based audio converter
+func NewAudioConverter() *AudioConverter {
+	return &AudioConverter{}
+}
+
+// Name returns the converter name
+func (a *AudioConverter) Name() string {
+	return "audio"
+}
+
+// Supports returns true if this converter can handle the given MIME type
+func (a *AudioConverter) Supports(mimeType string) bool {
+	return strings.HasPrefix(strings.ToLower(mimeType), "audio/")
+}
+
+// Convert generates a thumbnail from an audio file
+// It uses the embedded cover art when there is one and a waveform otherwise
+func (a *AudioConverter) Convert(ctx context.Context, input, output string, width, height int) error {
+	return a.ConvertWithOptions(ctx, input, output, width, height, ConversionOptions{})
+}
+
+// ConvertWithOptions generates a thumbnail from an audio file using explicit encoding options.
+// Cover art fits inside width x height; waveforms are drawn at exactly that
+// size, or 800x200 when either is 0. opts.Progressive is ignored.
+func (a *AudioConverter) ConvertWithOptions(ctx context.Context, input, output string, width, height int, opts ConversionOptions) error {
+	info, err := a.Probe(ctx, input)
+	if err != nil {
+		return err
+	}
+	if info.Width > 0 && info.Height > 0 {
+		return a.ExtractCoverArt(ctx, input, output, width, height, opts)
+	}
+
+	waveform, err := a.DecodeWaveform(ctx, input)
+	if err != nil {
+		return err
+	}
+	if width <= 0 || height <= 0 {
+		width, height = defaultWaveformWidth, defaultWaveformHeight
+	}
+	return writeImage(output, waveform.Render(width, height, WaveformColor, WaveformBackground), opts)
+}
+
+// ExtractCoverArt writes the embedded cover art of input, scaled to fit
+// inside width x height (or at full size when either is 0).
+func (a *AudioConverter) ExtractCoverArt(ctx context.Context, input, output string, width, height int, opts ConversionOptions) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("ffmpeg not found in PATH: %w", err)
+	}
+
+	// Cover art is stored as a single-frame video stream
+	args := []string{
+		"-v", "error",
+		"-i", input,
+		"-map", "0:v:0",
+		"-an",
+		"-frames:v", "1",
+	}
+	if width > 0 && height > 0 {
+		args = append(args, "-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease", width, height))
+	}
+
+	switch format := opts.outputFormat(output); format {
+	case "jpeg":
+		args = append(args, "-c:v", "mjpeg", "-pix_fmt", "yuvj420p", "-q:v", strconv.Itoa(ffmpegQScale(opts.Quality)))
+	case "png":
+		args = append(args, "-c:v", "png", "-pix_fmt", "rgb24")
+	default:
+		return fmt.Errorf("unsupported output format for ffmpeg: %s", format)
+	}
+	args = append(args, "-y", output)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg cover art extraction failed: %w\nOutput: %s", err, string(out))
+	}
+	return nil
+}
+
+// Waveform is the amplitude envelope of an audio track: the peak of each
+// 10ms window of the first audio stream, mixed down to mono.
+type Waveform struct {
+	peaks []uint16
+}
+
+// DecodeWaveform decodes the first audio stream of input to PCM and reduces
+// it to its envelope. Samples are streamed, so memory grows with the
+// duration at 200 bytes per second rather than with the source.
+func (a *AudioConverter) DecodeWaveform(ctx context.Context, input string) (*Waveform, error) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return nil, fmt.Errorf("ffmpeg not found in PATH: %w", err)
+	}
+
+	// -ac 1 -ar 8000: Mono at a low rate is plenty for an envelope
+	// -f s16le: Raw little-endian 16-bit samples on stdout
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-v", "error",
+		"-i", input,
+		"-map", "0:a:0",
+		"-ac", "1",
+		"-ar", strconv.Itoa(waveformSampleRate),
+		"-f", "s16le",
+		"-acodec", "pcm_s16le",
+		"-",
+	)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start ffmpeg: %w", err)
+	}
+
+	waveform, readErr := readWaveform(bufio.NewReader(stdout))
+	if readErr != nil {
+		// Let FFmpeg finish writing so Wait does not block
+		io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("ffmpeg audio decoding failed: %w\nOutput: %s", err, stderr.String())
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("read samples: %w", readErr)
+	}
+	if len(waveform.peaks) == 0 {
+		return nil, errors.New("audio stream has no samples")
+	}
+	return waveform, nil
+}
+
+// readWaveform reduces signed 16-bit little-endian mono samples to the peak
+// of each waveformWindow samples.
+func readWaveform(r io.Reader) (*Waveform, error) {
+	w := &Waveform{}
+	buf := make([]byte, 2*waveformWindow)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n >= 2 {
+			var peak uint16
+			for i := 0; i+1 < n; i += 2 {
+				// Widen before negating: -32768 has no int16 counterpart
+				v := int32(int16(binary.LittleEndian.Uint16(buf[i:])))
+				if v < 0 {
+					v = -v
+				}
+				peak = max(peak, uint16(v))
+			}
+			w.peaks = append(w.peaks, peak)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return w, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Columns returns the envelope resampled to n columns, each the peak of the
+// windows it covers, normalised so the loudest column is 1. A silent track
+// is all zeros.
+func (w *Waveform) Columns(n int) []float64 {
+	columns := make([]float64, max(n, 0))
+	if len(w.peaks) == 0 {
+		return columns
+	}
+
+	var loudest uint16
+	for i := range columns {
+		// Each column covers at least one window, so short tracks stretch
+		start := i * len(w.peaks) / n
+		end := max((i+1)*len(w.peaks)/n, start+1)
+		var peak uint16
+		for _, p := range w.peaks[start:end] {
+			peak = max(peak, p)
+		}
+		columns[i] = float64(peak)
+		loudest = max(loudest, peak)
+	}
+	if loudest == 0 {
+		return columns
+	}
+	for i := range columns {
+		columns[i] /= float64(loudest)
+	}
+	return columns
+}
+
+// Render draws the waveform at width x height: one bar per pixel column,
+// mirrored about the horizontal centre line, in fg on bg. Silence still
+// draws the centre line.
+func (w *Waveform) Render(width, height int, fg, bg color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	fgc := color.NRGBAModel.Convert(fg).(color.NRGBA)
+	bgc := color.NRGBAModel.Convert(bg).(color.NRGBA)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, bgc)
+		}
+	}
+
+	mid := float64(height) / 2
+	for x, amplitude := range w.Columns(width) {
+		half := amplitude * mid
+		top := max(int(mid-half), 0)
+		bottom := min(int(mid+half+0.5), height)
+		if bottom <= top {
+			// At least one pixel so quiet passages stay visible
+			top, bottom = int(mid), min(int(mid)+1, height)
+		}
+		for y := top; y < bottom; y++ {
+			img.SetNRGBA(x, y, fgc)
+		}
+	}
+	return img
+}
+
+// writeImage encodes img to output as JPEG or PNG, chosen like FFmpeg's
+// output by opts.Format or the output extension.
+func writeImage(output string, img image.Image, opts ConversionOptions) error {
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+
+	switch format := opts.outputFormat(output); format {
+	case "jpeg":
+		quality := opts.Quality
+		if quality <= 0 {
+			quality = jpeg.DefaultQuality
+		}
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: min(quality, 100)})
+	case "png":
+		err = png.Encode(f, img)
+	default:
+		err = fmt.Errorf("unsupported output format: %s", format)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(output)
+	}
+	return err
+}
+
+// audioProbe is the part of ffprobe's JSON output Probe reads
+type audioProbe struct {
+	Streams []struct {
+		CodecType   string `json:"codec_type"`
+		Width       int    `json:"width"`
+		Height      int    `json:"height"`
+		Disposition struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+		Size     string `json:"size"`
+	} `json:"format"`
+}
+
+// Probe returns metadata about the audio file
+// Width and Height are those of the embedded cover art, and zero without one
+func (a *AudioConverter) Probe(ctx context.Context, input string) (*FileInfo, error) {
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-show_entries", "stream=codec_type,width,height:stream_disposition=attached_pic:format=duration,size",
+		"-of", "json",
+		input,
+	)
+
+	// Keep stderr out of the JSON
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w\nOutput: %s", err, stderr.String())
+	}
+
+	var probe audioProbe
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("parse ffprobe output: %w", err)
+	}
+
+	info := &FileInfo{
+		MimeType: "audio/unknown",
+	}
+	hasAudio := false
+	for _, s := range probe.Streams {
+		switch {
+		case s.CodecType == "audio":
+			hasAudio = true
+		case s.CodecType == "video" && s.Disposition.AttachedPic == 1 && info.Width == 0:
+			info.Width, info.Height = s.Width, s.Height
+		}
+	}
+	if !hasAudio {
+		return nil, errors.New("no audio stream found")
+	}
+	if d, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		info.Duration = d
+	}
+	if s, err := strconv.ParseInt(probe.Format.Size, 10, 64); err == nil {
+		info.Size = s
+	}
+
+	return info, nil
+}
@@ -0,0 +1,94 @@
+package converters
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+)
+
+// pcm encodes samples as signed 16-bit little-endian PCM
+func pcm(samples ...int16) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, samples)
+	return buf.Bytes()
+}
+
+func TestReadWaveform(t *testing.T) {
+	// Two full windows and a partial one
+	samples := make([]int16, 2*waveformWindow+3)
+	samples[5] = 1000
+	samples[waveformWindow+7] = -32768
+	samples[2*waveformWindow+1] = -200
+
+	w, err := readWaveform(bytes.NewReader(pcm(samples...)))
+	if err != nil {
+		t.Fatalf("readWaveform returned error: %v", err)
+	}
+	want := []uint16{1000, 32768, 200}
+	if len(w.peaks) != len(want) {
+		t.Fatalf("got %d windows, want %d", len(w.peaks), len(want))
+	}
+	for i := range want {
+		if w.peaks[i] != want[i] {
+			t.Errorf("window %d peak = %d, want %d", i, w.peaks[i], want[i])
+		}
+	}
+}
+
+func TestWaveformColumns(t *testing.T) {
+	w := &Waveform{peaks: []uint16{100, 400, 200, 0}}
+
+	got := w.Columns(2)
+	if len(got) != 2 || got[0] != 1 || got[1] != 0.5 {
+		t.Errorf("Columns(2) = %v, want [1 0.5]", got)
+	}
+	// Short tracks stretch: every column maps to a window
+	got = w.Columns(8)
+	if got[2] != 1 || got[3] != 1 || got[7] != 0 {
+		t.Errorf("Columns(8) = %v", got)
+	}
+
+	silent := &Waveform{peaks: []uint16{0, 0}}
+	for _, v := range silent.Columns(4) {
+		if v != 0 {
+			t.Errorf("silent track column = %v, want 0", v)
+		}
+	}
+}
+
+func TestWaveformRender(t *testing.T) {
+	fg := color.NRGBA{R: 255, A: 255}
+	bg := color.NRGBA{B: 255, A: 255}
+	w := &Waveform{peaks: []uint16{1000, 0}}
+
+	img := w.Render(2, 10, fg, bg)
+	if b := img.Bounds(); b.Dx() != 2 || b.Dy() != 10 {
+		t.Fatalf("got %dx%d, want 2x10", b.Dx(), b.Dy())
+	}
+	// The loud column fills the height; the silent one keeps the centre line
+	for y := 0; y < 10; y++ {
+		if got := img.NRGBAAt(0, y); got != fg {
+			t.Errorf("loud column at y=%d = %v, want foreground", y, got)
+		}
+	}
+	if got := img.NRGBAAt(1, 5); got != fg {
+		t.Errorf("silent column centre = %v, want foreground", got)
+	}
+	if got := img.NRGBAAt(1, 0); got != bg {
+		t.Errorf("silent column top = %v, want background", got)
+	}
+}
+
+func TestGetConverterAudio(t *testing.T) {
+	conv, err := GetConverter("audio/mpeg")
+	if err != nil {
+		t.Fatalf("GetConverter returned error: %v", err)
+	}
+	if conv.Name() != "audio" {
+		t.Errorf("got converter %s, want audio", conv.Name())
+	}
+	if conv.Supports("video/mp4") {
+		t.Error("audio converter should leave videos to ffmpeg")
+	}
+}
@@ -1,10 +1,11 @@
 // Package converters provides interfaces and implementations for converting
-// various file types (images, videos, PDFs, documents) into thumbnails.
+// various file types (images, videos, audio, PDFs, documents) into thumbnails.
 package converters
 
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"strings"
 )
 
@@ -19,6 +20,10 @@ type Converter interface {
 	// Convert generates a thumbnail from the input file
 	Convert(ctx context.Context, input, output string, width, height int) error
 
+	// ConvertWithOptions generates a thumbnail using explicit encoding options.
+	// Convert is equivalent to ConvertWithOptions with zero-valued options.
+	ConvertWithOptions(ctx context.Context, input, output string, width, height int, opts ConversionOptions) error
+
 	// Probe returns metadata about the input file without converting it
 	Probe(ctx context.Context, input string) (*FileInfo, error)
 }
@@ -29,16 +34,33 @@ type FileInfo struct {
 	Width    int     // Width in pixels (images/videos)
 	Height   int     // Height in pixels (images/videos)
 	Duration float64 // Duration in seconds (videos/audio)
-	Pages    int     // Number of pages (PDFs/documents)
+	Pages    int     // Number of pages (PDFs/documents/TIFFs)
 	Size     int64   // File size in bytes
 }
 
-// ConversionOptions provides additional parameters for thumbnail generation
+// ConversionOptions provides additional parameters for thumbnail generation.
+// Zero values keep each converter's defaults.
 type ConversionOptions struct {
-	Quality     int    // JPEG quality (1-100)
-	Format      string // Output format (jpg, png, webp)
-	SeekTime    int    // Seek time in seconds (videos)
-	PreserveMeta bool   // Preserve EXIF metadata
+	Quality      int     // JPEG quality (1-100)
+	Format       string  // Output format (jpeg, png); empty = infer from output extension
+	Progressive  bool    // Write progressive JPEG where the tool supports it
+	SeekTime     float64 // Seek time in seconds (videos); overrides SeekPercent
+	SeekPercent  float64 // Seek position as a percentage of Duration (videos)
+	Duration     float64 // Known source duration in seconds; 0 = probe when needed
+	Page         int     // 1-based page to render (PDFs/documents); 0 = first page
+	PreserveMeta bool    // Preserve EXIF metadata
+}
+
+// outputFormat resolves the output format from opts or the output extension.
+func (o ConversionOptions) outputFormat(output string) string {
+	format := strings.ToLower(o.Format)
+	if format == "" {
+		format = strings.ToLower(strings.TrimPrefix(filepath.Ext(output), "."))
+	}
+	if format == "jpg" {
+		format = "jpeg"
+	}
+	return format
 }
 
 // GetConverter returns the appropriate converter for the given MIME type
@@ -48,8 +70,22 @@ func GetConverter(mimeType string) (Converter, error) {
 	switch {
 	case strings.HasPrefix(mimeType, "video/"):
 		return NewFFmpegConverter(), nil
+	case strings.HasPrefix(mimeType, "audio/"):
+		return NewAudioConverter(), nil
 	case mimeType == "application/pdf":
 		return NewPopplerConverter(), nil
+	case NewLibreOfficeConverter().Supports(mimeType):
+		return NewLibreOfficeConverter(), nil
+	case mimeType == "image/svg+xml":
+		return NewRSVGConverter(), nil
+	case NewHEIFConverter().Supports(mimeType):
+		return NewHEIFConverter(), nil
+	case NewRawConverter().Supports(mimeType):
+		return NewRawConverter(), nil
+	case NewTIFFConverter().Supports(mimeType):
+		return NewTIFFConverter(), nil
+	case NewPSDConverter().Supports(mimeType):
+		return NewPSDConverter(), nil
 	case strings.HasPrefix(mimeType, "image/"):
 		// For now, return nil - we'll use existing imaging library
 		// Later we can add govips here for better performance
@@ -70,8 +106,43 @@ func SupportedMimeTypes() []string {
 		"video/webm",
 		"video/x-matroska",
 		"video/x-flv",
+		// Audio
+		"audio/mpeg",
+		"audio/mp4",
+		"audio/aac",
+		"audio/flac",
+		"audio/ogg",
+		"audio/wav",
+		"audio/x-wav",
+		"audio/webm",
 		// PDFs
 		"application/pdf",
+		// Office documents (via LibreOffice)
+		"application/msword",
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"application/vnd.oasis.opendocument.text",
+		"application/rtf",
+		"text/rtf",
+		"application/vnd.ms-excel",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"application/vnd.oasis.opendocument.spreadsheet",
+		"application/vnd.ms-powerpoint",
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		"application/vnd.oasis.opendocument.presentation",
+		// SVG
+		"image/svg+xml",
+		// HEIC, HEIF and AVIF (via libheif)
+		"image/heic",
+		"image/heif",
+		"image/avif",
+		// Camera RAW
+		"image/x-canon-cr2",
+		"image/x-nikon-nef",
+		"image/x-sony-arw",
+		"image/x-adobe-dng",
+		// Multi-page TIFF and Photoshop (decoded in Go)
+		"image/tiff",
+		"image/vnd.adobe.photoshop",
 		// Images (handled by existing code)
 		"image/jpeg",
 		"image/png",
@@ -0,0 +1,248 @@
+package converters
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// heifMimeTypes are the HEIF-based image formats: HEIC from phones and AVIF
+// from the web
+var heifMimeTypes = map[string]bool{
+	"image/heic":          true,
+	"image/heif":          true,
+	"image/heic-sequence": true,
+	"image/heif-sequence": true,
+	"image/avif":          true,
+}
+
+// HEIFConverter uses libheif's heif-convert (or FFmpeg when it is missing)
+// to decode HEIC, HEIF and AVIF images
+type HEIFConverter struct{}
+
+// NewHEIFConverter creates a new HEIF and AVIF image converter
+func NewHEIFConverter() *HEIFConverter {
+	return &HEIFConverter{}
+}
+
+// Name returns the converter name
+func (h *HEIFConverter) Name() string {
+	return "heif"
+}
+
+// Supports returns true if this converter can handle the given MIME type
+func (h *HEIFConverter) Supports(mimeType string) bool {
+	return heifMimeTypes[strings.ToLower(mimeType)]
+}
+
+// Convert generates a thumbnail from a HEIF or AVIF image
+// It scales the image to fit inside width x height, keeping its aspect ratio
+func (h *HEIFConverter) Convert(ctx context.Context, input, output string, width, height int) error {
+	return h.ConvertWithOptions(ctx, input, output, width, height, ConversionOptions{})
+}
+
+// ConvertWithOptions generates a thumbnail from a HEIF or AVIF image using explicit encoding options.
+// The image is decoded at full size and scaled in Go. opts.Progressive is ignored.
+func (h *HEIFConverter) ConvertWithOptions(ctx context.Context, input, output string, width, height int, opts ConversionOptions) error {
+	tmpDir, err := os.MkdirTemp("", "heif-")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	decoded := filepath.Join(tmpDir, "image.png")
+	if err := h.Decode(ctx, input, decoded); err != nil {
+		return err
+	}
+	img, err := imaging.Open(decoded)
+	if err != nil {
+		return fmt.Errorf("open decoded image: %w", err)
+	}
+	if width > 0 && height > 0 {
+		img = imaging.Fit(img, width, height, imaging.Lanczos)
+	}
+	return writeImage(output, img, opts)
+}
+
+// Decode writes the primary image of input to output as a full-size PNG.
+// Orientation is applied while decoding: HEIF stores it as rotation and
+// mirror transforms (irot, imir), which phones write alongside the EXIF
+// orientation tag. The PNG carries no EXIF, so nothing rotates it twice.
+func (h *HEIFConverter) Decode(ctx context.Context, input, output string) error {
+	tool, err := lookPathHEIF()
+	if err != nil {
+		return err
+	}
+	if filepath.Base(tool) == "ffmpeg" {
+		return decodeWithFFmpeg(ctx, input, output)
+	}
+
+	// heif-convert picks the format from the output extension and writes
+	// auxiliary images (depth maps) next to it, so give it its own directory
+	tmpDir, err := os.MkdirTemp(filepath.Dir(output), "heif-")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	decoded := filepath.Join(tmpDir, "image.png")
+
+	cmd := exec.CommandContext(ctx, tool, input, decoded)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w\nOutput: %s", filepath.Base(tool), err, string(out))
+	}
+
+	// Files with several top-level images (bursts) are numbered from 1
+	if _, err := os.Stat(decoded); err != nil {
+		decoded = filepath.Join(tmpDir, "image-1.png")
+	}
+	if err := os.Rename(decoded, output); err != nil {
+		return fmt.Errorf("%s produced no image: %w", filepath.Base(tool), err)
+	}
+	return nil
+}
+
+// decodeWithFFmpeg decodes the first image with FFmpeg, which reads AVIF and,
+// from version 7.1, HEIC. FFmpeg applies the rotation itself.
+func decodeWithFFmpeg(ctx context.Context, input, output string) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-v", "error",
+		"-i", input,
+		"-frames:v", "1",
+		"-c:v", "png",
+		"-y", output,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg image decoding failed: %w\nOutput: %s", err, string(out))
+	}
+	return nil
+}
+
+// lookPathHEIF finds a decoder: heif-convert, renamed heif-dec in libheif
+// 1.18, or FFmpeg as a last resort
+func lookPathHEIF() (string, error) {
+	for _, name := range []string{"heif-dec", "heif-convert", "ffmpeg"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("heif-convert not found in PATH (install with: brew install libheif)")
+}
+
+// Probe returns metadata about the HEIF or AVIF image
+// Dimensions are read from the container without decoding: the largest image
+// spatial extent (ispe) property, which belongs to the primary image rather
+// than its tiles or thumbnail. They are before rotation.
+func (h *HEIFConverter) Probe(ctx context.Context, input string) (*FileInfo, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &FileInfo{Size: stat.Size()}
+	if err := probeHEIF(f, stat.Size(), info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// errNotHEIF is returned by probeHEIF for files without a HEIF brand
+var errNotHEIF = errors.New("not a HEIF or AVIF file")
+
+// probeHEIF walks the ISO base media boxes of r to fill in the MIME type from
+// the ftyp brand and the size from the ispe properties in meta/iprp/ipco.
+func probeHEIF(r io.ReaderAt, size int64, info *FileInfo) error {
+	var walk func(offset, end int64, path string) error
+	walk = func(offset, end int64, path string) error {
+		for offset+8 <= end {
+			var header [16]byte
+			if _, err := r.ReadAt(header[:8], offset); err != nil {
+				return fmt.Errorf("read box header: %w", err)
+			}
+			boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+			boxType := string(header[4:8])
+			headerSize := int64(8)
+			switch boxSize {
+			case 0:
+				boxSize = end - offset
+			case 1:
+				if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+					return fmt.Errorf("read box header: %w", err)
+				}
+				boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+				headerSize = 16
+			}
+			if boxSize < headerSize || offset+boxSize > end {
+				return fmt.Errorf("malformed %q box", boxType)
+			}
+			body := offset + headerSize
+
+			switch path + "/" + boxType {
+			case "/ftyp":
+				var brand [4]byte
+				if _, err := r.ReadAt(brand[:], body); err != nil {
+					return fmt.Errorf("read brand: %w", err)
+				}
+				info.MimeType = heifBrandMimeType(string(brand[:]))
+			case "/meta":
+				// A full box: version and flags precede the children
+				if err := walk(body+4, offset+boxSize, "/meta"); err != nil {
+					return err
+				}
+			case "/meta/iprp", "/meta/iprp/ipco":
+				if err := walk(body, offset+boxSize, path+"/"+boxType); err != nil {
+					return err
+				}
+			case "/meta/iprp/ipco/ispe":
+				var dims [12]byte
+				if _, err := r.ReadAt(dims[:], body); err != nil {
+					return fmt.Errorf("read ispe: %w", err)
+				}
+				w := int(binary.BigEndian.Uint32(dims[4:8]))
+				h := int(binary.BigEndian.Uint32(dims[8:12]))
+				if int64(w)*int64(h) > int64(info.Width)*int64(info.Height) {
+					info.Width, info.Height = w, h
+				}
+			}
+			offset += boxSize
+		}
+		return nil
+	}
+
+	if err := walk(0, size, ""); err != nil {
+		return err
+	}
+	if info.MimeType == "" {
+		return errNotHEIF
+	}
+	return nil
+}
+
+// heifBrandMimeType maps an ftyp major brand to a MIME type
+func heifBrandMimeType(brand string) string {
+	switch brand {
+	case "avif", "avis":
+		return "image/avif"
+	case "heic", "heix", "heim", "heis":
+		return "image/heic"
+	case "hevc", "hevx", "hevm", "hevs":
+		return "image/heic-sequence"
+	case "mif1":
+		return "image/heif"
+	case "msf1":
+		return "image/heif-sequence"
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package converters
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// box encodes an ISO base media box around payload
+func box(boxType string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(b, boxType...), body...)
+}
+
+// ispe encodes an image spatial extent property
+func ispe(width, height uint32) []byte {
+	b := make([]byte, 4, 12)
+	b = binary.BigEndian.AppendUint32(b, width)
+	return box("ispe", binary.BigEndian.AppendUint32(b, height))
+}
+
+func TestHEIFProbe(t *testing.T) {
+	meta := func(props ...[]byte) []byte {
+		return box("meta", make([]byte, 4), box("hdlr", make([]byte, 24)), box("iprp", box("ipco", props...), box("ipma")))
+	}
+	tests := []struct {
+		name          string
+		data          []byte
+		mimeType      string
+		width, height int
+	}{
+		{"heic grid", bytes.Join([][]byte{
+			box("ftyp", []byte("heic\x00\x00\x00\x00mif1heic")),
+			meta(ispe(512, 512), ispe(4032, 3024), box("irot", []byte{1}), ispe(320, 240)),
+			box("mdat", []byte{0, 0}),
+		}, nil), "image/heic", 4032, 3024},
+		{"avif", bytes.Join([][]byte{
+			box("ftyp", []byte("avif\x00\x00\x00\x00mif1miaf")),
+			meta(ispe(1920, 1080)),
+		}, nil), "image/avif", 1920, 1080},
+		{"mif1", box("ftyp", []byte("mif1\x00\x00\x00\x00")), "image/heif", 0, 0},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			info, err := NewHEIFConverter().Probe(context.Background(), path)
+			if err != nil {
+				t.Fatalf("Probe returned error: %v", err)
+			}
+			if info.MimeType != tt.mimeType || info.Width != tt.width || info.Height != tt.height {
+				t.Errorf("got %s %dx%d, want %s %dx%d", info.MimeType, info.Width, info.Height, tt.mimeType, tt.width, tt.height)
+			}
+		})
+	}
+
+	var info FileInfo
+	if err := probeHEIF(bytes.NewReader(box("ftyp", []byte("isom"))), 12, &info); !errors.Is(err, errNotHEIF) {
+		t.Errorf("mp4: got %v, want errNotHEIF", err)
+	}
+	truncated := box("ftyp", []byte("heic"))[:10]
+	if err := probeHEIF(bytes.NewReader(truncated), int64(len(truncated)), &info); err == nil {
+		t.Error("truncated box: got nil error")
+	}
+}
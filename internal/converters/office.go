@@ -0,0 +1,199 @@
+package converters
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// officeFormats maps the document types LibreOffice handles to the PDF export
+// filter of the application that opens them. The filter must match the
+// application for the page range to apply.
+var officeFormats = []struct {
+	mimeType string
+	ext      string
+	filter   string
+}{
+	// Text documents
+	{"application/msword", ".doc", "writer_pdf_Export"},
+	{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", ".docx", "writer_pdf_Export"},
+	{"application/vnd.oasis.opendocument.text", ".odt", "writer_pdf_Export"},
+	{"application/rtf", ".rtf", "writer_pdf_Export"},
+	{"text/rtf", ".rtf", "writer_pdf_Export"},
+	// Spreadsheets
+	{"application/vnd.ms-excel", ".xls", "calc_pdf_Export"},
+	{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", ".xlsx", "calc_pdf_Export"},
+	{"application/vnd.oasis.opendocument.spreadsheet", ".ods", "calc_pdf_Export"},
+	// Presentations
+	{"application/vnd.ms-powerpoint", ".ppt", "impress_pdf_Export"},
+	{"application/vnd.openxmlformats-officedocument.presentationml.presentation", ".pptx", "impress_pdf_Export"},
+	{"application/vnd.oasis.opendocument.presentation", ".odp", "impress_pdf_Export"},
+}
+
+// LibreOfficeConverter uses headless LibreOffice to convert office documents
+// to PDF and Poppler to render the PDF
+type LibreOfficeConverter struct {
+	pdf *PopplerConverter
+}
+
+// NewLibreOfficeConverter creates a new LibreOffice-based document converter
+func NewLibreOfficeConverter() *LibreOfficeConverter {
+	return &LibreOfficeConverter{
+		pdf: NewPopplerConverter(),
+	}
+}
+
+// Name returns the converter name
+func (l *LibreOfficeConverter) Name() string {
+	return "libreoffice"
+}
+
+// Supports returns true if this converter can handle the given MIME type
+func (l *LibreOfficeConverter) Supports(mimeType string) bool {
+	mimeType = strings.ToLower(mimeType)
+	for _, f := range officeFormats {
+		if f.mimeType == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// Convert generates a thumbnail from an office document
+// It renders only the first page or slide at the specified resolution
+func (l *LibreOfficeConverter) Convert(ctx context.Context, input, output string, width, height int) error {
+	return l.ConvertWithOptions(ctx, input, output, width, height, ConversionOptions{})
+}
+
+// ConvertWithOptions generates a thumbnail from an office document using explicit encoding options.
+// The document type is taken from the input's extension; see ExportPDF.
+func (l *LibreOfficeConverter) ConvertWithOptions(ctx context.Context, input, output string, width, height int, opts ConversionOptions) error {
+	tmpDir, err := os.MkdirTemp("", "office-")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Export up to the requested page, then render that page
+	page := max(opts.Page, 1)
+	pdfPath := filepath.Join(tmpDir, "document.pdf")
+	if err := l.ExportPDF(ctx, input, pdfPath, "", page); err != nil {
+		return err
+	}
+	return l.pdf.ConvertWithOptions(ctx, pdfPath, output, width, height, opts)
+}
+
+// ExportPDF converts the first pages of an office document to a PDF at
+// output. The export filter is chosen by mimeType, or by the input's
+// extension when mimeType is empty; if neither is known the whole document
+// is exported. pages <= 0 also exports every page. Page ranges need
+// LibreOffice 7.4 or later.
+func (l *LibreOfficeConverter) ExportPDF(ctx context.Context, input, output, mimeType string, pages int) error {
+	soffice, err := lookPathSoffice()
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(output), "soffice-")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// LibreOffice locks its user profile, so concurrent conversions each
+	// need their own. The input is linked under a fixed name because the
+	// PDF is named after it.
+	profileDir := filepath.Join(tmpDir, "profile")
+	source := filepath.Join(tmpDir, "document"+filepath.Ext(input))
+	if err := os.Symlink(absPath(input), source); err != nil {
+		return fmt.Errorf("link input: %w", err)
+	}
+
+	target := "pdf"
+	if filter := exportFilter(mimeType, input); filter != "" && pages > 0 {
+		target = fmt.Sprintf(`pdf:%s:{"PageRange":{"type":"string","value":"1-%d"}}`, filter, pages)
+	}
+
+	// Build soffice command
+	// --headless: No UI
+	// -env:UserInstallation: Private profile for this conversion
+	// --convert-to: Target format and export filter options
+	// --outdir: Directory for the converted file
+	args := []string{
+		"--headless",
+		"-env:UserInstallation=file://" + profileDir,
+		"--convert-to", target,
+		"--outdir", tmpDir,
+		source,
+	}
+
+	cmd := exec.CommandContext(ctx, soffice, args...)
+	outputBytes, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("soffice failed: %w\nOutput: %s", err, string(outputBytes))
+	}
+
+	// soffice exits 0 even when it cannot load the document, so check the file
+	converted := filepath.Join(tmpDir, "document.pdf")
+	if _, err := os.Stat(converted); err != nil {
+		return fmt.Errorf("soffice produced no PDF\nOutput: %s", string(outputBytes))
+	}
+	if err := os.Rename(converted, output); err != nil {
+		return fmt.Errorf("failed to move output: %w", err)
+	}
+	return nil
+}
+
+// Probe returns metadata about the office document
+// Page counts are unknown until the document is converted
+func (l *LibreOfficeConverter) Probe(ctx context.Context, input string) (*FileInfo, error) {
+	stat, err := os.Stat(input)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &FileInfo{Size: stat.Size()}
+	ext := strings.ToLower(filepath.Ext(input))
+	for _, f := range officeFormats {
+		if f.ext == ext {
+			info.MimeType = f.mimeType
+			break
+		}
+	}
+	return info, nil
+}
+
+// exportFilter returns the PDF export filter for mimeType, or for the input's
+// extension when mimeType is empty
+func exportFilter(mimeType, input string) string {
+	mimeType = strings.ToLower(mimeType)
+	ext := strings.ToLower(filepath.Ext(input))
+	for _, f := range officeFormats {
+		if (mimeType != "" && f.mimeType == mimeType) || (mimeType == "" && f.ext == ext) {
+			return f.filter
+		}
+	}
+	return ""
+}
+
+// lookPathSoffice finds the LibreOffice binary, which some packages install
+// as "libreoffice" only
+func lookPathSoffice() (string, error) {
+	for _, name := range []string{"soffice", "libreoffice"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("soffice not found in PATH (install with: brew install --cask libreoffice)")
+}
+
+// absPath makes path absolute so a symlink to it resolves from any directory
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
@@ -0,0 +1,33 @@
+package converters
+
+import "testing"
+
+func TestExportFilter(t *testing.T) {
+	tests := []struct {
+		mimeType, input, want string
+	}{
+		{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", "/tmp/thumbnail-src-123", "writer_pdf_Export"},
+		{"application/vnd.ms-excel", "", "calc_pdf_Export"},
+		{"", "deck.PPTX", "impress_pdf_Export"},
+		{"", "notes.txt", ""},
+		{"application/zip", "deck.pptx", ""},
+	}
+	for _, tt := range tests {
+		if got := exportFilter(tt.mimeType, tt.input); got != tt.want {
+			t.Errorf("exportFilter(%q, %q) = %q, want %q", tt.mimeType, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGetConverterOffice(t *testing.T) {
+	conv, err := GetConverter("application/vnd.oasis.opendocument.text")
+	if err != nil {
+		t.Fatalf("GetConverter returned error: %v", err)
+	}
+	if conv.Name() != "libreoffice" {
+		t.Errorf("got converter %s, want libreoffice", conv.Name())
+	}
+	if conv.Supports("application/pdf") {
+		t.Error("libreoffice converter should leave PDFs to poppler")
+	}
+}
@@ -2,6 +2,7 @@ package converters
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,9 +11,26 @@ import (
 	"strings"
 )
 
+// ErrPDFEncrypted is returned when a PDF needs a user password that was not
+// supplied or does not match
+var ErrPDFEncrypted = errors.New("pdf is encrypted")
+
+// ErrPDFMalformed is returned when Poppler cannot parse a PDF
+var ErrPDFMalformed = errors.New("pdf is malformed")
+
+// malformedMarkers are Poppler messages that mean the file is not a readable PDF
+var malformedMarkers = []string{
+	"Syntax Error",
+	"May not be a PDF file",
+	"Couldn't read xref table",
+	"Couldn't find trailer dictionary",
+	"PDF file is damaged",
+}
+
 // PopplerConverter uses Poppler's pdftoppm to generate thumbnails from PDF files
 type PopplerConverter struct {
-	dpi int // Resolution for rendering (default 150)
+	dpi      int    // Resolution for rendering (default 150)
+	password string // User password for encrypted PDFs
 }
 
 // NewPopplerConverter creates a new Poppler-based PDF converter
@@ -35,19 +53,22 @@ func (p *PopplerConverter) Supports(mimeType string) bool {
 // Convert generates a thumbnail from a PDF file
 // It renders only the first page at the specified resolution
 func (p *PopplerConverter) Convert(ctx context.Context, input, output string, width, height int) error {
+	return p.ConvertWithOptions(ctx, input, output, width, height, ConversionOptions{})
+}
+
+// ConvertWithOptions generates a thumbnail from a PDF file using explicit encoding options.
+// It renders the page selected by opts.Page, or the first page.
+func (p *PopplerConverter) ConvertWithOptions(ctx context.Context, input, output string, width, height int, opts ConversionOptions) error {
 	// Check if pdftoppm is available
 	if _, err := exec.LookPath("pdftoppm"); err != nil {
 		return fmt.Errorf("pdftoppm not found in PATH: %w (install with: brew install poppler)", err)
 	}
 
-	// Determine output format from file extension
+	// Determine output format from options or file extension
 	ext := strings.ToLower(filepath.Ext(output))
-	var format string
-	switch ext {
-	case ".png":
-		format = "png"
-	case ".jpg", ".jpeg":
-		format = "jpeg"
+	format := opts.outputFormat(output)
+	switch format {
+	case "png", "jpeg":
 	default:
 		format = "png" // Default to PNG
 		output = strings.TrimSuffix(output, ext) + ".png"
@@ -56,22 +77,41 @@ func (p *PopplerConverter) Convert(ctx context.Context, input, output string, wi
 	// pdftoppm requires output path without extension
 	outputBase := strings.TrimSuffix(output, filepath.Ext(output))
 
+	page := "1"
+	if opts.Page > 1 {
+		page = strconv.Itoa(opts.Page)
+	}
+
 	// Build pdftoppm command
 	// -png/-jpeg: Output format
 	// -singlefile: Only convert first page (don't add page numbers to filename)
-	// -f 1 -l 1: Convert pages 1 to 1 (first page only)
+	// -f N -l N: Convert pages N to N (a single page)
 	// -scale-to: Scale to fit within this size (preserves aspect ratio)
 	// -r: Resolution in DPI
 	args := []string{
 		"-" + format,     // Output format
 		"-singlefile",    // Don't add page numbers
-		"-f", "1",        // From page 1
-		"-l", "1",        // To page 1
+		"-f", page,       // From page N
+		"-l", page,       // To page N
 		"-r", strconv.Itoa(p.dpi), // Resolution
 		input,            // Input PDF
 		outputBase,       // Output path (without extension)
 	}
 
+	// -jpegopt: JPEG quality and progressive encoding
+	if format == "jpeg" {
+		var jpegOpts []string
+		if opts.Quality > 0 {
+			jpegOpts = append(jpegOpts, "quality="+strconv.Itoa(opts.Quality))
+		}
+		if opts.Progressive {
+			jpegOpts = append(jpegOpts, "progressive=y")
+		}
+		if len(jpegOpts) > 0 {
+			args = append([]string{"-jpegopt", strings.Join(jpegOpts, ",")}, args...)
+		}
+	}
+
 	// Add scaling if dimensions specified
 	if width > 0 {
 		// pdftoppm's -scale-to uses the larger dimension
@@ -82,16 +122,19 @@ func (p *PopplerConverter) Convert(ctx context.Context, input, output string, wi
 		args = append([]string{"-scale-to", strconv.Itoa(maxDim)}, args...)
 	}
 
-	cmd := exec.CommandContext(ctx, "pdftoppm", args...)
+	cmd := exec.CommandContext(ctx, "pdftoppm", p.passwordArgs(args)...)
 
 	// Run command and capture output
 	outputBytes, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("pdftoppm failed: %w\nOutput: %s", err, string(outputBytes))
+		return popplerError("pdftoppm", err, outputBytes)
 	}
 
-	// pdftoppm creates filename with extension, verify it exists
-	expectedOutput := outputBase + "." + format
+	// pdftoppm creates filename with extension (.png or .jpg), verify it exists
+	expectedOutput := outputBase + ".png"
+	if format == "jpeg" {
+		expectedOutput = outputBase + ".jpg"
+	}
 	if expectedOutput != output {
 		// Rename to expected output name
 		if err := os.Rename(expectedOutput, output); err != nil {
@@ -105,11 +148,11 @@ func (p *PopplerConverter) Convert(ctx context.Context, input, output string, wi
 // Probe returns metadata about the PDF file
 func (p *PopplerConverter) Probe(ctx context.Context, input string) (*FileInfo, error) {
 	// Use pdfinfo to get PDF metadata
-	cmd := exec.CommandContext(ctx, "pdfinfo", input)
+	cmd := exec.CommandContext(ctx, "pdfinfo", p.passwordArgs([]string{input})...)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return nil, fmt.Errorf("pdfinfo failed: %w\nOutput: %s", err, string(output))
+		return nil, popplerError("pdfinfo", err, output)
 	}
 
 	// Parse output
@@ -164,3 +207,37 @@ func (p *PopplerConverter) SetDPI(dpi int) {
 		p.dpi = dpi
 	}
 }
+
+// SetPassword sets the user password used to open encrypted PDFs
+// PDFs that only restrict permissions open without one
+func (p *PopplerConverter) SetPassword(password string) {
+	p.password = password
+}
+
+// passwordArgs prepends the user password, if any, to a Poppler command line
+// The command line is visible to other local users while the tool runs, so
+// errors carry the tool's output, never its arguments
+func (p *PopplerConverter) passwordArgs(args []string) []string {
+	if p.password == "" {
+		return args
+	}
+	return append([]string{"-upw", p.password}, args...)
+}
+
+// popplerError wraps a failed Poppler command, mapping the messages for
+// encrypted and unparseable files to ErrPDFEncrypted and ErrPDFMalformed
+func popplerError(tool string, err error, output []byte) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		out := string(output)
+		if strings.Contains(out, "Incorrect password") {
+			return fmt.Errorf("%s failed: %w (password missing or incorrect)\nOutput: %s", tool, ErrPDFEncrypted, out)
+		}
+		for _, marker := range malformedMarkers {
+			if strings.Contains(out, marker) {
+				return fmt.Errorf("%s failed: %w\nOutput: %s", tool, ErrPDFMalformed, out)
+			}
+		}
+	}
+	return fmt.Errorf("%s failed: %w\nOutput: %s", tool, err, string(output))
+}
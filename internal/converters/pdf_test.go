@@ -0,0 +1,49 @@
+package converters
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestPopplerError(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 1").Run()
+	if exitErr == nil {
+		t.Fatal("expected the command to fail")
+	}
+
+	tests := []struct {
+		name   string
+		err    error
+		output string
+		want   error
+	}{
+		{"encrypted", exitErr, "Command Line Error: Incorrect password\n", ErrPDFEncrypted},
+		{"malformed", exitErr, "Syntax Warning: May not be a PDF file (continuing anyway)\nSyntax Error: Couldn't find trailer dictionary\n", ErrPDFMalformed},
+		{"other failure", exitErr, "I/O Error: Couldn't open file 'missing.pdf'\n", exitErr},
+		{"killed before exit", errors.New("signal: killed"), "Syntax Error: truncated\n", nil},
+	}
+	for _, tt := range tests {
+		err := popplerError("pdfinfo", tt.err, []byte(tt.output))
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+		for _, sentinel := range []error{ErrPDFEncrypted, ErrPDFMalformed} {
+			if sentinel != tt.want && errors.Is(err, sentinel) {
+				t.Errorf("%s: got %v, should not match %v", tt.name, err, sentinel)
+			}
+		}
+	}
+}
+
+func TestPopplerPasswordArgs(t *testing.T) {
+	p := NewPopplerConverter()
+	if got := p.passwordArgs([]string{"in.pdf"}); len(got) != 1 {
+		t.Errorf("without password got %v", got)
+	}
+	p.SetPassword("secret")
+	got := p.passwordArgs([]string{"in.pdf"})
+	if len(got) != 3 || got[0] != "-upw" || got[1] != "secret" || got[2] != "in.pdf" {
+		t.Errorf("with password got %v", got)
+	}
+}
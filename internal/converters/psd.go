@@ -0,0 +1,387 @@
+package converters
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// psdMimeTypes are the names Photoshop documents are uploaded under
+var psdMimeTypes = map[string]bool{
+	"image/vnd.adobe.photoshop": true,
+	"image/x-photoshop":         true,
+	"image/psd":                 true,
+	"application/x-photoshop":   true,
+	"application/photoshop":     true,
+}
+
+// PSD colour modes
+const (
+	psdBitmap    = 0
+	psdGrayscale = 1
+	psdIndexed   = 2
+	psdRGB       = 3
+	psdCMYK      = 4
+	psdDuotone   = 8
+)
+
+// psdHeader is the fixed header of a PSD or PSB file
+type psdHeader struct {
+	Signature [4]byte
+	Version   uint16
+	_         [6]byte
+	Channels  uint16
+	Height    uint32
+	Width     uint32
+	Depth     uint16
+	ColorMode uint16
+}
+
+// large reports whether the file is a PSB (large document), whose section
+// lengths and row byte counts are twice as wide
+func (h *psdHeader) large() bool {
+	return h.Version == 2
+}
+
+// PSDConverter reads the flattened composite image that Photoshop stores
+// after the layers in PSD and PSB files. Layers are not composited, so files
+// saved without "Maximize Compatibility" have no usable composite.
+type PSDConverter struct{}
+
+// NewPSDConverter creates a new Photoshop document converter
+func NewPSDConverter() *PSDConverter {
+	return &PSDConverter{}
+}
+
+// Name returns the converter name
+func (c *PSDConverter) Name() string {
+	return "psd"
+}
+
+// Supports returns true if this converter can handle the given MIME type
+func (c *PSDConverter) Supports(mimeType string) bool {
+	return psdMimeTypes[strings.ToLower(mimeType)]
+}
+
+// Convert generates a thumbnail from a Photoshop document
+// It scales the image to fit inside width x height, keeping its aspect ratio
+func (c *PSDConverter) Convert(ctx context.Context, input, output string, width, height int) error {
+	return c.ConvertWithOptions(ctx, input, output, width, height, ConversionOptions{})
+}
+
+// ConvertWithOptions generates a thumbnail from a Photoshop document using explicit encoding options.
+// opts.Progressive is ignored.
+func (c *PSDConverter) ConvertWithOptions(ctx context.Context, input, output string, width, height int, opts ConversionOptions) error {
+	img, err := c.Decode(input)
+	if err != nil {
+		return err
+	}
+	if width > 0 && height > 0 {
+		img = imaging.Fit(img, width, height, imaging.Lanczos)
+	}
+	return writeImage(output, img, opts)
+}
+
+// Decode returns the composite image of a Photoshop document. Bitmap,
+// grayscale, duotone, indexed, RGB and CMYK documents of 1, 8 or 16 bits
+// are read; 16-bit samples are reduced to 8 bits. Alpha and spot channels
+// are ignored, so the result is opaque.
+func (c *PSDConverter) Decode(input string) (image.Image, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decodePSD(bufio.NewReader(f))
+}
+
+// Probe returns metadata about the Photoshop document
+func (c *PSDConverter) Probe(ctx context.Context, input string) (*FileInfo, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := readPSDHeader(f)
+	if err != nil {
+		return nil, err
+	}
+	return &FileInfo{
+		MimeType: "image/vnd.adobe.photoshop",
+		Width:    int(h.Width),
+		Height:   int(h.Height),
+		Size:     stat.Size(),
+	}, nil
+}
+
+// readPSDHeader reads and validates the file header
+func readPSDHeader(r io.Reader) (*psdHeader, error) {
+	var h psdHeader
+	if err := binary.Read(r, binary.BigEndian, &h); err != nil {
+		return nil, fmt.Errorf("read psd header: %w", err)
+	}
+	if string(h.Signature[:]) != "8BPS" || (h.Version != 1 && h.Version != 2) {
+		return nil, errors.New("not a PSD or PSB file")
+	}
+	if h.Width == 0 || h.Height == 0 || h.Channels == 0 {
+		return nil, fmt.Errorf("psd has no image (%dx%d, %d channels)", h.Width, h.Height, h.Channels)
+	}
+	return &h, nil
+}
+
+// decodePSD reads the header, the palette of indexed documents and then the
+// composite image data, skipping the image resources and layers in between.
+func decodePSD(r io.Reader) (image.Image, error) {
+	h, err := readPSDHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var channels int
+	switch h.ColorMode {
+	case psdBitmap, psdGrayscale, psdIndexed, psdDuotone:
+		channels = 1
+	case psdRGB:
+		channels = 3
+	case psdCMYK:
+		channels = 4
+	default:
+		return nil, fmt.Errorf("unsupported psd colour mode %d", h.ColorMode)
+	}
+	if int(h.Channels) < channels {
+		return nil, fmt.Errorf("psd colour mode %d needs %d channels, has %d", h.ColorMode, channels, h.Channels)
+	}
+	if h.Depth != 1 && h.Depth != 8 && h.Depth != 16 || (h.Depth == 1) != (h.ColorMode == psdBitmap) {
+		return nil, fmt.Errorf("unsupported psd depth %d for colour mode %d", h.Depth, h.ColorMode)
+	}
+
+	// Colour mode data: the palette of indexed documents
+	colorData, err := readPSDSection(r, false)
+	if err != nil {
+		return nil, fmt.Errorf("read colour mode data: %w", err)
+	}
+	if h.ColorMode == psdIndexed && len(colorData) < 768 {
+		return nil, errors.New("indexed psd has no palette")
+	}
+	// Image resources, then layers and masks
+	if err := skipPSDSection(r, false); err != nil {
+		return nil, fmt.Errorf("skip image resources: %w", err)
+	}
+	if err := skipPSDSection(r, h.large()); err != nil {
+		return nil, fmt.Errorf("skip layers: %w", err)
+	}
+
+	planes, err := readPSDPlanes(r, h, channels)
+	if err != nil {
+		return nil, fmt.Errorf("read composite image: %w", err)
+	}
+	return psdImage(h, planes, colorData), nil
+}
+
+// readPSDSection reads a length-prefixed section, refusing sections too big
+// for the colour mode data they hold
+func readPSDSection(r io.Reader, large bool) ([]byte, error) {
+	n, err := readPSDLength(r, large)
+	if err != nil {
+		return nil, err
+	}
+	if n > 1<<20 {
+		return nil, fmt.Errorf("section of %d bytes", n)
+	}
+	data := make([]byte, n)
+	_, err = io.ReadFull(r, data)
+	return data, err
+}
+
+// skipPSDSection skips a length-prefixed section
+func skipPSDSection(r io.Reader, large bool) error {
+	n, err := readPSDLength(r, large)
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(io.Discard, r, int64(n))
+	return err
+}
+
+// readPSDLength reads a section length, 8 bytes wide for PSB layers
+func readPSDLength(r io.Reader, large bool) (uint64, error) {
+	if large {
+		var n uint64
+		err := binary.Read(r, binary.BigEndian, &n)
+		return n, err
+	}
+	var n uint32
+	err := binary.Read(r, binary.BigEndian, &n)
+	return uint64(n), err
+}
+
+// readPSDPlanes reads the first channels planes of the composite image as
+// 8-bit samples. Planes are stored one after another, raw or PackBits
+// compressed row by row.
+func readPSDPlanes(r io.Reader, h *psdHeader, channels int) ([][]byte, error) {
+	var compression uint16
+	if err := binary.Read(r, binary.BigEndian, &compression); err != nil {
+		return nil, err
+	}
+
+	width, height := int(h.Width), int(h.Height)
+	rowBytes := (width*int(h.Depth) + 7) / 8
+
+	// PackBits data starts with the byte count of every row of every plane
+	var rowLengths []int
+	switch compression {
+	case 0:
+	case 1:
+		rowLengths = make([]int, int(h.Channels)*height)
+		for i := range rowLengths {
+			n, err := readPSDRowLength(r, h.large())
+			if err != nil {
+				return nil, err
+			}
+			rowLengths[i] = n
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression %d", compression)
+	}
+
+	row := make([]byte, rowBytes)
+	var packed []byte
+	planes := make([][]byte, channels)
+	for c := range planes {
+		plane := make([]byte, width*height)
+		for y := 0; y < height; y++ {
+			if compression == 0 {
+				if _, err := io.ReadFull(r, row); err != nil {
+					return nil, err
+				}
+			} else {
+				n := rowLengths[c*height+y]
+				if cap(packed) < n {
+					packed = make([]byte, n)
+				}
+				packed = packed[:n]
+				if _, err := io.ReadFull(r, packed); err != nil {
+					return nil, err
+				}
+				if err := unpackBits(row, packed); err != nil {
+					return nil, fmt.Errorf("row %d of channel %d: %w", y, c, err)
+				}
+			}
+			psdSamples(plane[y*width:(y+1)*width], row, h.Depth)
+		}
+		planes[c] = plane
+	}
+	return planes, nil
+}
+
+// readPSDRowLength reads one PackBits row byte count, 4 bytes wide in PSB
+func readPSDRowLength(r io.Reader, large bool) (int, error) {
+	if large {
+		var n uint32
+		err := binary.Read(r, binary.BigEndian, &n)
+		return int(n), err
+	}
+	var n uint16
+	err := binary.Read(r, binary.BigEndian, &n)
+	return int(n), err
+}
+
+// unpackBits decodes a PackBits row into dst, which it must fill exactly
+func unpackBits(dst, src []byte) error {
+	i := 0
+	for len(src) > 0 && i < len(dst) {
+		n := int(int8(src[0]))
+		src = src[1:]
+		switch {
+		case n >= 0:
+			// n+1 literal bytes
+			if n+1 > len(src) || i+n+1 > len(dst) {
+				return errors.New("packbits literal overruns row")
+			}
+			i += copy(dst[i:], src[:n+1])
+			src = src[n+1:]
+		case n > -128:
+			// One byte repeated 1-n times
+			if len(src) == 0 || i+1-n > len(dst) {
+				return errors.New("packbits run overruns row")
+			}
+			for j := 0; j < 1-n; j++ {
+				dst[i] = src[0]
+				i++
+			}
+			src = src[1:]
+		}
+	}
+	if i != len(dst) {
+		return fmt.Errorf("packbits row has %d of %d bytes", i, len(dst))
+	}
+	return nil
+}
+
+// psdSamples converts one stored row to 8-bit samples: 16-bit samples keep
+// their high byte, and bitmap pixels (1 = black) become 0 or 255
+func psdSamples(dst, row []byte, depth uint16) {
+	switch depth {
+	case 1:
+		for x := range dst {
+			if row[x/8]&(0x80>>(x%8)) != 0 {
+				dst[x] = 0
+			} else {
+				dst[x] = 255
+			}
+		}
+	case 8:
+		copy(dst, row)
+	case 16:
+		for x := range dst {
+			dst[x] = row[2*x]
+		}
+	}
+}
+
+// psdImage combines the planes into an image of the document's colour mode
+func psdImage(h *psdHeader, planes [][]byte, colorData []byte) image.Image {
+	width, height := int(h.Width), int(h.Height)
+	rect := image.Rect(0, 0, width, height)
+	switch h.ColorMode {
+	case psdBitmap, psdGrayscale, psdDuotone:
+		// Duotone documents store their grayscale image
+		return &image.Gray{Pix: planes[0], Stride: width, Rect: rect}
+	case psdIndexed:
+		palette := make(color.Palette, 256)
+		for i := range palette {
+			palette[i] = color.RGBA{colorData[i], colorData[256+i], colorData[512+i], 255}
+		}
+		return &image.Paletted{Pix: planes[0], Stride: width, Rect: rect, Palette: palette}
+	}
+
+	img := image.NewNRGBA(rect)
+	for i := 0; i < width*height; i++ {
+		px := img.Pix[i*4 : i*4+4]
+		if h.ColorMode == psdCMYK {
+			// Samples are stored inverted: 255 is no ink
+			k := int(planes[3][i])
+			px[0] = uint8(int(planes[0][i]) * k / 255)
+			px[1] = uint8(int(planes[1][i]) * k / 255)
+			px[2] = uint8(int(planes[2][i]) * k / 255)
+		} else {
+			px[0], px[1], px[2] = planes[0][i], planes[1][i], planes[2][i]
+		}
+		px[3] = 255
+	}
+	return img
+}
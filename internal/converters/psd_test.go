@@ -0,0 +1,115 @@
+package converters
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildPSD writes an 8-bit document whose planes are filled with one value
+// each, PackBits compressed when rle is set. Rows of the first plane are runs
+// and the rest literals.
+func buildPSD(colorMode uint16, width, height int, rle bool, fill ...byte) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.BigEndian, psdHeader{
+		Signature: [4]byte{'8', 'B', 'P', 'S'},
+		Version:   1,
+		Channels:  uint16(len(fill) + 1), // plus an alpha channel, ignored
+		Height:    uint32(height),
+		Width:     uint32(width),
+		Depth:     8,
+		ColorMode: colorMode,
+	})
+	// Empty colour mode data and image resources; a layer section to skip
+	b.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4, 1, 2, 3, 4})
+
+	fill = append(fill, 0)
+	if !rle {
+		b.Write([]byte{0, 0})
+		for _, v := range fill {
+			b.Write(bytes.Repeat([]byte{v}, width*height))
+		}
+		return b.Bytes()
+	}
+
+	b.Write([]byte{0, 1})
+	var rows [][]byte
+	for c, v := range fill {
+		row := []byte{byte(1 - width), v}
+		if c > 0 {
+			row = append([]byte{byte(width - 1)}, bytes.Repeat([]byte{v}, width)...)
+		}
+		for y := 0; y < height; y++ {
+			rows = append(rows, row)
+		}
+	}
+	for _, row := range rows {
+		binary.Write(&b, binary.BigEndian, uint16(len(row)))
+	}
+	for _, row := range rows {
+		b.Write(row)
+	}
+	return b.Bytes()
+}
+
+func TestPSDDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want color.NRGBA
+	}{
+		{"rgb raw", buildPSD(psdRGB, 40, 30, false, 200, 100, 50), color.NRGBA{200, 100, 50, 255}},
+		{"rgb rle", buildPSD(psdRGB, 40, 30, true, 10, 20, 30), color.NRGBA{10, 20, 30, 255}},
+		{"gray rle", buildPSD(psdGrayscale, 40, 30, true, 90), color.NRGBA{90, 90, 90, 255}},
+		// Inverted CMYK: no cyan, full magenta and yellow, half black
+		{"cmyk", buildPSD(psdCMYK, 40, 30, true, 255, 0, 0, 128), color.NRGBA{128, 0, 0, 255}},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".psd")
+			if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			info, err := NewPSDConverter().Probe(context.Background(), path)
+			if err != nil {
+				t.Fatalf("Probe returned error: %v", err)
+			}
+			if info.Width != 40 || info.Height != 30 {
+				t.Errorf("Probe: got %dx%d, want 40x30", info.Width, info.Height)
+			}
+
+			img, err := NewPSDConverter().Decode(path)
+			if err != nil {
+				t.Fatalf("Decode returned error: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != 40 || b.Dy() != 30 {
+				t.Errorf("got %dx%d, want 40x30", b.Dx(), b.Dy())
+			}
+			if got := color.NRGBAModel.Convert(img.At(39, 29)); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnpackBits(t *testing.T) {
+	dst := make([]byte, 6)
+	// Two literals, a run of three, then a no-op and one literal
+	if err := unpackBits(dst, []byte{1, 'a', 'b', 0xfe, 'c', 0x80, 0, 'd'}); err != nil {
+		t.Fatalf("unpackBits returned error: %v", err)
+	}
+	if string(dst) != "abcccd" {
+		t.Errorf("got %q, want abcccd", dst)
+	}
+	if err := unpackBits(dst, []byte{0xfd, 'x'}); err == nil {
+		t.Error("short row: expected an error")
+	}
+	if err := unpackBits(dst, []byte{9, 'x'}); err == nil {
+		t.Error("truncated literal: expected an error")
+	}
+}
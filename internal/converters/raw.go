@@ -0,0 +1,429 @@
+package converters
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// rawExtensions maps camera RAW file extensions to their MIME types. All of
+// them are TIFF-based.
+var rawExtensions = map[string]string{
+	".cr2": "image/x-canon-cr2",
+	".nef": "image/x-nikon-nef",
+	".arw": "image/x-sony-arw",
+	".dng": "image/x-adobe-dng",
+}
+
+// rawMimeTypes are the camera RAW MIME types the converter accepts
+var rawMimeTypes = map[string]bool{
+	"image/x-canon-cr2": true,
+	"image/x-nikon-nef": true,
+	"image/x-sony-arw":  true,
+	"image/x-adobe-dng": true,
+	"image/dng":         true,
+}
+
+// rawMinPreview is the shortest long side an embedded preview needs to be
+// used instead of decoding the sensor data. Older cameras only embed a
+// 160x120 thumbnail.
+const rawMinPreview = 1024
+
+// ErrNoRawPreview is returned by ExtractPreview for RAW files without a usable
+// embedded JPEG preview
+var ErrNoRawPreview = errors.New("raw file has no embedded preview")
+
+// RawMimeType returns the MIME type of the camera RAW format named by the
+// extension of filename, or "" for other files
+func RawMimeType(filename string) string {
+	return rawExtensions[strings.ToLower(filepath.Ext(filename))]
+}
+
+// RawConverter thumbnails camera RAW photos (CR2, NEF, ARW, DNG). It uses the
+// JPEG preview the camera embeds in the file, read in Go, and only decodes
+// the sensor data with LibRaw's dcraw_emu (or dcraw) when there is none.
+type RawConverter struct{}
+
+// NewRawConverter creates a new camera RAW converter
+func NewRawConverter() *RawConverter {
+	return &RawConverter{}
+}
+
+// Name returns the converter name
+func (c *RawConverter) Name() string {
+	return "raw"
+}
+
+// Supports returns true if this converter can handle the given MIME type
+func (c *RawConverter) Supports(mimeType string) bool {
+	return rawMimeTypes[strings.ToLower(mimeType)]
+}
+
+// Convert generates a thumbnail from a camera RAW photo
+// It scales the image to fit inside width x height, keeping its aspect ratio
+func (c *RawConverter) Convert(ctx context.Context, input, output string, width, height int) error {
+	return c.ConvertWithOptions(ctx, input, output, width, height, ConversionOptions{})
+}
+
+// ConvertWithOptions generates a thumbnail from a camera RAW photo using explicit encoding options.
+// opts.Progressive is ignored.
+func (c *RawConverter) ConvertWithOptions(ctx context.Context, input, output string, width, height int, opts ConversionOptions) error {
+	img, err := c.Decode(ctx, input)
+	if err != nil {
+		return err
+	}
+	if width > 0 && height > 0 {
+		img = imaging.Fit(img, width, height, imaging.Lanczos)
+	}
+	return writeImage(output, img, opts)
+}
+
+// Decode returns the photo upright. The embedded preview is used when its
+// long side is at least rawMinPreview pixels; otherwise the sensor data is
+// decoded at half size, falling back to a smaller preview if that fails.
+func (c *RawConverter) Decode(ctx context.Context, input string) (image.Image, error) {
+	preview, err := c.ExtractPreview(input)
+	if err == nil && max(preview.Bounds().Dx(), preview.Bounds().Dy()) >= rawMinPreview {
+		return preview, nil
+	}
+
+	decoded, decodeErr := c.decodeSensor(ctx, input)
+	switch {
+	case decodeErr == nil:
+		return decoded, nil
+	case preview != nil:
+		// A small preview beats no thumbnail
+		return preview, nil
+	default:
+		return nil, fmt.Errorf("%w; decode sensor data: %v", err, decodeErr)
+	}
+}
+
+// ExtractPreview decodes the largest JPEG preview embedded in the RAW file's
+// TIFF structure and turns it upright using the file's orientation tag.
+func (c *RawConverter) ExtractPreview(input string) (image.Image, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := readRawTIFF(f, stat.Size())
+	if err != nil {
+		return nil, err
+	}
+	if raw.preview == nil {
+		return nil, ErrNoRawPreview
+	}
+	img, err := jpeg.Decode(io.NewSectionReader(f, raw.preview.offset, raw.preview.length))
+	if err != nil {
+		return nil, fmt.Errorf("decode preview: %w", err)
+	}
+	return orient(img, raw.orientation), nil
+}
+
+// decodeSensor decodes the sensor data at half size to an upright 8-bit
+// image, with the camera's white balance. Both tools apply the orientation.
+func (c *RawConverter) decodeSensor(ctx context.Context, input string) (image.Image, error) {
+	var cmd *exec.Cmd
+	switch {
+	case lookPath("dcraw_emu"):
+		// -Z -: Write to stdout
+		cmd = exec.CommandContext(ctx, "dcraw_emu", "-w", "-h", "-T", "-Z", "-", input)
+	case lookPath("dcraw"):
+		// -c: Write to stdout
+		cmd = exec.CommandContext(ctx, "dcraw", "-c", "-w", "-h", "-T", input)
+	default:
+		return nil, fmt.Errorf("dcraw_emu not found in PATH (install with: brew install libraw)")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w\nOutput: %s", filepath.Base(cmd.Path), err, stderr.String())
+	}
+	img, err := imaging.Decode(&stdout)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s output: %w", filepath.Base(cmd.Path), err)
+	}
+	return img, nil
+}
+
+// lookPath reports whether the named tool is installed
+func lookPath(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// Probe returns metadata about the camera RAW photo
+// Width and Height are the size Decode returns: the upright preview's when it
+// is large enough, otherwise half the sensor's, as decodeSensor reads it.
+func (c *RawConverter) Probe(ctx context.Context, input string) (*FileInfo, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := readRawTIFF(f, stat.Size())
+	if err != nil {
+		return nil, err
+	}
+	info := &FileInfo{
+		MimeType: RawMimeType(input),
+		Size:     stat.Size(),
+		Width:    (raw.width + 1) / 2,
+		Height:   (raw.height + 1) / 2,
+	}
+	if p := raw.preview; p != nil && max(p.width, p.height) >= rawMinPreview {
+		info.Width, info.Height = p.width, p.height
+	}
+	if raw.orientation >= 5 {
+		info.Width, info.Height = info.Height, info.Width
+	}
+	return info, nil
+}
+
+// TIFF tags read from RAW files
+const (
+	tagNewSubfileType  = 0x00fe
+	tagImageWidth      = 0x0100
+	tagImageLength     = 0x0101
+	tagCompression     = 0x0103
+	tagPhotometric     = 0x0106
+	tagStripOffsets    = 0x0111
+	tagOrientation     = 0x0112
+	tagStripByteCounts = 0x0117
+	tagSubIFDs         = 0x014a
+	tagJPEGOffset      = 0x0201
+	tagJPEGLength      = 0x0202
+)
+
+// TIFF values and the bounds on what is read
+const (
+	rawMaxIFDs          = 64
+	rawMaxEntries       = 1024
+	compressionOldJPEG  = 6
+	compressionJPEG     = 7
+	photometricCFA      = 32803
+	photometricLinear   = 34892
+	tiffTypeShort       = 3
+	tiffTypeLong        = 4
+	tiffTypeIFD         = 13
+	tiffEntrySize       = 12
+	tiffHeaderSize      = 8
+	tiffMaxValueEntries = 64
+)
+
+// rawPreview locates an embedded JPEG
+type rawPreview struct {
+	offset, length int64
+	width, height  int
+}
+
+// rawTIFF is what is read from a RAW file's TIFF structure
+type rawTIFF struct {
+	// preview is the largest embedded baseline JPEG, or nil
+	preview *rawPreview
+	// width and height are the largest image's, usually the sensor data
+	width, height int
+	// orientation is the EXIF orientation of IFD0, 1 to 8
+	orientation int
+}
+
+// readRawTIFF walks the IFD chain and SubIFDs of a TIFF-based RAW file,
+// collecting JPEG previews stored as JPEGInterchangeFormat or as a single
+// JPEG strip. Lossless JPEG sensor data is skipped because image/jpeg
+// cannot read it.
+func readRawTIFF(r io.ReaderAt, size int64) (*rawTIFF, error) {
+	order, first, err := readTIFFHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := &rawTIFF{orientation: 1}
+	visited := make(map[int64]bool)
+	queue := []int64{first}
+	for len(queue) > 0 && len(visited) < rawMaxIFDs {
+		offset := queue[0]
+		queue = queue[1:]
+		if offset == 0 || visited[offset] {
+			continue
+		}
+		visited[offset] = true
+
+		ifd, next, err := readIFD(r, order, offset, size)
+		if err != nil {
+			return nil, err
+		}
+		if next != 0 {
+			queue = append(queue, next)
+		}
+		queue = append(queue, ifd[tagSubIFDs]...)
+
+		// IFD0 holds the orientation
+		if len(visited) == 1 {
+			if o := ifd.value(tagOrientation); o >= 1 && o <= 8 {
+				raw.orientation = int(o)
+			}
+		}
+		if w, h := int(ifd.value(tagImageWidth)), int(ifd.value(tagImageLength)); w*h > raw.width*raw.height {
+			raw.width, raw.height = w, h
+		}
+		if p := ifd.preview(r, size); p != nil && (raw.preview == nil || p.width*p.height > raw.preview.width*raw.preview.height) {
+			raw.preview = p
+		}
+	}
+	return raw, nil
+}
+
+// errNotTIFF is returned for files without a TIFF header
+var errNotTIFF = errors.New("not a TIFF file")
+
+// readTIFFHeader returns a TIFF file's byte order and the offset of its
+// first IFD
+func readTIFFHeader(r io.ReaderAt) (binary.ByteOrder, int64, error) {
+	var header [tiffHeaderSize]byte
+	if _, err := r.ReadAt(header[:], 0); err != nil {
+		return nil, 0, fmt.Errorf("read tiff header: %w", err)
+	}
+	switch string(header[:4]) {
+	case "II*\x00":
+		return binary.LittleEndian, int64(binary.LittleEndian.Uint32(header[4:])), nil
+	case "MM\x00*":
+		return binary.BigEndian, int64(binary.BigEndian.Uint32(header[4:])), nil
+	}
+	return nil, 0, errNotTIFF
+}
+
+// tiffIFD holds the integer values of one IFD's entries by tag
+type tiffIFD map[uint16][]int64
+
+// value returns the first value of tag, or 0
+func (ifd tiffIFD) value(tag uint16) int64 {
+	if v := ifd[tag]; len(v) > 0 {
+		return v[0]
+	}
+	return 0
+}
+
+// preview returns the IFD's JPEG, if it holds a baseline JPEG inside the file
+func (ifd tiffIFD) preview(r io.ReaderAt, size int64) *rawPreview {
+	p := &rawPreview{offset: ifd.value(tagJPEGOffset), length: ifd.value(tagJPEGLength)}
+	if p.offset == 0 {
+		compression := ifd.value(tagCompression)
+		photometric := ifd.value(tagPhotometric)
+		if compression != compressionOldJPEG && compression != compressionJPEG ||
+			photometric == photometricCFA || photometric == photometricLinear ||
+			len(ifd[tagStripOffsets]) != 1 {
+			return nil
+		}
+		p.offset, p.length = ifd.value(tagStripOffsets), ifd.value(tagStripByteCounts)
+	}
+	if p.offset <= 0 || p.length <= 0 || p.offset+p.length > size {
+		return nil
+	}
+
+	cfg, err := jpeg.DecodeConfig(io.NewSectionReader(r, p.offset, p.length))
+	if err != nil {
+		return nil
+	}
+	p.width, p.height = cfg.Width, cfg.Height
+	return p
+}
+
+// readIFD reads the SHORT, LONG and IFD entries of the IFD at offset, and
+// the offset of the next IFD
+func readIFD(r io.ReaderAt, order binary.ByteOrder, offset, size int64) (tiffIFD, int64, error) {
+	var count [2]byte
+	if _, err := r.ReadAt(count[:], offset); err != nil {
+		return nil, 0, fmt.Errorf("read ifd: %w", err)
+	}
+	n := int(order.Uint16(count[:]))
+	if n > rawMaxEntries {
+		return nil, 0, fmt.Errorf("ifd at %d has %d entries", offset, n)
+	}
+	entries := make([]byte, n*tiffEntrySize+4)
+	if _, err := r.ReadAt(entries, offset+2); err != nil {
+		return nil, 0, fmt.Errorf("read ifd: %w", err)
+	}
+
+	ifd := make(tiffIFD, n)
+	for i := 0; i < n; i++ {
+		e := entries[i*tiffEntrySize:]
+		tag, typ, cnt := order.Uint16(e), order.Uint16(e[2:]), int64(order.Uint32(e[4:]))
+		width := int64(4)
+		if typ == tiffTypeShort {
+			width = 2
+		} else if typ != tiffTypeLong && typ != tiffTypeIFD {
+			continue
+		}
+		if cnt == 0 || cnt > tiffMaxValueEntries {
+			continue
+		}
+
+		// Values that fit in four bytes are stored in the entry itself
+		data := e[8:12]
+		if cnt*width > 4 {
+			valueOffset := int64(order.Uint32(e[8:]))
+			if valueOffset+cnt*width > size {
+				continue
+			}
+			data = make([]byte, cnt*width)
+			if _, err := r.ReadAt(data, valueOffset); err != nil {
+				continue
+			}
+		}
+		values := make([]int64, cnt)
+		for j := range values {
+			if width == 2 {
+				values[j] = int64(order.Uint16(data[j*2:]))
+			} else {
+				values[j] = int64(order.Uint32(data[j*4:]))
+			}
+		}
+		ifd[tag] = values
+	}
+	return ifd, int64(order.Uint32(entries[n*tiffEntrySize:])), nil
+}
+
+// orient turns img upright according to an EXIF orientation value
+func orient(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	}
+	return img
+}
@@ -0,0 +1,153 @@
+package converters
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// encodeJPEG returns a width x height blue JPEG with a red top-left corner
+func encodeJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := imaging.New(width, height, color.NRGBA{0, 0, 255, 255})
+	img = imaging.Paste(img, imaging.New(100, 100, color.NRGBA{255, 0, 0, 255}), image.Pt(0, 0))
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// buildRaw lays out a little-endian TIFF like a CR2: IFD0 with the
+// orientation and a thumbnail, a SubIFD with the preview as a JPEG strip,
+// and a chained IFD whose sensor data is also JPEG-compressed.
+func buildRaw(orientation uint16, thumb, preview, sensor []byte) []byte {
+	le := binary.LittleEndian
+	ifdSize := func(n int) uint32 { return uint32(2 + n*12 + 4) }
+	ifd0 := uint32(8)
+	subIFD := ifd0 + ifdSize(4)
+	rawIFD := subIFD + ifdSize(3)
+	thumbOffset := rawIFD + ifdSize(6)
+	previewOffset := thumbOffset + uint32(len(thumb))
+	sensorOffset := previewOffset + uint32(len(preview))
+
+	b := append([]byte("II*\x00"), le.AppendUint32(nil, ifd0)...)
+	ifd := func(next uint32, entries ...[2]uint32) {
+		b = le.AppendUint16(b, uint16(len(entries)))
+		for _, e := range entries {
+			b = le.AppendUint16(b, uint16(e[0]))
+			if e[0] == tagOrientation {
+				b = le.AppendUint16(b, tiffTypeShort)
+				b = le.AppendUint32(b, 1)
+				b = le.AppendUint16(b, uint16(e[1]))
+				b = le.AppendUint16(b, 0)
+				continue
+			}
+			b = le.AppendUint16(b, tiffTypeLong)
+			b = le.AppendUint32(b, 1)
+			b = le.AppendUint32(b, e[1])
+		}
+		b = le.AppendUint32(b, next)
+	}
+	ifd(rawIFD,
+		[2]uint32{tagOrientation, uint32(orientation)},
+		[2]uint32{tagSubIFDs, subIFD},
+		[2]uint32{tagJPEGOffset, thumbOffset},
+		[2]uint32{tagJPEGLength, uint32(len(thumb))},
+	)
+	ifd(0,
+		[2]uint32{tagCompression, compressionOldJPEG},
+		[2]uint32{tagStripOffsets, previewOffset},
+		[2]uint32{tagStripByteCounts, uint32(len(preview))},
+	)
+	ifd(0,
+		[2]uint32{tagImageWidth, 6000},
+		[2]uint32{tagImageLength, 4000},
+		[2]uint32{tagCompression, compressionJPEG},
+		[2]uint32{tagPhotometric, photometricCFA},
+		[2]uint32{tagStripOffsets, sensorOffset},
+		[2]uint32{tagStripByteCounts, uint32(len(sensor))},
+	)
+	b = append(b, thumb...)
+	b = append(b, preview...)
+	return append(b, sensor...)
+}
+
+func TestRawExtractPreview(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "IMG_0001.CR2")
+	data := buildRaw(6, encodeJPEG(t, 160, 120), encodeJPEG(t, 1200, 800), encodeJPEG(t, 1600, 1600))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := NewRawConverter().Probe(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Probe returned error: %v", err)
+	}
+	if info.MimeType != "image/x-canon-cr2" || info.Width != 800 || info.Height != 1200 {
+		t.Errorf("Probe: got %s %dx%d, want image/x-canon-cr2 800x1200", info.MimeType, info.Width, info.Height)
+	}
+
+	// The preview wins over the thumbnail and the sensor data, and is
+	// rotated 90° clockwise, moving the red corner to the top right
+	img, err := NewRawConverter().Decode(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Decode returned error: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 800 || b.Dy() != 1200 {
+		t.Fatalf("got %dx%d, want 800x1200", b.Dx(), b.Dy())
+	}
+	if r, _, b, _ := img.At(750, 50).RGBA(); r>>8 < 200 || b>>8 > 60 {
+		t.Errorf("top right is not red after rotation: r=%d b=%d", r>>8, b>>8)
+	}
+
+	if err := os.WriteFile(path, []byte("MM\x00*\x00\x00\x00\x08\x00\x00\x00\x00\x00\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRawConverter().ExtractPreview(path); !errors.Is(err, ErrNoRawPreview) {
+		t.Errorf("empty IFD: got %v, want ErrNoRawPreview", err)
+	}
+}
+
+func TestRawProbeSmallPreview(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "DSC_0001.NEF")
+	data := buildRaw(6, encodeJPEG(t, 160, 120), encodeJPEG(t, 640, 480), encodeJPEG(t, 16, 16))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The sensor data is decoded at half size, then rotated
+	info, err := NewRawConverter().Probe(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Probe returned error: %v", err)
+	}
+	if info.Width != 2000 || info.Height != 3000 {
+		t.Errorf("Probe: got %dx%d, want 2000x3000", info.Width, info.Height)
+	}
+}
+
+func TestRawMimeType(t *testing.T) {
+	tests := map[string]string{
+		"IMG_0001.CR2": "image/x-canon-cr2",
+		"DSC_0001.nef": "image/x-nikon-nef",
+		"DSC01.ARW":    "image/x-sony-arw",
+		"photo.dng":    "image/x-adobe-dng",
+		"photo.tiff":   "",
+		"raw":          "",
+	}
+	for name, want := range tests {
+		if got := RawMimeType(name); got != want {
+			t.Errorf("RawMimeType(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
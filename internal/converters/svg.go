@@ -0,0 +1,330 @@
+package converters
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrSVGUnsafe is returned for SVGs that declare XML entities or reference
+// anything outside the document: other files, URLs or XIncludes. Only
+// fragment ("#id") and data: references are allowed. Retrying will not help.
+var ErrSVGUnsafe = errors.New("svg references external resources")
+
+// svgMaxBytes caps the SVG documents that are parsed and rendered
+const svgMaxBytes = 32 << 20
+
+// cssURL matches url(...) references and @import rules in stylesheets
+var cssURL = regexp.MustCompile(`(?i)url\(\s*['"]?\s*([^'")\s]*)|@import`)
+
+// procInstHref matches the href pseudo-attribute of a processing instruction
+var procInstHref = regexp.MustCompile(`(?:^|\s)href\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+
+// RSVGConverter uses librsvg's rsvg-convert to rasterize SVG images
+type RSVGConverter struct{}
+
+// NewRSVGConverter creates a new rsvg-convert-based SVG converter
+func NewRSVGConverter() *RSVGConverter {
+	return &RSVGConverter{}
+}
+
+// Name returns the converter name
+func (r *RSVGConverter) Name() string {
+	return "rsvg"
+}
+
+// Supports returns true if this converter can handle the given MIME type
+func (r *RSVGConverter) Supports(mimeType string) bool {
+	return strings.ToLower(mimeType) == "image/svg+xml"
+}
+
+// Convert generates a thumbnail from an SVG image
+// It renders the image to fit inside width x height, keeping its aspect ratio
+func (r *RSVGConverter) Convert(ctx context.Context, input, output string, width, height int) error {
+	return r.ConvertWithOptions(ctx, input, output, width, height, ConversionOptions{})
+}
+
+// ConvertWithOptions generates a thumbnail from an SVG image using explicit encoding options.
+// rsvg-convert only writes PNG, so JPEG output is rendered on white and
+// re-encoded in Go. opts.Progressive is ignored.
+func (r *RSVGConverter) ConvertWithOptions(ctx context.Context, input, output string, width, height int, opts ConversionOptions) error {
+	format := opts.outputFormat(output)
+	switch format {
+	case "png":
+		return r.Render(ctx, input, output, width, height, "")
+	case "jpeg":
+	default:
+		return fmt.Errorf("unsupported output format for rsvg-convert: %s", format)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "svg-")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	rendered := filepath.Join(tmpDir, "image.png")
+	if err := r.Render(ctx, input, rendered, width, height, "white"); err != nil {
+		return err
+	}
+	f, err := os.Open(rendered)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return fmt.Errorf("decode rendered svg: %w", err)
+	}
+	return writeImage(output, img, opts)
+}
+
+// Render rasterizes input to a PNG at output that fits inside width x height,
+// keeping the aspect ratio. The image is drawn at that size rather than
+// scaled afterwards, so edges stay sharp. background is a CSS colour, or
+// empty to keep transparency.
+//
+// The document is checked with CheckSVG and rendered from a copy in a
+// private directory, so nothing it names by relative path exists there.
+func (r *RSVGConverter) Render(ctx context.Context, input, output string, width, height int, background string) error {
+	if _, err := exec.LookPath("rsvg-convert"); err != nil {
+		return fmt.Errorf("rsvg-convert not found in PATH: %w (install with: brew install librsvg)", err)
+	}
+
+	data, err := readSVG(input)
+	if err != nil {
+		return err
+	}
+	if err := CheckSVG(bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(output), "rsvg-")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	source := filepath.Join(tmpDir, "image.svg")
+	if err := os.WriteFile(source, data, 0o600); err != nil {
+		return fmt.Errorf("copy input: %w", err)
+	}
+
+	// Build rsvg-convert command
+	// -w, -h: Target box in pixels
+	// -a: Keep the aspect ratio, fitting inside the box
+	// -b: Background colour (transparent when omitted)
+	args := []string{
+		"-w", strconv.Itoa(width),
+		"-h", strconv.Itoa(height),
+		"-a",
+		"-f", "png",
+	}
+	if background != "" {
+		args = append(args, "-b", background)
+	}
+	args = append(args, "-o", output, source)
+
+	cmd := exec.CommandContext(ctx, "rsvg-convert", args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("rsvg-convert failed: %w\nOutput: %s", err, string(out))
+	}
+	return nil
+}
+
+// readSVG reads an SVG document, refusing ones over svgMaxBytes
+func readSVG(input string) ([]byte, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, svgMaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > svgMaxBytes {
+		return nil, fmt.Errorf("svg is larger than %d bytes", svgMaxBytes)
+	}
+	return data, nil
+}
+
+// CheckSVG returns ErrSVGUnsafe if the document declares entities, uses
+// XInclude, or references anything but its own fragments and data: URLs,
+// whether through href/src attributes, xml-stylesheet instructions or CSS
+// url() and @import.
+func CheckSVG(r io.Reader) error {
+	d := newSVGDecoder(r)
+	inStyle := false
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("parse svg: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.Directive:
+			if bytes.Contains(bytes.ToUpper(t), []byte("ENTITY")) {
+				return fmt.Errorf("%w: entity declaration", ErrSVGUnsafe)
+			}
+		case xml.ProcInst:
+			if strings.EqualFold(t.Target, "xml-stylesheet") {
+				if m := procInstHref.FindSubmatch(t.Inst); m != nil && !isLocalReference(string(m[1])+string(m[2])) {
+					return fmt.Errorf("%w: xml-stylesheet %s", ErrSVGUnsafe, t.Inst)
+				}
+			}
+		case xml.StartElement:
+			if t.Name.Space == "http://www.w3.org/2001/XInclude" || strings.EqualFold(t.Name.Local, "include") {
+				return fmt.Errorf("%w: xinclude", ErrSVGUnsafe)
+			}
+			for _, attr := range t.Attr {
+				switch name := strings.ToLower(attr.Name.Local); {
+				case name == "href" || name == "src":
+					// Links are never followed when rendering
+					if !isLocalReference(attr.Value) && !strings.EqualFold(t.Name.Local, "a") {
+						return fmt.Errorf("%w: %s=%q", ErrSVGUnsafe, attr.Name.Local, attr.Value)
+					}
+				default:
+					// style and presentation attributes such as fill="url(#g)"
+					if err := checkCSS(attr.Value); err != nil {
+						return err
+					}
+				}
+			}
+			inStyle = strings.EqualFold(t.Name.Local, "style")
+		case xml.EndElement:
+			inStyle = false
+		case xml.CharData:
+			if inStyle {
+				if err := checkCSS(string(t)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// newSVGDecoder returns a lenient XML decoder: undeclared HTML entities such
+// as &nbsp; are left as text and legacy encodings are read as bytes, which is
+// enough to find elements and attributes.
+func newSVGDecoder(r io.Reader) *xml.Decoder {
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return d
+}
+
+// checkCSS rejects stylesheets that import or reference external resources
+func checkCSS(css string) error {
+	for _, m := range cssURL.FindAllStringSubmatch(css, -1) {
+		if m[1] == "" && strings.EqualFold(m[0], "@import") {
+			return fmt.Errorf("%w: @import", ErrSVGUnsafe)
+		}
+		if !isLocalReference(m[1]) {
+			return fmt.Errorf("%w: url(%s)", ErrSVGUnsafe, m[1])
+		}
+	}
+	return nil
+}
+
+// isLocalReference reports whether ref stays inside the document
+func isLocalReference(ref string) bool {
+	ref = strings.TrimSpace(ref)
+	return strings.HasPrefix(ref, "#") || strings.HasPrefix(strings.ToLower(ref), "data:")
+}
+
+// Probe returns metadata about the SVG image
+// Width and Height are its intrinsic size in pixels, taken from the width
+// and height attributes or else the viewBox, and zero when it has neither.
+func (r *RSVGConverter) Probe(ctx context.Context, input string) (*FileInfo, error) {
+	data, err := readSVG(input)
+	if err != nil {
+		return nil, err
+	}
+
+	d := newSVGDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("parse svg: %w", err)
+		}
+		if root, ok := tok.(xml.StartElement); ok {
+			if !strings.EqualFold(root.Name.Local, "svg") {
+				return nil, fmt.Errorf("parse svg: root element is <%s>", root.Name.Local)
+			}
+			info := &FileInfo{MimeType: "image/svg+xml", Size: int64(len(data))}
+			info.Width, info.Height = svgSize(root.Attr)
+			return info, nil
+		}
+	}
+}
+
+// svgSize returns the intrinsic size of an <svg> element. Percentages and
+// missing dimensions fall back to the viewBox, scaled to keep a single
+// given dimension.
+func svgSize(attrs []xml.Attr) (int, int) {
+	var width, height, vbW, vbH float64
+	for _, attr := range attrs {
+		switch attr.Name.Local {
+		case "width":
+			width = svgLength(attr.Value)
+		case "height":
+			height = svgLength(attr.Value)
+		case "viewBox":
+			f := strings.FieldsFunc(attr.Value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' })
+			if len(f) == 4 {
+				vbW, _ = strconv.ParseFloat(f[2], 64)
+				vbH, _ = strconv.ParseFloat(f[3], 64)
+			}
+		}
+	}
+
+	switch {
+	case width > 0 && height > 0:
+	case vbW > 0 && vbH > 0 && width > 0:
+		height = width * vbH / vbW
+	case vbW > 0 && vbH > 0 && height > 0:
+		width = height * vbW / vbH
+	case vbW > 0 && vbH > 0:
+		width, height = vbW, vbH
+	default:
+		return 0, 0
+	}
+	return int(width + 0.5), int(height + 0.5)
+}
+
+// svgUnits converts CSS absolute units to pixels at 96 DPI
+var svgUnits = map[string]float64{
+	"": 1, "px": 1, "pt": 96.0 / 72, "pc": 16, "in": 96, "cm": 96 / 2.54, "mm": 96 / 25.4,
+}
+
+// svgLength parses an absolute SVG length in pixels, or 0 for relative
+// lengths (%, em) and invalid values
+func svgLength(value string) float64 {
+	value = strings.TrimSpace(value)
+	num := strings.TrimRight(value, "abcdefghijklmnopqrstuvwxyz%")
+	scale, ok := svgUnits[value[len(num):]]
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return n * scale
+}
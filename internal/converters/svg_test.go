@@ -0,0 +1,74 @@
+package converters
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckSVG(t *testing.T) {
+	tests := []struct {
+		name   string
+		svg    string
+		unsafe bool
+	}{
+		{"plain", `<svg xmlns="http://www.w3.org/2000/svg"><rect width="10" height="10"/></svg>`, false},
+		{"fragment", `<svg xmlns:xlink="http://www.w3.org/1999/xlink"><use xlink:href="#icon"/><rect fill="url(#grad)"/></svg>`, false},
+		{"data uri", `<svg><image href="data:image/png;base64,iVBORw0KGgo="/></svg>`, false},
+		{"link", `<svg><a href="https://example.com"><text>hi</text></a></svg>`, false},
+		{"html entity", `<svg><text>a&nbsp;b</text></svg>`, false},
+		{"entity", `<!DOCTYPE svg [<!ENTITY xxe SYSTEM "file:///etc/passwd">]><svg><text>&xxe;</text></svg>`, true},
+		{"external image", `<svg xmlns:xlink="http://www.w3.org/1999/xlink"><image xlink:href="https://example.com/a.png"/></svg>`, true},
+		{"local file", `<svg><image href="/etc/hosts"/></svg>`, true},
+		{"external use", `<svg><use href="sprite.svg#icon"/></svg>`, true},
+		{"xinclude", `<svg xmlns:xi="http://www.w3.org/2001/XInclude"><xi:include href="#x"/></svg>`, true},
+		{"style import", `<svg><style>@import "https://example.com/a.css";</style></svg>`, true},
+		{"style url", `<svg><style>rect { fill: url('https://example.com/a.svg#p') }</style></svg>`, true},
+		{"attribute url", `<svg><rect style="filter: url(file.svg#f)"/></svg>`, true},
+		{"stylesheet", `<?xml-stylesheet type="text/css" href="https://example.com/a.css"?><svg/>`, true},
+		{"local stylesheet", `<?xml-stylesheet href='#style'?><svg/>`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSVG(strings.NewReader(tt.svg))
+			if tt.unsafe && !errors.Is(err, ErrSVGUnsafe) {
+				t.Errorf("got %v, want ErrSVGUnsafe", err)
+			}
+			if !tt.unsafe && err != nil {
+				t.Errorf("got %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestRSVGProbe(t *testing.T) {
+	tests := []struct {
+		svg           string
+		width, height int
+	}{
+		{`<svg width="120" height="80"/>`, 120, 80},
+		{`<svg width="2in" height="1in"/>`, 192, 96},
+		{`<svg viewBox="0 0 24 24"/>`, 24, 24},
+		{`<svg width="100%" height="100%" viewBox="0,0,300,150"/>`, 300, 150},
+		{`<svg width="600" viewBox="0 0 300 150"/>`, 600, 300},
+		{`<?xml version="1.0"?><!-- icon --><svg/>`, 0, 0},
+	}
+	dir := t.TempDir()
+	for i, tt := range tests {
+		path := filepath.Join(dir, "image.svg")
+		if err := os.WriteFile(path, []byte(tt.svg), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		info, err := NewRSVGConverter().Probe(context.Background(), path)
+		if err != nil {
+			t.Errorf("%d: Probe returned error: %v", i, err)
+			continue
+		}
+		if info.Width != tt.width || info.Height != tt.height {
+			t.Errorf("%s: got %dx%d, want %dx%d", tt.svg, info.Width, info.Height, tt.width, tt.height)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package converters
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"golang.org/x/image/tiff"
+)
+
+// tiffMaxPages bounds the IFD chain walked in a multi-page TIFF
+const tiffMaxPages = 4096
+
+// TIFFConverter decodes TIFF images in Go, including multi-page ones such as
+// scanned documents, whose first page the imaging library would always use
+type TIFFConverter struct{}
+
+// NewTIFFConverter creates a new TIFF converter
+func NewTIFFConverter() *TIFFConverter {
+	return &TIFFConverter{}
+}
+
+// Name returns the converter name
+func (c *TIFFConverter) Name() string {
+	return "tiff"
+}
+
+// Supports returns true if this converter can handle the given MIME type
+func (c *TIFFConverter) Supports(mimeType string) bool {
+	mimeType = strings.ToLower(mimeType)
+	return mimeType == "image/tiff" || mimeType == "image/tiff-fx"
+}
+
+// Convert generates a thumbnail from a TIFF image
+// It scales the image to fit inside width x height, keeping its aspect ratio
+func (c *TIFFConverter) Convert(ctx context.Context, input, output string, width, height int) error {
+	return c.ConvertWithOptions(ctx, input, output, width, height, ConversionOptions{})
+}
+
+// ConvertWithOptions generates a thumbnail from a TIFF image using explicit encoding options.
+// opts.Page selects the page; the largest is used otherwise. opts.Progressive is ignored.
+func (c *TIFFConverter) ConvertWithOptions(ctx context.Context, input, output string, width, height int, opts ConversionOptions) error {
+	img, err := c.Decode(input, opts.Page)
+	if err != nil {
+		return err
+	}
+	if width > 0 && height > 0 {
+		img = imaging.Fit(img, width, height, imaging.Lanczos)
+	}
+	return writeImage(output, img, opts)
+}
+
+// Decode returns the 1-based page of a TIFF upright, according to its
+// orientation tag. Page 0 picks the largest page, the first of equals, so
+// a small cover or thumbnail page does not stand in for a scan.
+func (c *TIFFConverter) Decode(input string, page int) (image.Image, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := readTIFFPages(f, stat.Size())
+	if err != nil {
+		return nil, err
+	}
+	p, err := doc.page(page)
+	if err != nil {
+		return nil, err
+	}
+
+	// The decoder only reads the IFD the header points at
+	r := &tiffPageReader{r: f}
+	doc.order.PutUint32(r.firstIFD[:], uint32(p.offset))
+	img, err := tiff.Decode(io.NewSectionReader(r, 0, stat.Size()))
+	if err != nil {
+		return nil, fmt.Errorf("decode tiff page: %w", err)
+	}
+	return orient(img, p.orientation), nil
+}
+
+// Probe returns metadata about the TIFF image
+// Pages counts the full-resolution pages. Width and Height are the upright
+// size of the page Decode uses by default.
+func (c *TIFFConverter) Probe(ctx context.Context, input string) (*FileInfo, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := readTIFFPages(f, stat.Size())
+	if err != nil {
+		return nil, err
+	}
+	p, err := doc.page(0)
+	if err != nil {
+		return nil, err
+	}
+	info := &FileInfo{
+		MimeType: "image/tiff",
+		Width:    p.width,
+		Height:   p.height,
+		Pages:    len(doc.pages),
+		Size:     stat.Size(),
+	}
+	if p.orientation >= 5 {
+		info.Width, info.Height = info.Height, info.Width
+	}
+	return info, nil
+}
+
+// tiffPage is one full-resolution image in a TIFF's IFD chain
+type tiffPage struct {
+	offset        int64
+	width, height int
+	orientation   int
+}
+
+// tiffPages lists the pages of a TIFF
+type tiffPages struct {
+	order binary.ByteOrder
+	pages []tiffPage
+}
+
+// page returns the 1-based page n, or the largest page for 0
+func (d *tiffPages) page(n int) (tiffPage, error) {
+	if len(d.pages) == 0 {
+		return tiffPage{}, fmt.Errorf("tiff has no pages")
+	}
+	if n > len(d.pages) {
+		return tiffPage{}, fmt.Errorf("page %d is past the last page (%d)", n, len(d.pages))
+	}
+	if n > 0 {
+		return d.pages[n-1], nil
+	}
+	largest := d.pages[0]
+	for _, p := range d.pages[1:] {
+		if p.width*p.height > largest.width*largest.height {
+			largest = p
+		}
+	}
+	return largest, nil
+}
+
+// readTIFFPages walks the IFD chain, skipping reduced-resolution images
+// (thumbnails) and transparency masks
+func readTIFFPages(r io.ReaderAt, size int64) (*tiffPages, error) {
+	order, offset, err := readTIFFHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &tiffPages{order: order}
+	visited := make(map[int64]bool)
+	for offset != 0 && !visited[offset] && len(visited) < tiffMaxPages {
+		visited[offset] = true
+		ifd, next, err := readIFD(r, order, offset, size)
+		if err != nil {
+			return nil, err
+		}
+		if ifd.value(tagNewSubfileType)&0b101 == 0 {
+			p := tiffPage{
+				offset:      offset,
+				width:       int(ifd.value(tagImageWidth)),
+				height:      int(ifd.value(tagImageLength)),
+				orientation: int(ifd.value(tagOrientation)),
+			}
+			doc.pages = append(doc.pages, p)
+		}
+		offset = next
+	}
+	return doc, nil
+}
+
+// tiffPageReader reads a TIFF whose header points at firstIFD instead
+type tiffPageReader struct {
+	r        io.ReaderAt
+	firstIFD [4]byte
+}
+
+// ReadAt implements io.ReaderAt
+func (t *tiffPageReader) ReadAt(p []byte, off int64) (int, error) {
+	n, err := t.r.ReadAt(p, off)
+	// Bytes 4-8 of the header hold the first IFD offset
+	for i := max(off, 4); i < min(off+int64(n), 8); i++ {
+		p[i-off] = t.firstIFD[i-4]
+	}
+	return n, err
+}
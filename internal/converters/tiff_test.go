@@ -0,0 +1,107 @@
+package converters
+
+import (
+	"context"
+	"encoding/binary"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tiffTestPage is one uncompressed 8-bit grayscale image written by buildTIFF
+type tiffTestPage struct {
+	width, height int
+	gray          byte
+	subfileType   uint32
+	orientation   uint32
+}
+
+// buildTIFF writes the pages as a big-endian TIFF IFD chain, each page's
+// pixels followed by its IFD
+func buildTIFF(pages ...tiffTestPage) []byte {
+	be := binary.BigEndian
+	b := []byte("MM\x00*\x00\x00\x00\x00")
+	next := 4 // where the previous IFD stores the next offset
+	for _, p := range pages {
+		pixels := len(b)
+		for i := 0; i < p.width*p.height; i++ {
+			b = append(b, p.gray)
+		}
+		if len(b)%2 == 1 {
+			b = append(b, 0)
+		}
+		be.PutUint32(b[next:], uint32(len(b)))
+
+		entries := [][2]uint32{
+			{tagNewSubfileType, p.subfileType},
+			{tagImageWidth, uint32(p.width)},
+			{tagImageLength, uint32(p.height)},
+			{0x0102, 8}, // BitsPerSample
+			{tagCompression, 1},
+			{tagPhotometric, 1}, // BlackIsZero
+			{tagStripOffsets, uint32(pixels)},
+			{tagOrientation, max(p.orientation, 1)},
+			{0x0115, 1},                // SamplesPerPixel
+			{0x0116, uint32(p.height)}, // RowsPerStrip
+			{tagStripByteCounts, uint32(p.width * p.height)},
+		}
+		b = be.AppendUint16(b, uint16(len(entries)))
+		for _, e := range entries {
+			b = be.AppendUint16(b, uint16(e[0]))
+			b = be.AppendUint16(b, tiffTypeLong)
+			b = be.AppendUint32(b, 1)
+			b = be.AppendUint32(b, e[1])
+		}
+		next = len(b)
+		b = be.AppendUint32(b, 0)
+	}
+	return b
+}
+
+func TestTIFFPages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.tif")
+	data := buildTIFF(
+		tiffTestPage{width: 100, height: 50, gray: 50},
+		tiffTestPage{width: 300, height: 300, gray: 128, subfileType: 1}, // thumbnail
+		tiffTestPage{width: 200, height: 120, gray: 200, orientation: 6},
+	)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := NewTIFFConverter()
+
+	info, err := c.Probe(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Probe returned error: %v", err)
+	}
+	if info.Pages != 2 || info.Width != 120 || info.Height != 200 {
+		t.Errorf("Probe: got %d pages, %dx%d; want 2 pages, 120x200", info.Pages, info.Width, info.Height)
+	}
+
+	tests := []struct {
+		page          int
+		width, height int
+		gray          uint8
+	}{
+		{0, 120, 200, 200}, // largest, rotated upright
+		{1, 100, 50, 50},
+		{2, 120, 200, 200},
+	}
+	for _, tt := range tests {
+		img, err := c.Decode(path, tt.page)
+		if err != nil {
+			t.Errorf("page %d: Decode returned error: %v", tt.page, err)
+			continue
+		}
+		b := img.Bounds()
+		gray := color.GrayModel.Convert(img.At(b.Min.X, b.Min.Y)).(color.Gray).Y
+		if b.Dx() != tt.width || b.Dy() != tt.height || gray != tt.gray {
+			t.Errorf("page %d: got %dx%d gray %d, want %dx%d gray %d", tt.page, b.Dx(), b.Dy(), gray, tt.width, tt.height, tt.gray)
+		}
+	}
+
+	if _, err := c.Decode(path, 3); err == nil {
+		t.Error("page 3: expected an error past the last page")
+	}
+}
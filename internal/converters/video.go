@@ -3,20 +3,36 @@ package converters
 import (
 	"context"
 	"fmt"
+	"math"
+	"os"
 	"os/exec"
 	"strconv"
 	"strings"
 )
 
+// DefaultSeekPercent is where frames are taken from, as a percentage of the
+// video's duration, unless the caller asks for another position.
+const DefaultSeekPercent = 10
+
+const (
+	// minSeekDuration is the shortest clip worth seeking into; shorter ones
+	// are read from the start
+	minSeekDuration = 1.0
+	// seekEndMargin keeps frames after the seek point for the thumbnail filter
+	seekEndMargin = 1.0
+)
+
 // FFmpegConverter uses FFmpeg to generate thumbnails from video files
 type FFmpegConverter struct {
-	seekTime int // Default seek time in seconds to skip intros
+	seekTime    int     // Seek time in seconds when the duration is unknown
+	seekPercent float64 // Seek position as a percentage of the duration
 }
 
 // NewFFmpegConverter creates a new FFmpeg-based video converter
 func NewFFmpegConverter() *FFmpegConverter {
 	return &FFmpegConverter{
-		seekTime: 5, // Skip first 5 seconds by default to avoid blank frames
+		seekTime:    5, // Skip first 5 seconds to avoid blank frames when the duration is unknown
+		seekPercent: DefaultSeekPercent,
 	}
 }
 
@@ -33,6 +49,12 @@ func (f *FFmpegConverter) Supports(mimeType string) bool {
 // Convert generates a thumbnail from a video file
 // It uses FFmpeg's thumbnail filter to automatically select the most representative frame
 func (f *FFmpegConverter) Convert(ctx context.Context, input, output string, width, height int) error {
+	return f.ConvertWithOptions(ctx, input, output, width, height, ConversionOptions{})
+}
+
+// ConvertWithOptions generates a thumbnail from a video file using explicit encoding options.
+// FFmpeg cannot write progressive JPEGs, so opts.Progressive is ignored here.
+func (f *FFmpegConverter) ConvertWithOptions(ctx context.Context, input, output string, width, height int, opts ConversionOptions) error {
 	// Check if ffmpeg is available
 	if _, err := exec.LookPath("ffmpeg"); err != nil {
 		return fmt.Errorf("ffmpeg not found in PATH: %w", err)
@@ -45,25 +67,47 @@ func (f *FFmpegConverter) Convert(ctx context.Context, input, output string, wid
 		videoFilter = fmt.Sprintf("thumbnail,scale=%d:%d:force_original_aspect_ratio=decrease", width, height)
 	}
 
+	seekTime := f.seekPosition(ctx, input, opts)
+
+	err := f.extractThumbnail(ctx, input, output, seekTime, videoFilter, opts)
+	if seekTime > 0 && (err != nil || isEmptyFile(output)) {
+		// Seeking past the last decodable frame writes nothing; the duration
+		// reported by the container is not always reliable
+		err = f.extractThumbnail(ctx, input, output, 0, videoFilter, opts)
+	}
+	return err
+}
+
+// extractThumbnail runs FFmpeg once, seeking to seekTime seconds.
+func (f *FFmpegConverter) extractThumbnail(ctx context.Context, input, output string, seekTime float64, videoFilter string, opts ConversionOptions) error {
 	// Build ffmpeg command for intelligent thumbnail extraction
 	// -ss: Seek to position (before -i for faster parsing)
 	// -i: Input file
 	// -vf: Video filter (thumbnail + optional scale)
 	// -frames:v 1: Extract only one frame
-	// -pix_fmt yuvj420p: Pixel format for JPEG (full range YUV)
-	// -q:v 2: High quality (1-31, lower is better)
-	// -y: Overwrite output file
 	args := []string{
-		"-ss", strconv.Itoa(f.seekTime), // Skip intro
-		"-i", input,                      // Input file
-		"-vf", videoFilter,               // Smart frame selection + scaling
-		"-frames:v", "1",                 // Single frame
-		"-pix_fmt", "yuvj420p",           // Full-range YUV for JPEG
-		"-q:v", "2",                      // High quality
-		"-y",                             // Overwrite
-		output,                           // Output file
+		"-ss", strconv.FormatFloat(seekTime, 'f', 3, 64), // Skip intro
+		"-i", input,                                      // Input file
+		"-vf", videoFilter,                               // Smart frame selection + scaling
+		"-frames:v", "1",                                 // Single frame
 	}
 
+	switch format := opts.outputFormat(output); format {
+	case "jpeg":
+		// -pix_fmt yuvj420p: Pixel format for JPEG (full range YUV)
+		// -q:v: Quality scale (1-31, lower is better)
+		args = append(args, "-c:v", "mjpeg", "-pix_fmt", "yuvj420p", "-q:v", strconv.Itoa(ffmpegQScale(opts.Quality)))
+	case "png":
+		args = append(args, "-c:v", "png", "-pix_fmt", "rgb24")
+	default:
+		return fmt.Errorf("unsupported output format for ffmpeg: %s", format)
+	}
+
+	args = append(args,
+		"-y",   // Overwrite
+		output, // Output file
+	)
+
 	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
 
 	// Run command and capture output
@@ -75,14 +119,65 @@ func (f *FFmpegConverter) Convert(ctx context.Context, input, output string, wid
 	return nil
 }
 
+// seekPosition returns where to take the frame from, in seconds. An explicit
+// SeekTime wins, then a percentage of the duration (probed when the caller did
+// not pass it); without a duration the fixed seekTime is used. The position
+// always leaves frames before the end, and very short clips start at 0.
+func (f *FFmpegConverter) seekPosition(ctx context.Context, input string, opts ConversionOptions) float64 {
+	duration := opts.Duration
+	if duration <= 0 {
+		if info, err := f.Probe(ctx, input); err == nil {
+			duration = info.Duration
+		}
+	}
+
+	var seek float64
+	switch {
+	case opts.SeekTime > 0:
+		seek = opts.SeekTime
+	case duration > 0:
+		percent := f.seekPercent
+		if opts.SeekPercent > 0 {
+			percent = opts.SeekPercent
+		}
+		seek = duration * percent / 100
+	default:
+		seek = float64(f.seekTime)
+	}
+
+	if duration > 0 {
+		if duration < minSeekDuration {
+			return 0
+		}
+		seek = math.Min(seek, duration-seekEndMargin)
+	}
+	return math.Max(seek, 0)
+}
+
+func isEmptyFile(path string) bool {
+	info, err := os.Stat(path)
+	return err != nil || info.Size() == 0
+}
+
+// ffmpegQScale maps a 1-100 quality to FFmpeg's -q:v scale (1 best, 31 worst).
+// Zero keeps the previous default of 2.
+func ffmpegQScale(quality int) int {
+	if quality <= 0 {
+		return 2
+	}
+	if quality > 100 {
+		quality = 100
+	}
+	return 1 + (100-quality)*30/99
+}
+
 // Probe returns metadata about the video file
 func (f *FFmpegConverter) Probe(ctx context.Context, input string) (*FileInfo, error) {
 	// Use ffprobe to get video metadata
 	cmd := exec.CommandContext(ctx, "ffprobe",
 		"-v", "error",
 		"-select_streams", "v:0",
-		"-show_entries", "stream=width,height,duration",
-		"-show_entries", "format=size",
+		"-show_entries", "stream=width,height,duration:format=size,duration",
 		"-of", "default=noprint_wrappers=1",
 		input,
 	)
@@ -115,7 +210,9 @@ func (f *FFmpegConverter) Probe(ctx context.Context, input string) (*FileInfo, e
 				info.Height = h
 			}
 		case "duration":
-			if d, err := strconv.ParseFloat(value, 64); err == nil {
+			// Stream duration comes first; the container duration covers
+			// formats that leave it as N/A
+			if d, err := strconv.ParseFloat(value, 64); err == nil && info.Duration == 0 {
 				info.Duration = d
 			}
 		case "size":
@@ -128,10 +225,45 @@ func (f *FFmpegConverter) Probe(ctx context.Context, input string) (*FileInfo, e
 	return info, nil
 }
 
-// SetSeekTime sets the number of seconds to skip from the beginning
+// SetSeekTime sets the number of seconds to skip from the beginning when the
+// video's duration is unknown
 // Useful to avoid blank frames or intro sequences
 func (f *FFmpegConverter) SetSeekTime(seconds int) {
 	if seconds >= 0 {
 		f.seekTime = seconds
 	}
 }
+
+// SetSeekPercent sets the default seek position as a percentage (0-100) of
+// the video's duration
+func (f *FFmpegConverter) SetSeekPercent(percent float64) {
+	if percent >= 0 && percent < 100 {
+		f.seekPercent = percent
+	}
+}
+
+// ExtractFrame writes the frame at the given time, in seconds, scaled to
+// exactly width x height (or at full size when either is 0). Unlike Convert
+// it does not search for a representative frame, so storyboards stay in sync
+// with the timeline.
+func (f *FFmpegConverter) ExtractFrame(ctx context.Context, input, output string, at float64, width, height int) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("ffmpeg not found in PATH: %w", err)
+	}
+
+	args := []string{
+		"-ss", strconv.FormatFloat(at, 'f', 3, 64), // Fast seek to the frame
+		"-i", input,
+		"-frames:v", "1",
+	}
+	if width > 0 && height > 0 {
+		args = append(args, "-vf", fmt.Sprintf("scale=%d:%d:flags=lanczos", width, height))
+	}
+	args = append(args, "-an", "-y", output)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg frame extraction failed: %w\nOutput: %s", err, string(out))
+	}
+	return nil
+}
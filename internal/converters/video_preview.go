@@ -0,0 +1,140 @@
+package converters
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// PreviewOptions configures FFmpegConverter.Preview.
+type PreviewOptions struct {
+	Format        string  // gif, webp or mp4
+	Duration      float64 // Clip length in seconds
+	Segments      int     // Evenly spaced excerpts joined into the clip
+	VideoDuration float64 // Source length in seconds; 0 takes one excerpt from the start
+	FPS           int     // Frame rate of the clip
+	Quality       int     // 1-100; 0 keeps the encoder default
+}
+
+// minPreviewSegment keeps excerpts long enough to show motion.
+const minPreviewSegment = 0.5
+
+// Preview writes a short, looping, silent clip sampled from several points of
+// the video, scaled to exactly width x height. GIF and WebP loop by themselves;
+// MP4 clips rely on the player (e.g. <video loop muted>).
+func (f *FFmpegConverter) Preview(ctx context.Context, input, output string, width, height int, opts PreviewOptions) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("ffmpeg not found in PATH: %w", err)
+	}
+	if width <= 0 || height <= 0 || opts.Duration <= 0 || opts.FPS <= 0 {
+		return fmt.Errorf("invalid preview size %dx%d, duration %.2fs or fps %d", width, height, opts.Duration, opts.FPS)
+	}
+
+	starts, length := previewSegments(opts.VideoDuration, opts.Duration, opts.Segments)
+
+	// One input per excerpt; seeking before -i is fast and frame accurate enough
+	var args []string
+	var filter strings.Builder
+	for i, start := range starts {
+		args = append(args,
+			"-ss", strconv.FormatFloat(start, 'f', 3, 64),
+			"-t", strconv.FormatFloat(length, 'f', 3, 64),
+			"-i", input,
+		)
+		fmt.Fprintf(&filter, "[%d:v:0]fps=%d,scale=%d:%d:flags=lanczos,setsar=1,setpts=PTS-STARTPTS[v%d];", i, opts.FPS, width, height, i)
+	}
+	for i := range starts {
+		fmt.Fprintf(&filter, "[v%d]", i)
+	}
+	fmt.Fprintf(&filter, "concat=n=%d:v=1:a=0", len(starts))
+
+	switch opts.Format {
+	case "gif":
+		// A palette built from the clip itself looks far better than the default
+		filter.WriteString(",split[a][b];[a]palettegen=stats_mode=diff[p];[b][p]paletteuse=dither=bayer[out]")
+		args = append(args, "-filter_complex", filter.String(), "-map", "[out]", "-loop", "0")
+	case "webp":
+		filter.WriteString("[out]")
+		args = append(args, "-filter_complex", filter.String(), "-map", "[out]",
+			"-c:v", "libwebp", "-q:v", strconv.Itoa(webpQuality(opts.Quality)), "-loop", "0")
+	case "mp4":
+		filter.WriteString("[out]")
+		args = append(args, "-filter_complex", filter.String(), "-map", "[out]",
+			"-c:v", "libx264", "-preset", "veryfast", "-crf", strconv.Itoa(x264CRF(opts.Quality)),
+			"-pix_fmt", "yuv420p", "-movflags", "+faststart")
+	default:
+		return fmt.Errorf("unsupported preview format for ffmpeg: %s", opts.Format)
+	}
+
+	args = append(args,
+		"-an", // Silent
+		"-y",  // Overwrite
+		output,
+	)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg preview failed: %w\nOutput: %s", err, string(out))
+	}
+	return nil
+}
+
+// previewSegments returns the start times of up to segments excerpts, centred
+// on evenly spaced points of the video, and the length of each excerpt.
+func previewSegments(videoDuration, clipDuration float64, segments int) ([]float64, float64) {
+	if videoDuration > 0 && videoDuration <= clipDuration {
+		return []float64{0}, videoDuration
+	}
+	if segments < 1 || videoDuration <= 0 {
+		segments = 1
+	}
+	if limit := int(clipDuration / minPreviewSegment); segments > limit {
+		segments = limit
+	}
+	if segments < 1 {
+		segments = 1
+	}
+
+	length := clipDuration / float64(segments)
+	if videoDuration <= 0 {
+		return []float64{0}, length
+	}
+
+	starts := make([]float64, segments)
+	for i := range starts {
+		start := videoDuration*(float64(i)+0.5)/float64(segments) - length/2
+		if start < 0 {
+			start = 0
+		}
+		if start+length > videoDuration {
+			start = videoDuration - length
+		}
+		starts[i] = start
+	}
+	return starts, length
+}
+
+// webpQuality keeps FFmpeg's libwebp default of 75 for zero.
+func webpQuality(quality int) int {
+	if quality <= 0 {
+		return 75
+	}
+	if quality > 100 {
+		return 100
+	}
+	return quality
+}
+
+// x264CRF maps a 1-100 quality to an x264 CRF (18 near-lossless, 51 worst).
+// Zero uses 28, a little below x264's default of 23, which suits small previews.
+func x264CRF(quality int) int {
+	if quality <= 0 {
+		return 28
+	}
+	if quality > 100 {
+		quality = 100
+	}
+	return 18 + (100-quality)*33/99
+}
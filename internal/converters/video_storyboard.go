@@ -0,0 +1,54 @@
+package converters
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// StoryboardOptions configures FFmpegConverter.Storyboard.
+type StoryboardOptions struct {
+	Frames     int // Tiles sampled, one per interval
+	Columns    int // Sprite width in tiles
+	Rows       int // Sprite height in tiles
+	TileWidth  int // Exact tile size
+	TileHeight int
+	Interval   float64 // Seconds of video each tile covers
+}
+
+// Storyboard writes a sprite of opts.Frames frames, one from the middle of
+// each interval, tiled row by row, in a single FFmpeg pass. Tiles the video
+// runs out of before are left black.
+func (f *FFmpegConverter) Storyboard(ctx context.Context, input, output string, opts StoryboardOptions) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("ffmpeg not found in PATH: %w", err)
+	}
+	if opts.Frames <= 0 || opts.Columns <= 0 || opts.Rows <= 0 || opts.TileWidth <= 0 || opts.TileHeight <= 0 || opts.Interval <= 0 {
+		return fmt.Errorf("invalid storyboard of %d frames in %dx%d tiles of %dx%d every %.3fs",
+			opts.Frames, opts.Columns, opts.Rows, opts.TileWidth, opts.TileHeight, opts.Interval)
+	}
+
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-v", "error",
+		"-ss", strconv.FormatFloat(opts.Interval/2, 'f', 3, 64), // Sample the middle of each interval
+		"-i", input,
+		"-vf", storyboardFilter(opts),
+		"-frames:v", "1",
+		"-an",
+		"-y",
+		output,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg storyboard failed: %w\nOutput: %s", err, string(out))
+	}
+	return nil
+}
+
+// storyboardFilter samples one frame per interval, scales it to the tile
+// size and lays the tiles out in a grid.
+func storyboardFilter(opts StoryboardOptions) string {
+	return fmt.Sprintf("fps=1/%s,scale=%d:%d:flags=lanczos,setsar=1,tile=%dx%d:nb_frames=%d",
+		strconv.FormatFloat(opts.Interval, 'f', 6, 64),
+		opts.TileWidth, opts.TileHeight, opts.Columns, opts.Rows, opts.Frames)
+}
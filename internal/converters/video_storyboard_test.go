@@ -0,0 +1,11 @@
+package converters
+
+import "testing"
+
+func TestStoryboardFilter(t *testing.T) {
+	got := storyboardFilter(StoryboardOptions{Frames: 6, Columns: 4, Rows: 2, TileWidth: 160, TileHeight: 90, Interval: 2.5})
+	want := "fps=1/2.500000,scale=160:90:flags=lanczos,setsar=1,tile=4x2:nb_frames=6"
+	if got != want {
+		t.Errorf("storyboardFilter = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,406 @@
+package img
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/disintegration/imaging"
+)
+
+// VariantAnimated marks the animated output generated next to a spec's static
+// poster when the spec sets Animated and the source has several frames.
+const VariantAnimated = "animated"
+
+// minFrameDelay mirrors browsers, which play GIF and WebP frames with a delay
+// below 20ms at 100ms instead.
+const minFrameDelay = 20 * time.Millisecond
+
+// animation is a decoded multi-frame source. Every frame is composited onto
+// the full canvas, so frames can be resized independently.
+type animation struct {
+	frames    []*image.NRGBA
+	delays    []time.Duration
+	loopCount int // as in gif.GIF: 0 loops forever, -1 plays once
+}
+
+// isGIF reports whether the file at path starts with a GIF signature.
+func isGIF(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	sig := make([]byte, 6)
+	if _, err := io.ReadFull(f, sig); err != nil {
+		return false
+	}
+	return string(sig) == "GIF87a" || string(sig) == "GIF89a"
+}
+
+// decodeAnimation decodes the first maxFrames frames of a GIF, or all of
+// them when maxFrames is 0. It returns nil for sources with a single frame.
+// The frames are counted before decoding so the composited frames can be
+// checked against limits.MaxPixels up front.
+func decodeAnimation(path string, limits Limits, maxFrames int) (*animation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg, err := gif.DecodeConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("read gif header: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	frameCount, end, err := countGIFFrames(f, maxFrames)
+	if err != nil {
+		return nil, fmt.Errorf("scan gif frames: %w", err)
+	}
+	if frameCount < 2 {
+		return nil, nil
+	}
+	// Every frame is kept as a full canvas, so the budget covers all of them
+	if err := limits.checkPixels(cfg.Width*frameCount, cfg.Height); err != nil {
+		return nil, err
+	}
+
+	// Decode the counted frames only, closing the stream after the last one
+	r := io.MultiReader(io.NewSectionReader(f, 0, end), bytes.NewReader([]byte{gifTrailer}))
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode gif frames: %w", err)
+	}
+
+	anim := &animation{loopCount: g.LoopCount}
+	canvas := image.NewNRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	for i, frame := range g.Image {
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		var previous *image.NRGBA
+		if disposal == gif.DisposalPrevious {
+			previous = imaging.Clone(canvas)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		anim.frames = append(anim.frames, imaging.Clone(canvas))
+
+		delay := time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		if delay < minFrameDelay {
+			delay = 100 * time.Millisecond
+		}
+		anim.delays = append(anim.delays, delay)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return anim, nil
+}
+
+const gifTrailer = 0x3b
+
+// countGIFFrames counts image descriptors by walking the GIF block structure
+// without decompressing any pixel data, stopping after maxFrames frames when
+// maxFrames is positive. end is the offset just past the last counted frame.
+func countGIFFrames(r io.Reader, maxFrames int) (frames int, end int64, err error) {
+	br := bufio.NewReader(r)
+	var offset int64
+	read := func(n int) ([]byte, error) {
+		b := make([]byte, n)
+		m, err := io.ReadFull(br, b)
+		offset += int64(m)
+		return b, err
+	}
+	discard := func(n int) error {
+		m, err := br.Discard(n)
+		offset += int64(m)
+		return err
+	}
+
+	header, err := read(13) // signature + logical screen descriptor
+	if err != nil {
+		return 0, 0, err
+	}
+	if header[10]&0x80 != 0 {
+		if err := discard(3 << (header[10]&0x07 + 1)); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	for {
+		block, err := read(1)
+		if err != nil {
+			return 0, 0, err
+		}
+		switch block[0] {
+		case 0x21: // extension: label, then data sub-blocks
+			if _, err := read(1); err != nil {
+				return 0, 0, err
+			}
+		case 0x2c: // image descriptor, optional local colour table, LZW code size
+			desc, err := read(9)
+			if err != nil {
+				return 0, 0, err
+			}
+			if desc[8]&0x80 != 0 {
+				if err := discard(3 << (desc[8]&0x07 + 1)); err != nil {
+					return 0, 0, err
+				}
+			}
+			if _, err := read(1); err != nil {
+				return 0, 0, err
+			}
+		case gifTrailer:
+			return frames, end, nil
+		default:
+			return 0, 0, fmt.Errorf("unknown block 0x%02x", block[0])
+		}
+
+		// Skip data sub-blocks up to the zero-length terminator
+		for {
+			size, err := read(1)
+			if err != nil {
+				return 0, 0, err
+			}
+			if size[0] == 0 {
+				break
+			}
+			if err := discard(int(size[0])); err != nil {
+				return 0, 0, err
+			}
+		}
+
+		if block[0] == 0x2c {
+			frames++
+			end = offset
+			if frames == maxFrames {
+				return frames, end, nil
+			}
+		}
+	}
+}
+
+// animationFrames returns how many frames the animated specs need: the
+// largest MaxFrames, or 0 when one of them keeps every frame.
+func animationFrames(specs []ThumbnailSpec) int {
+	n := 0
+	for _, spec := range specs {
+		if !spec.Animated {
+			continue
+		}
+		if spec.MaxFrames == 0 {
+			return 0
+		}
+		n = max(n, spec.MaxFrames)
+	}
+	return n
+}
+
+// trimmed returns the frames that fit within the spec's MaxFrames and
+// MaxDuration. At least one frame is always kept.
+func (a *animation) trimmed(spec ThumbnailSpec) ([]*image.NRGBA, []time.Duration) {
+	n := len(a.frames)
+	if spec.MaxFrames > 0 && n > spec.MaxFrames {
+		n = spec.MaxFrames
+	}
+	if spec.MaxDuration > 0 {
+		var total time.Duration
+		for i := 0; i < n; i++ {
+			total += a.delays[i]
+			if total > spec.MaxDuration {
+				n = max(i, 1)
+				break
+			}
+		}
+	}
+	return a.frames[:n], a.delays[:n]
+}
+
+// animatedFormat is GIF unless the spec asks for WebP, the only other
+// animated format.
+func (s ThumbnailSpec) animatedFormat() OutputFormat {
+	if s.Format == FormatWebP {
+		return FormatWebP
+	}
+	return FormatGIF
+}
+
+// generateAnimated resizes every frame of anim and writes the animated output
+// for spec. crop is the poster's crop for ResizeFill, reused for every frame
+// so a smart crop does not jump around between frames.
+func generateAnimated(ctx context.Context, anim *animation, baseDstPath string, spec ThumbnailSpec, crop image.Rectangle) (ThumbnailOutput, error) {
+	frames, delays := anim.trimmed(spec)
+
+	resized := make([]*image.NRGBA, len(frames))
+	for i, frame := range frames {
+		if spec.resizeMode() == ResizeFill {
+			resized[i] = imaging.Resize(imaging.Crop(frame, crop), spec.Width, spec.Height, imaging.Lanczos)
+		} else {
+			resized[i], _ = resizeImage(frame, spec)
+		}
+	}
+
+	format := spec.animatedFormat()
+	name := spec.Name + "_" + VariantAnimated
+	dstPath := thumbnailPath(baseDstPath, ThumbnailSpec{Name: name}, format)
+
+	var quality int
+	var err error
+	if format == FormatWebP {
+		quality = spec.quality(FormatWebP)
+		err = encodeAnimatedWebP(ctx, resized, delays, anim.loopCount, dstPath, quality, spec.Lossless)
+	} else {
+		err = encodeAnimatedGIF(resized, delays, anim.loopCount, dstPath)
+	}
+	if err != nil {
+		return ThumbnailOutput{}, fmt.Errorf("save %s: %w", name, err)
+	}
+
+	var total time.Duration
+	for _, d := range delays {
+		total += d
+	}
+	b := resized[0].Bounds()
+	return ThumbnailOutput{
+		Name:       name,
+		Path:       dstPath,
+		Width:      b.Dx(),
+		Height:     b.Dy(),
+		Mode:       spec.resizeMode(),
+		Crop:       crop,
+		Format:     format,
+		Quality:    quality,
+		Variant:    VariantAnimated,
+		Animated:   true,
+		FrameCount: len(resized),
+		Duration:   total,
+	}, nil
+}
+
+func encodeAnimatedGIF(frames []*image.NRGBA, delays []time.Duration, loopCount int, dstPath string) error {
+	g := &gif.GIF{LoopCount: loopCount}
+	for i, frame := range frames {
+		g.Image = append(g.Image, quantizeFrame(frame))
+		g.Delay = append(g.Delay, int(delays[i]/(10*time.Millisecond)))
+		// Frames are full canvases, so clear before drawing the next one
+		g.Disposal = append(g.Disposal, gif.DisposalBackground)
+	}
+
+	f, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, g); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// quantizeFrame maps frame onto its own median cut palette without
+// dithering, which would shimmer between frames. Mostly transparent pixels
+// get a dedicated transparent palette entry.
+func quantizeFrame(frame *image.NRGBA) *image.Paletted {
+	colors := ExtractPalette(frame, 255)
+	palette := make(color.Palette, 0, len(colors)+1)
+	for _, c := range colors {
+		palette = append(palette, c)
+	}
+	if len(palette) == 0 {
+		palette = append(palette, color.Black)
+	}
+
+	b := frame.Bounds()
+	p := image.NewPaletted(b, palette)
+	draw.Draw(p, b, frame, b.Min, draw.Src)
+
+	transparent := -1
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if frame.Pix[frame.PixOffset(x, y)+3] >= paletteMinAlpha {
+				continue
+			}
+			if transparent < 0 {
+				p.Palette = append(p.Palette, color.Transparent)
+				transparent = len(p.Palette) - 1
+			}
+			p.SetColorIndex(x, y, uint8(transparent))
+		}
+	}
+	return p
+}
+
+// encodeAnimatedWebP writes an animated WebP with img2webp from libwebp.
+func encodeAnimatedWebP(ctx context.Context, frames []*image.NRGBA, delays []time.Duration, loopCount int, dstPath string, quality int, lossless bool) error {
+	if _, err := exec.LookPath("img2webp"); err != nil {
+		return fmt.Errorf("img2webp not found in PATH: %w (install with: brew install webp)", err)
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dstPath), "frames-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// WebP counts every play, GIF only the repeats
+	loops := 0
+	if loopCount < 0 {
+		loops = 1
+	} else if loopCount > 0 {
+		loops = loopCount + 1
+	}
+
+	args := []string{"-loop", strconv.Itoa(loops)}
+	if lossless {
+		args = append(args, "-lossless")
+	} else {
+		args = append(args, "-lossy", "-q", strconv.Itoa(quality))
+	}
+	for i, frame := range frames {
+		framePath := filepath.Join(tmpDir, fmt.Sprintf("%04d.png", i))
+		if err := imaging.Save(frame, framePath); err != nil {
+			return fmt.Errorf("write frame %d: %w", i, err)
+		}
+		args = append(args, "-d", strconv.FormatInt(delays[i].Milliseconds(), 10), framePath)
+	}
+	args = append(args, "-o", dstPath)
+
+	cmd := exec.CommandContext(ctx, "img2webp", args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("img2webp failed: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// parseDuration accepts Go durations ("2.5s", "1500ms") or plain seconds.
+func parseDuration(value string) (time.Duration, error) {
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d, nil
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	return 0, errors.New("invalid duration " + strconv.Quote(value) + " (expected e.g. 3s or 2.5)")
+}
@@ -0,0 +1,190 @@
+package img
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGenerateThumbnailsAnimatedGIF(t *testing.T) {
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "source.gif")
+	createTestGIF(t, srcPath, 200, 100, 4, 5)
+
+	specs := []ThumbnailSpec{
+		{Name: "small", Width: 50, Height: 50, Animated: true, MaxFrames: 3},
+		{Name: "static", Width: 50, Height: 50},
+	}
+	results, err := GenerateThumbnails(srcPath, filepath.Join(tmp, "thumb.gif"), specs)
+	if err != nil {
+		t.Fatalf("GenerateThumbnails returned error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected poster, animation and static outputs, got %d", len(results))
+	}
+
+	poster, animated := results[0], results[1]
+	if poster.Name != "small" || poster.Animated || poster.Variant != "" {
+		t.Errorf("poster: got name=%q animated=%v variant=%q", poster.Name, poster.Animated, poster.Variant)
+	}
+	if animated.Name != "small_animated" || !animated.Animated || animated.Variant != VariantAnimated {
+		t.Errorf("animated: got name=%q animated=%v variant=%q", animated.Name, animated.Animated, animated.Variant)
+	}
+	if animated.FrameCount != 3 || animated.Duration != 150*time.Millisecond {
+		t.Errorf("animated: got %d frames over %v, want 3 over 150ms", animated.FrameCount, animated.Duration)
+	}
+	if animated.Width != 50 || animated.Height != 25 || animated.SourceWidth != 200 {
+		t.Errorf("animated: got %dx%d from %d wide source, want 50x25 from 200", animated.Width, animated.Height, animated.SourceWidth)
+	}
+	if results[2].Name != "static" || results[2].Animated {
+		t.Errorf("static spec should only produce a poster, got %q", results[2].Name)
+	}
+
+	f, err := os.Open(animated.Path)
+	if err != nil {
+		t.Fatalf("open animated thumbnail: %v", err)
+	}
+	defer f.Close()
+	g, err := gif.DecodeAll(f)
+	if err != nil {
+		t.Fatalf("decode animated thumbnail: %v", err)
+	}
+	if len(g.Image) != 3 || g.Delay[0] != 5 {
+		t.Errorf("got %d frames with delay %d, want 3 with delay 5", len(g.Image), g.Delay[0])
+	}
+}
+
+func TestGenerateThumbnailsAnimatedStillImage(t *testing.T) {
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "source.gif")
+	createTestGIF(t, srcPath, 100, 100, 1, 10)
+
+	results, err := GenerateThumbnails(srcPath, filepath.Join(tmp, "thumb.gif"), []ThumbnailSpec{
+		{Name: "small", Width: 50, Height: 50, Animated: true},
+	})
+	if err != nil {
+		t.Fatalf("GenerateThumbnails returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Animated {
+		t.Errorf("single-frame GIF should only produce a poster, got %d outputs", len(results))
+	}
+}
+
+func TestAnimationTrimmedByDuration(t *testing.T) {
+	anim := &animation{
+		frames: make([]*image.NRGBA, 5),
+		delays: []time.Duration{time.Second, time.Second, time.Second, time.Second, time.Second},
+	}
+
+	frames, _ := anim.trimmed(ThumbnailSpec{MaxDuration: 2500 * time.Millisecond})
+	if len(frames) != 2 {
+		t.Errorf("got %d frames, want 2", len(frames))
+	}
+	frames, _ = anim.trimmed(ThumbnailSpec{MaxDuration: 100 * time.Millisecond})
+	if len(frames) != 1 {
+		t.Errorf("got %d frames, want at least 1", len(frames))
+	}
+}
+
+func TestDecodeAnimationLimits(t *testing.T) {
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "source.gif")
+	createTestGIF(t, srcPath, 100, 100, 4, 10)
+
+	// Each frame fits, all four composited frames do not
+	_, err := decodeAnimation(srcPath, Limits{MaxPixels: 30_000}, 0)
+	if err == nil {
+		t.Fatal("expected limit error")
+	}
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Value != 40_000 {
+		t.Errorf("got %v, want pixels limit error for 40000", err)
+	}
+
+	// Only the frames kept by MaxFrames count towards the budget
+	anim, err := decodeAnimation(srcPath, Limits{MaxPixels: 30_000}, 3)
+	if err != nil {
+		t.Fatalf("decodeAnimation returned error: %v", err)
+	}
+	if len(anim.frames) != 3 {
+		t.Errorf("got %d frames, want 3", len(anim.frames))
+	}
+}
+
+func TestGenerateThumbnailsAnimationOverLimit(t *testing.T) {
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "source.gif")
+	createTestGIF(t, srcPath, 100, 100, 4, 10)
+
+	// The animation is skipped, the poster is still written
+	results, err := generateThumbnails(context.Background(), srcPath, filepath.Join(tmp, "thumb.gif"), []ThumbnailSpec{
+		{Name: "small", Width: 50, Height: 50, Animated: true},
+	}, Limits{MaxPixels: 30_000})
+	if err != nil {
+		t.Fatalf("generateThumbnails returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Animated {
+		t.Errorf("got %d outputs, want only the poster", len(results))
+	}
+}
+
+func TestApplySpecOptionAnimated(t *testing.T) {
+	spec := ThumbnailSpec{Name: "preview", Width: 200, Height: 200}
+	for _, opt := range []string{"animated", "frames=24", "duration=2.5"} {
+		if err := ApplySpecOption(&spec, opt); err != nil {
+			t.Fatalf("ApplySpecOption(%q) returned error: %v", opt, err)
+		}
+	}
+	if !spec.Animated || spec.MaxFrames != 24 || spec.MaxDuration != 2500*time.Millisecond {
+		t.Errorf("got animated=%v frames=%d duration=%v", spec.Animated, spec.MaxFrames, spec.MaxDuration)
+	}
+
+	if err := ApplyHints(&spec, map[string]string{HintMaxDuration: "1s"}); err != nil {
+		t.Fatalf("ApplyHints returned error: %v", err)
+	}
+	if spec.MaxDuration != time.Second {
+		t.Errorf("MaxDuration = %v, want 1s", spec.MaxDuration)
+	}
+
+	for _, opt := range []string{"frames=0", "duration=soon", "animated=maybe"} {
+		if err := ApplySpecOption(&spec, opt); err == nil {
+			t.Errorf("ApplySpecOption(%q) expected error", opt)
+		}
+	}
+}
+
+// createTestGIF writes an animated GIF whose frames cycle through solid
+// colours, each shown for delay hundredths of a second.
+func createTestGIF(t *testing.T, path string, w, h, frames, delay int) {
+	t.Helper()
+
+	palette := color.Palette{
+		color.NRGBA{R: 200, A: 255},
+		color.NRGBA{G: 200, A: 255},
+		color.NRGBA{B: 200, A: 255},
+	}
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, w, h), palette)
+		for p := range frame.Pix {
+			frame.Pix[p] = uint8(i % len(palette))
+		}
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, delay)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create gif: %v", err)
+	}
+	defer f.Close()
+	if err := gif.EncodeAll(f, g); err != nil {
+		t.Fatalf("encode gif: %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package img
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"os"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"golang.org/x/image/webp"
+)
+
+// WebP VP8X flags and ANMF frame flags
+const (
+	webpAnimationFlag = 1 << 1
+	webpAlphaFlag     = 1 << 4
+
+	webpDisposeFlag = 1 << 0 // dispose the frame area to transparent
+	webpNoBlendFlag = 1 << 1 // replace the frame area instead of blending
+)
+
+// isAnimatedWebP reports whether the file at path is a WebP whose VP8X chunk
+// sets the animation flag. The WebP decoder only reads still images, so
+// these must be decoded with decodeAnimatedWebP.
+func isAnimatedWebP(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	header := make([]byte, 21) // RIFF header, VP8X chunk header and flags
+	if _, err := io.ReadFull(f, header); err != nil {
+		return false
+	}
+	return string(header[:4]) == "RIFF" && string(header[8:12]) == "WEBP" &&
+		string(header[12:16]) == "VP8X" && header[20]&webpAnimationFlag != 0
+}
+
+// webpFrame is an ANMF chunk: where a frame goes on the canvas, for how long,
+// and the chunks holding its bitstream.
+type webpFrame struct {
+	bounds  image.Rectangle
+	delay   time.Duration
+	flags   byte
+	bitmaps []byte // ALPH, VP8 and VP8L chunks, headers included
+}
+
+// decodeAnimatedWebP decodes the first maxFrames frames of an animated WebP,
+// or all of them when maxFrames is 0. Like decodeAnimation, it composites
+// every frame onto the full canvas and checks the composited frames against
+// limits.MaxPixels before decoding any.
+func decodeAnimatedWebP(path string, limits Limits, maxFrames int) (*animation, error) {
+	if err := limits.checkFileSize(path); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	width, height, loops, frames, err := parseAnimatedWebP(data)
+	if err != nil {
+		return nil, fmt.Errorf("read webp animation: %w", err)
+	}
+	if len(frames) == 0 {
+		return nil, errors.New("webp animation has no frames")
+	}
+	if maxFrames > 0 && len(frames) > maxFrames {
+		frames = frames[:maxFrames]
+	}
+	// Every frame is kept as a full canvas, so the budget covers all of them
+	if err := limits.checkPixels(width*len(frames), height); err != nil {
+		return nil, err
+	}
+
+	// WebP counts every play, GIF only the repeats
+	anim := &animation{}
+	switch {
+	case loops == 1:
+		anim.loopCount = -1
+	case loops > 1:
+		anim.loopCount = loops - 1
+	}
+
+	canvas := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i, frame := range frames {
+		if !frame.bounds.In(canvas.Bounds()) {
+			return nil, fmt.Errorf("webp frame %d lies outside the %dx%d canvas", i, width, height)
+		}
+		img, err := decodeWebPFrame(frame)
+		if err != nil {
+			return nil, fmt.Errorf("decode webp frame %d: %w", i, err)
+		}
+
+		op := draw.Over
+		if frame.flags&webpNoBlendFlag != 0 {
+			op = draw.Src
+		}
+		draw.Draw(canvas, frame.bounds, img, img.Bounds().Min, op)
+		anim.frames = append(anim.frames, imaging.Clone(canvas))
+
+		delay := frame.delay
+		if delay < minFrameDelay {
+			delay = 100 * time.Millisecond
+		}
+		anim.delays = append(anim.delays, delay)
+
+		if frame.flags&webpDisposeFlag != 0 {
+			draw.Draw(canvas, frame.bounds, image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+	return anim, nil
+}
+
+// parseAnimatedWebP walks the RIFF chunks of an animated WebP and returns its
+// canvas size, its loop count (0 loops forever) and its frames.
+func parseAnimatedWebP(data []byte) (width, height, loops int, frames []webpFrame, err error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return 0, 0, 0, nil, errors.New("not a WebP file")
+	}
+	if size := int64(binary.LittleEndian.Uint32(data[4:8])) + 8; size < int64(len(data)) {
+		data = data[:size]
+	}
+
+	err = walkRIFFChunks(data[12:], func(id string, payload []byte) error {
+		switch id {
+		case "VP8X":
+			if len(payload) < 10 {
+				return errors.New("short VP8X chunk")
+			}
+			width, height = int(uint24(payload[4:]))+1, int(uint24(payload[7:]))+1
+		case "ANIM":
+			if len(payload) < 6 {
+				return errors.New("short ANIM chunk")
+			}
+			loops = int(binary.LittleEndian.Uint16(payload[4:6]))
+		case "ANMF":
+			if len(payload) < 16 {
+				return errors.New("short ANMF chunk")
+			}
+			x, y := 2*int(uint24(payload[0:])), 2*int(uint24(payload[3:]))
+			w, h := int(uint24(payload[6:]))+1, int(uint24(payload[9:]))+1
+			frame := webpFrame{
+				bounds: image.Rect(x, y, x+w, y+h),
+				delay:  time.Duration(uint24(payload[12:])) * time.Millisecond,
+				flags:  payload[15],
+			}
+			// Keep the bitstream chunks, skipping any unknown ones
+			err := walkRIFFChunks(payload[16:], func(id string, chunk []byte) error {
+				if id == "ALPH" || id == "VP8 " || id == "VP8L" {
+					frame.bitmaps = appendRIFFChunk(frame.bitmaps, id, chunk)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			frames = append(frames, frame)
+		}
+		return nil
+	})
+	if err == nil && (width == 0 || height == 0) {
+		err = errors.New("missing VP8X chunk")
+	}
+	return width, height, loops, frames, err
+}
+
+// decodeWebPFrame decodes the bitstream of one frame by wrapping it in a
+// still WebP file. Lossy frames with an alpha channel need a VP8X chunk
+// declaring it. The bitstream must have the size its ANMF chunk declares,
+// which was checked against the limits.
+func decodeWebPFrame(frame webpFrame) (image.Image, error) {
+	var body []byte
+	body = append(body, "WEBP"...)
+	if bytes.HasPrefix(frame.bitmaps, []byte("ALPH")) {
+		vp8x := make([]byte, 10)
+		vp8x[0] = webpAlphaFlag
+		putUint24(vp8x[4:], uint32(frame.bounds.Dx()-1))
+		putUint24(vp8x[7:], uint32(frame.bounds.Dy()-1))
+		body = appendRIFFChunk(body, "VP8X", vp8x)
+	}
+	body = append(body, frame.bitmaps...)
+
+	file := appendRIFFChunk(nil, "RIFF", body)
+	cfg, err := webp.DecodeConfig(bytes.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+	if size := frame.bounds.Size(); cfg.Width != size.X || cfg.Height != size.Y {
+		return nil, fmt.Errorf("bitstream is %dx%d, frame is %dx%d", cfg.Width, cfg.Height, size.X, size.Y)
+	}
+	return webp.Decode(bytes.NewReader(file))
+}
+
+// walkRIFFChunks calls fn with the ID and payload of each chunk in data.
+func walkRIFFChunks(data []byte, fn func(id string, payload []byte) error) error {
+	for len(data) >= 8 {
+		id := string(data[:4])
+		size := int64(binary.LittleEndian.Uint32(data[4:8]))
+		if size > int64(len(data)-8) {
+			return fmt.Errorf("truncated %q chunk", id)
+		}
+		if err := fn(id, data[8:8+size]); err != nil {
+			return err
+		}
+		// Chunks are padded to an even size
+		next := 8 + size + size&1
+		if next > int64(len(data)) {
+			break
+		}
+		data = data[next:]
+	}
+	return nil
+}
+
+// appendRIFFChunk appends a chunk with the given ID and payload to dst.
+func appendRIFFChunk(dst []byte, id string, payload []byte) []byte {
+	dst = append(dst, id...)
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(payload)))
+	dst = append(dst, payload...)
+	if len(payload)%2 == 1 {
+		dst = append(dst, 0)
+	}
+	return dst
+}
+
+func uint24(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
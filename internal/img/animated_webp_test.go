@@ -0,0 +1,200 @@
+package img
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testWebPFrame is one ANMF frame of a solid colour.
+type testWebPFrame struct {
+	rect  image.Rectangle // offsets must be even
+	color color.NRGBA
+	delay time.Duration
+	flags byte
+}
+
+// createTestAnimatedWebP writes an animated WebP of solid-colour lossless
+// frames, which the VP8L format can express in a few bytes.
+func createTestAnimatedWebP(t *testing.T, path string, width, height, loops int, frames []testWebPFrame) {
+	t.Helper()
+
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpAnimationFlag | webpAlphaFlag
+	putUint24(vp8x[4:], uint32(width-1))
+	putUint24(vp8x[7:], uint32(height-1))
+	body := appendRIFFChunk([]byte("WEBP"), "VP8X", vp8x)
+
+	anim := make([]byte, 6)
+	binary.LittleEndian.PutUint16(anim[4:], uint16(loops))
+	body = appendRIFFChunk(body, "ANIM", anim)
+
+	for _, f := range frames {
+		anmf := make([]byte, 16)
+		putUint24(anmf[0:], uint32(f.rect.Min.X/2))
+		putUint24(anmf[3:], uint32(f.rect.Min.Y/2))
+		putUint24(anmf[6:], uint32(f.rect.Dx()-1))
+		putUint24(anmf[9:], uint32(f.rect.Dy()-1))
+		putUint24(anmf[12:], uint32(f.delay.Milliseconds()))
+		anmf[15] = f.flags
+		anmf = appendRIFFChunk(anmf, "VP8L", solidVP8L(f.rect.Dx(), f.rect.Dy(), f.color))
+		body = appendRIFFChunk(body, "ANMF", anmf)
+	}
+
+	if err := os.WriteFile(path, appendRIFFChunk(nil, "RIFF", body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// solidVP8L encodes a lossless bitstream whose prefix codes each have a
+// single symbol, so every pixel is c and takes no bits.
+func solidVP8L(width, height int, c color.NRGBA) []byte {
+	var w lsbWriter
+	w.write(0x2f, 8)
+	w.write(uint32(width-1), 14)
+	w.write(uint32(height-1), 14)
+	w.write(1, 1) // alpha is used
+	w.write(0, 3) // version
+	w.write(0, 1) // no transform
+	w.write(0, 1) // no colour cache
+	w.write(0, 1) // no meta prefix codes
+	for _, v := range []uint8{c.G, c.R, c.B, c.A} {
+		w.write(1, 1) // simple code
+		w.write(0, 1) // one symbol
+		w.write(1, 1) // of 8 bits
+		w.write(uint32(v), 8)
+	}
+	w.write(0b001, 3) // distance: simple code, one symbol of 1 bit
+	w.write(0, 1)
+	return w.bytes()
+}
+
+type lsbWriter struct {
+	buf   []byte
+	nBits uint
+}
+
+func (w *lsbWriter) write(v uint32, n uint) {
+	for i := uint(0); i < n; i++ {
+		if w.nBits%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		w.buf[len(w.buf)-1] |= byte(v>>i&1) << (w.nBits % 8)
+		w.nBits++
+	}
+}
+
+func (w *lsbWriter) bytes() []byte {
+	return w.buf
+}
+
+func TestDecodeAnimatedWebP(t *testing.T) {
+	srcPath := filepath.Join(t.TempDir(), "source.webp")
+	red := color.NRGBA{R: 255, A: 255}
+	blue := color.NRGBA{B: 255, A: 255}
+	createTestAnimatedWebP(t, srcPath, 40, 20, 1, []testWebPFrame{
+		{rect: image.Rect(0, 0, 40, 20), color: red, delay: 50 * time.Millisecond},
+		{rect: image.Rect(20, 10, 40, 20), color: blue, delay: 50 * time.Millisecond, flags: webpDisposeFlag},
+		{rect: image.Rect(0, 0, 2, 2), color: color.NRGBA{}, delay: 10 * time.Millisecond},
+	})
+
+	if !isAnimatedWebP(srcPath) {
+		t.Fatal("isAnimatedWebP = false, want true")
+	}
+	anim, err := decodeAnimatedWebP(srcPath, Limits{}, 0)
+	if err != nil {
+		t.Fatalf("decodeAnimatedWebP returned error: %v", err)
+	}
+	if len(anim.frames) != 3 || anim.loopCount != -1 {
+		t.Fatalf("got %d frames looping %d, want 3 playing once", len(anim.frames), anim.loopCount)
+	}
+	if anim.delays[0] != 50*time.Millisecond || anim.delays[2] != 100*time.Millisecond {
+		t.Errorf("got delays %v, want 50ms and 100ms for the too short last one", anim.delays)
+	}
+
+	// The second frame covers the bottom right quarter, then is disposed;
+	// the third, transparent one blends onto the red canvas
+	if got := anim.frames[1].NRGBAAt(30, 15); got != blue {
+		t.Errorf("frame 1: got %v in the bottom right, want blue", got)
+	}
+	if got := anim.frames[1].NRGBAAt(5, 5); got != red {
+		t.Errorf("frame 1: got %v in the top left, want red", got)
+	}
+	if got := anim.frames[2].NRGBAAt(30, 15); got != (color.NRGBA{}) {
+		t.Errorf("frame 2: got %v in the disposed area, want transparent", got)
+	}
+	if got := anim.frames[2].NRGBAAt(1, 1); got != red {
+		t.Errorf("frame 2: got %v under the transparent frame, want red", got)
+	}
+
+	if _, err := decodeAnimatedWebP(srcPath, Limits{MaxPixels: 40 * 20 * 2}, 0); err == nil {
+		t.Error("expected a limit error for three 40x20 frames")
+	}
+	if anim, err := decodeAnimatedWebP(srcPath, Limits{MaxPixels: 40 * 20}, 1); err != nil || len(anim.frames) != 1 {
+		t.Errorf("poster only: got %v", err)
+	}
+}
+
+func TestDecodeAnimatedWebPFrameSize(t *testing.T) {
+	srcPath := filepath.Join(t.TempDir(), "source.webp")
+	createTestAnimatedWebP(t, srcPath, 40, 20, 0, []testWebPFrame{
+		{rect: image.Rect(0, 0, 40, 20), color: color.NRGBA{R: 255, A: 255}},
+		{rect: image.Rect(0, 0, 40, 20), color: color.NRGBA{G: 255, A: 255}},
+	})
+
+	// Declare a 2x2 second frame around its 40x20 bitstream
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := bytes.LastIndex(data, []byte("ANMF")) + 8
+	putUint24(data[second+6:], 1)
+	putUint24(data[second+9:], 1)
+	if err := os.WriteFile(srcPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := decodeAnimatedWebP(srcPath, Limits{}, 0); err == nil {
+		t.Error("expected an error for a bitstream larger than its frame")
+	}
+}
+
+func TestGenerateThumbnailsAnimatedWebP(t *testing.T) {
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "source.webp")
+	createTestAnimatedWebP(t, srcPath, 200, 100, 0, []testWebPFrame{
+		{rect: image.Rect(0, 0, 200, 100), color: color.NRGBA{R: 255, A: 255}, delay: 80 * time.Millisecond},
+		{rect: image.Rect(0, 0, 200, 100), color: color.NRGBA{G: 255, A: 255}, delay: 80 * time.Millisecond},
+	})
+
+	results, err := GenerateThumbnails(srcPath, filepath.Join(tmp, "thumb.png"), []ThumbnailSpec{
+		{Name: "small", Width: 50, Height: 50, Animated: true},
+		{Name: "static", Width: 50, Height: 50},
+	})
+	if err != nil {
+		t.Fatalf("GenerateThumbnails returned error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected poster, animation and static outputs, got %d", len(results))
+	}
+	animated := results[1]
+	if animated.Variant != VariantAnimated || animated.FrameCount != 2 || animated.Duration != 160*time.Millisecond {
+		t.Errorf("animated: got variant=%q %d frames over %v", animated.Variant, animated.FrameCount, animated.Duration)
+	}
+	if poster := results[0]; poster.SourceWidth != 200 || poster.Palette.Dominant != "#ff0000" {
+		t.Errorf("poster: got %d wide source with dominant %s, want the red first frame", poster.SourceWidth, poster.Palette.Dominant)
+	}
+
+	// Without animated specs only the first frame is decoded
+	results, err = GenerateThumbnails(srcPath, filepath.Join(tmp, "still.png"), []ThumbnailSpec{
+		{Name: "static", Width: 50, Height: 50},
+	})
+	if err != nil || len(results) != 1 || results[0].Animated {
+		t.Errorf("static specs: got %d outputs, err %v", len(results), err)
+	}
+}
@@ -0,0 +1,132 @@
+package img
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+
+	"github.com/tendant/simple-thumbnailer/internal/converters"
+)
+
+// AudioGenerator implements Generator for audio files using FFmpeg.
+// Files with embedded cover art are thumbnailed from the art like images;
+// the rest get a waveform drawn at each spec's size.
+type AudioGenerator struct {
+	converter *converters.AudioConverter
+
+	// Limits bounds the sources that will be processed. The zero value is unlimited.
+	Limits Limits
+}
+
+// NewAudioGenerator creates a new audio thumbnail generator
+func NewAudioGenerator() *AudioGenerator {
+	return &AudioGenerator{
+		converter: converters.NewAudioConverter(),
+		Limits:    DefaultLimits,
+	}
+}
+
+// Generate implements Generator.Generate for audio
+// Cover art is extracted once at full resolution and resized per spec (JPEG
+// by default). Waveforms are decoded once and drawn at exactly each spec's
+// box (PNG by default); the resize mode does not apply to them.
+func (g *AudioGenerator) Generate(ctx context.Context, srcPath string, baseDstPath string, specs []ThumbnailSpec) ([]ThumbnailOutput, error) {
+	if err := g.Limits.checkFileSize(srcPath); err != nil {
+		return nil, err
+	}
+
+	fileInfo, err := g.converter.Probe(ctx, srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("probe: %w", err)
+	}
+	if err := g.Limits.checkDuration(fileInfo.Duration); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(baseDstPath), 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir: %w", err)
+	}
+
+	if fileInfo.Width > 0 && fileInfo.Height > 0 {
+		if err := g.Limits.checkPixels(fileInfo.Width, fileInfo.Height); err != nil {
+			return nil, err
+		}
+		cover, err := g.extractCoverArt(ctx, srcPath, baseDstPath)
+		if err != nil {
+			return nil, fmt.Errorf("extract cover art: %w", err)
+		}
+		return decodedThumbnails(ctx, cover, baseDstPath, specs, FormatJPEG)
+	}
+
+	waveform, err := g.converter.DecodeWaveform(ctx, srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("decode waveform: %w", err)
+	}
+	return waveformThumbnails(ctx, waveform, baseDstPath, specs)
+}
+
+// extractCoverArt decodes the embedded cover art at full resolution.
+func (g *AudioGenerator) extractCoverArt(ctx context.Context, srcPath, baseDstPath string) (image.Image, error) {
+	tmpDir, err := os.MkdirTemp(filepath.Dir(baseDstPath), "cover-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	coverPath := filepath.Join(tmpDir, "cover.png")
+	if err := g.converter.ExtractCoverArt(ctx, srcPath, coverPath, 0, 0, converters.ConversionOptions{}); err != nil {
+		return nil, err
+	}
+	return openImage(coverPath, Limits{MaxPixels: g.Limits.MaxPixels})
+}
+
+// waveformThumbnails draws the waveform at every spec's size, on the spec's
+// background colour when it has one.
+func waveformThumbnails(ctx context.Context, waveform *converters.Waveform, baseDstPath string, specs []ThumbnailSpec) ([]ThumbnailOutput, error) {
+	var results []ThumbnailOutput
+	var sourceHash PerceptualHash
+	var palette Palette
+	for i, spec := range specs {
+		bg := color.Color(converters.WaveformBackground)
+		if spec.Background != nil {
+			bg = spec.Background
+		}
+		thumb := waveform.Render(spec.Width, spec.Height, converters.WaveformColor, bg)
+		// Analyse the first rendering; every output carries the same values
+		if i == 0 {
+			sourceHash = ComputePerceptualHash(thumb)
+			palette = ComputePalette(thumb)
+		}
+
+		outputPath := thumbnailPath(baseDstPath, spec, FormatPNG)
+		quality, err := saveImage(ctx, thumb, outputPath, spec)
+		if err != nil {
+			return nil, fmt.Errorf("save %s: %w", spec.Name, err)
+		}
+
+		results = append(results, ThumbnailOutput{
+			Name:       spec.Name,
+			Path:       outputPath,
+			Width:      spec.Width,
+			Height:     spec.Height,
+			Mode:       spec.resizeMode(),
+			Format:     spec.outputFormat(FormatPNG),
+			Quality:    quality,
+			SourceHash: sourceHash,
+			Palette:    palette,
+		})
+	}
+	return results, nil
+}
+
+// Supports implements Generator.Supports for audio
+func (g *AudioGenerator) Supports(mimeType string) bool {
+	return g.converter.Supports(mimeType)
+}
+
+// Name implements Generator.Name
+func (g *AudioGenerator) Name() string {
+	return "audio"
+}
@@ -0,0 +1,45 @@
+package img
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestAudioGeneratorWaveform(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not installed")
+	}
+	tmpDir := t.TempDir()
+	sample := filepath.Join(tmpDir, "tone.wav")
+	cmd := exec.Command("ffmpeg", "-v", "error", "-f", "lavfi", "-i", "sine=frequency=440:duration=2", "-y", sample)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("create sample: %v\n%s", err, out)
+	}
+
+	results, err := NewAudioGenerator().Generate(context.Background(), sample, filepath.Join(tmpDir, "thumb.png"), []ThumbnailSpec{
+		{Name: "small", Width: 200, Height: 50},
+		{Name: "large", Width: 800, Height: 200, Format: FormatJPEG},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 outputs, got %d", len(results))
+	}
+	for _, r := range results {
+		width, height, err := imageSize(r.Path)
+		if err != nil {
+			t.Errorf("%s: read dimensions: %v", r.Name, err)
+		} else if r.Width != width || r.Height != height {
+			t.Errorf("%s: reported %dx%d, file is %dx%d", r.Name, r.Width, r.Height, width, height)
+		}
+	}
+	if results[0].Format != FormatPNG || results[1].Format != FormatJPEG {
+		t.Errorf("got formats %q and %q, want png and jpeg", results[0].Format, results[1].Format)
+	}
+	if results[0].SourceHash.PHash == "" || results[1].SourceHash != results[0].SourceHash {
+		t.Errorf("source hash %+v, want shared non-empty hash", results[1].SourceHash)
+	}
+}
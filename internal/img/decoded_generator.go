@@ -0,0 +1,136 @@
+package img
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+
+	"github.com/tendant/simple-thumbnailer/internal/converters"
+)
+
+// DecodeFunc decodes the source at srcPath to an upright image. tmpDir is an
+// empty directory for intermediate files, removed once Generate returns, and
+// limits are those of the generator, for decoders that write a bitmap to
+// disk before reading it back.
+type DecodeFunc func(ctx context.Context, srcPath, tmpDir string, limits Limits) (image.Image, error)
+
+// DecodedGenerator implements Generator for still images the imaging library
+// cannot read. The size recorded in the file is checked before anything is
+// decoded, then the source is decoded once by a format-specific DecodeFunc
+// and resized in Go like any other image.
+type DecodedGenerator struct {
+	name          string
+	converter     converters.Converter
+	decode        DecodeFunc
+	defaultFormat OutputFormat
+
+	// Limits bounds the sources that will be processed. The zero value is unlimited.
+	Limits Limits
+}
+
+// NewDecodedGenerator creates a generator named name for the MIME types
+// converter supports. converter probes the source size and decode reads it;
+// thumbnails are in defaultFormat unless a spec asks for another one.
+func NewDecodedGenerator(name string, converter converters.Converter, decode DecodeFunc, defaultFormat OutputFormat) *DecodedGenerator {
+	return &DecodedGenerator{
+		name:          name,
+		converter:     converter,
+		decode:        decode,
+		defaultFormat: defaultFormat,
+		Limits:        DefaultLimits,
+	}
+}
+
+// NewHEIFGenerator creates a generator for HEIC, HEIF and AVIF images. They
+// are decoded with libheif to a full-size bitmap, upright, and thumbnailed
+// as JPEG by default, as phones use HEIC for photos.
+func NewHEIFGenerator() *DecodedGenerator {
+	c := converters.NewHEIFConverter()
+	return NewDecodedGenerator("heif", c, func(ctx context.Context, srcPath, tmpDir string, limits Limits) (image.Image, error) {
+		decodedPath := filepath.Join(tmpDir, "image.png")
+		if err := c.Decode(ctx, srcPath, decodedPath); err != nil {
+			return nil, err
+		}
+		src, err := openImage(decodedPath, Limits{MaxPixels: limits.MaxPixels})
+		if err != nil {
+			return nil, fmt.Errorf("open decoded image: %w", err)
+		}
+		return src, nil
+	}, FormatJPEG)
+}
+
+// NewRawGenerator creates a generator for camera RAW photos (CR2, NEF, ARW,
+// DNG). The JPEG preview embedded by the camera is resized when it is large
+// enough, which avoids decoding the sensor data. Thumbnails are JPEG by
+// default and upright according to the file's orientation tag.
+func NewRawGenerator() *DecodedGenerator {
+	c := converters.NewRawConverter()
+	return NewDecodedGenerator("raw", c, func(ctx context.Context, srcPath, _ string, _ Limits) (image.Image, error) {
+		return c.Decode(ctx, srcPath)
+	}, FormatJPEG)
+}
+
+// NewTIFFGenerator creates a generator for TIFF images. Multi-page TIFFs,
+// such as scanned documents, are thumbnailed from their largest page rather
+// than whatever the first IFD holds, turned upright by its orientation tag.
+// Thumbnails are PNG by default.
+func NewTIFFGenerator() *DecodedGenerator {
+	c := converters.NewTIFFConverter()
+	return NewDecodedGenerator("tiff", c, func(_ context.Context, srcPath, _ string, _ Limits) (image.Image, error) {
+		return c.Decode(srcPath, 0)
+	}, FormatPNG)
+}
+
+// NewPSDGenerator creates a generator for Photoshop documents (PSD and PSB)
+// using the flattened composite image stored alongside the layers.
+// Thumbnails are PNG by default.
+func NewPSDGenerator() *DecodedGenerator {
+	c := converters.NewPSDConverter()
+	return NewDecodedGenerator("psd", c, func(_ context.Context, srcPath, _ string, _ Limits) (image.Image, error) {
+		return c.Decode(srcPath)
+	}, FormatPNG)
+}
+
+// Generate implements Generator.Generate for decoded images
+// A source that does not record its size is refused rather than handed to
+// the decoder.
+func (g *DecodedGenerator) Generate(ctx context.Context, srcPath string, baseDstPath string, specs []ThumbnailSpec) ([]ThumbnailOutput, error) {
+	if err := g.Limits.checkFileSize(srcPath); err != nil {
+		return nil, err
+	}
+
+	fileInfo, err := g.converter.Probe(ctx, srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("probe: %w", err)
+	}
+	if err := g.Limits.checkProbedPixels(fileInfo.Width, fileInfo.Height); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(baseDstPath), 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(baseDstPath), g.name+"-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	src, err := g.decode(ctx, srcPath, tmpDir, g.Limits)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	return decodedThumbnails(ctx, src, baseDstPath, specs, g.defaultFormat)
+}
+
+// Supports implements Generator.Supports for decoded images
+func (g *DecodedGenerator) Supports(mimeType string) bool {
+	return g.converter.Supports(mimeType)
+}
+
+// Name implements Generator.Name
+func (g *DecodedGenerator) Name() string {
+	return g.name
+}
@@ -0,0 +1,175 @@
+package img
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/tendant/simple-thumbnailer/internal/converters"
+	"golang.org/x/image/tiff"
+)
+
+func TestDecodedGeneratorDecodeFunc(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "scan.tif")
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tiff.Encode(f, imaging.New(200, 100, color.White), nil); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	// The probed size is checked, but the image comes from decode
+	var gotTmpDir string
+	gen := NewDecodedGenerator("test", converters.NewTIFFConverter(), func(_ context.Context, _, tmpDir string, _ Limits) (image.Image, error) {
+		gotTmpDir = tmpDir
+		return imaging.New(200, 100, color.NRGBA{R: 255, A: 255}), nil
+	}, FormatJPEG)
+	results, err := gen.Generate(context.Background(), src, filepath.Join(tmp, "thumb", "scan.tif"), []ThumbnailSpec{
+		{Name: "small", Width: 50, Height: 50},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if r := results[0]; r.Format != FormatJPEG || r.Width != 50 || r.Height != 25 || r.Palette.Dominant != "#ff0000" {
+		t.Errorf("got %s %dx%d with dominant %s", r.Format, r.Width, r.Height, r.Palette.Dominant)
+	}
+	if _, err := os.Stat(gotTmpDir); gotTmpDir == "" || !os.IsNotExist(err) {
+		t.Errorf("temporary directory %q was not removed", gotTmpDir)
+	}
+
+	gen.Limits.MaxPixels = 100 * 100
+	if _, err := gen.Generate(context.Background(), src, filepath.Join(tmp, "big", "scan.tif"), []ThumbnailSpec{{Name: "small", Width: 50, Height: 50}}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("pixel limit: got %v, want ErrLimitExceeded", err)
+	}
+}
+
+func TestHEIFGeneratorGenerate(t *testing.T) {
+	if _, err := exec.LookPath("heif-enc"); err != nil {
+		t.Skip("heif-enc not installed")
+	}
+	tmp := t.TempDir()
+	png := filepath.Join(tmp, "photo.png")
+	if err := imaging.Save(imaging.New(320, 240, color.NRGBA{200, 30, 30, 255}), png); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(tmp, "photo.heic")
+	if out, err := exec.Command("heif-enc", "-o", src, png).CombinedOutput(); err != nil {
+		t.Skipf("heif-enc failed: %v\n%s", err, out)
+	}
+
+	results, err := NewHEIFGenerator().Generate(context.Background(), src, filepath.Join(tmp, "thumb", "photo.heic"), []ThumbnailSpec{
+		{Name: "small", Width: 64, Height: 64},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	r := results[0]
+	if r.Format != FormatJPEG || filepath.Ext(r.Path) != ".jpg" {
+		t.Errorf("got %s at %s, want JPEG", r.Format, r.Path)
+	}
+	if r.SourceWidth != 320 || r.SourceHeight != 240 || r.Width != 64 || r.Height != 48 {
+		t.Errorf("got %dx%d from %dx%d", r.Width, r.Height, r.SourceWidth, r.SourceHeight)
+	}
+	if _, err := imaging.Open(r.Path); err != nil {
+		t.Errorf("open thumbnail: %v", err)
+	}
+}
+
+func TestHEIFGeneratorRejectsUnknownSize(t *testing.T) {
+	// A HEIC brand and nothing else: no ispe property to check MaxPixels against
+	src := filepath.Join(t.TempDir(), "photo.heic")
+	ftyp := []byte{0, 0, 0, 16, 'f', 't', 'y', 'p', 'h', 'e', 'i', 'c', 0, 0, 0, 0}
+	if err := os.WriteFile(src, ftyp, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewHEIFGenerator().Generate(context.Background(), src, filepath.Join(t.TempDir(), "photo.heic"), []ThumbnailSpec{
+		{Name: "small", Width: 64, Height: 64},
+	})
+	if !errors.Is(err, ErrSizeUnknown) {
+		t.Fatalf("got %v, want ErrSizeUnknown", err)
+	}
+}
+
+func TestRawGeneratorGenerate(t *testing.T) {
+	// A DNG-like TIFF whose IFD0 points at a 1500x1000 JPEG preview
+	var preview bytes.Buffer
+	if err := jpeg.Encode(&preview, imaging.New(1500, 1000, color.NRGBA{90, 120, 60, 255}), nil); err != nil {
+		t.Fatal(err)
+	}
+	le := binary.LittleEndian
+	raw := append([]byte("II*\x00"), le.AppendUint32(nil, 8)...)
+	raw = le.AppendUint16(raw, 2)
+	for _, e := range [][2]uint32{{0x0201, 8 + 2 + 2*12 + 4}, {0x0202, uint32(preview.Len())}} {
+		raw = le.AppendUint16(raw, uint16(e[0]))
+		raw = le.AppendUint16(raw, 4)
+		raw = le.AppendUint32(raw, 1)
+		raw = le.AppendUint32(raw, e[1])
+	}
+	raw = append(le.AppendUint32(raw, 0), preview.Bytes()...)
+
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "photo.dng")
+	if err := os.WriteFile(src, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := NewRawGenerator().Generate(context.Background(), src, filepath.Join(tmp, "thumb", "photo.dng"), []ThumbnailSpec{
+		{Name: "small", Width: 300, Height: 300},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	r := results[0]
+	if r.Format != FormatJPEG || filepath.Ext(r.Path) != ".jpg" {
+		t.Errorf("got %s at %s, want JPEG", r.Format, r.Path)
+	}
+	if r.SourceWidth != 1500 || r.SourceHeight != 1000 || r.Width != 300 || r.Height != 200 {
+		t.Errorf("got %dx%d from %dx%d", r.Width, r.Height, r.SourceWidth, r.SourceHeight)
+	}
+
+	gen := NewRawGenerator()
+	gen.Limits.MaxPixels = 1000 * 1000
+	if _, err := gen.Generate(context.Background(), src, filepath.Join(tmp, "big", "photo.dng"), []ThumbnailSpec{{Name: "small", Width: 64, Height: 64}}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("pixel limit: got %v, want ErrLimitExceeded", err)
+	}
+}
+
+func TestTIFFGeneratorGenerate(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "scan.tif")
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tiff.Encode(f, imaging.New(600, 800, color.NRGBA{240, 240, 230, 255}), &tiff.Options{Compression: tiff.Deflate}); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	results, err := NewTIFFGenerator().Generate(context.Background(), src, filepath.Join(tmp, "thumb", "scan.tif"), []ThumbnailSpec{
+		{Name: "small", Width: 120, Height: 120},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	r := results[0]
+	if r.Format != FormatPNG || filepath.Ext(r.Path) != ".png" {
+		t.Errorf("got %s at %s, want PNG", r.Format, r.Path)
+	}
+	if r.SourceWidth != 600 || r.SourceHeight != 800 || r.Width != 90 || r.Height != 120 {
+		t.Errorf("got %dx%d from %dx%d", r.Width, r.Height, r.SourceWidth, r.SourceHeight)
+	}
+}
@@ -0,0 +1,218 @@
+package img
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp" // register the WebP decoder for sources and tests
+)
+
+// OutputFormat is the encoding used for a generated thumbnail.
+type OutputFormat string
+
+const (
+	FormatJPEG OutputFormat = "jpeg"
+	FormatPNG  OutputFormat = "png"
+	FormatGIF  OutputFormat = "gif"
+	FormatWebP OutputFormat = "webp"
+	// FormatMP4 is only used for video preview clips.
+	FormatMP4 OutputFormat = "mp4"
+	// FormatVTT is only used for storyboard cue files.
+	FormatVTT OutputFormat = "vtt"
+)
+
+const (
+	// DefaultJPEGQuality is used when a spec does not set Quality.
+	// It matches the imaging library's default so existing output is unchanged.
+	DefaultJPEGQuality = 95
+	// DefaultWebPQuality is used for lossy WebP when a spec does not set Quality.
+	DefaultWebPQuality = 80
+)
+
+// ParseOutputFormat converts a config or hint value into an OutputFormat.
+func ParseOutputFormat(value string) (OutputFormat, error) {
+	switch strings.ToLower(strings.TrimPrefix(value, ".")) {
+	case "jpg", "jpeg":
+		return FormatJPEG, nil
+	case "png":
+		return FormatPNG, nil
+	case "gif":
+		return FormatGIF, nil
+	case "webp":
+		return FormatWebP, nil
+	default:
+		return "", fmt.Errorf("unsupported output format %q (supported: jpeg, png, gif, webp)", value)
+	}
+}
+
+// Ext returns the file extension, including the dot, used for the format.
+func (f OutputFormat) Ext() string {
+	if f == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + string(f)
+}
+
+// MimeType returns the MIME type of files written in the format.
+func (f OutputFormat) MimeType() string {
+	switch f {
+	case FormatMP4:
+		return "video/mp4"
+	case FormatVTT:
+		return "text/vtt"
+	}
+	return "image/" + string(f)
+}
+
+// formatFromPath infers the output format from a file extension.
+func formatFromPath(path string) (OutputFormat, bool) {
+	f, err := ParseOutputFormat(filepath.Ext(path))
+	return f, err == nil
+}
+
+// outputFormat returns the format a spec should be encoded in, falling back to
+// fallback when the spec does not choose one.
+func (s ThumbnailSpec) outputFormat(fallback OutputFormat) OutputFormat {
+	if s.Format != "" {
+		return s.Format
+	}
+	return fallback
+}
+
+// quality returns the encoder quality for format, or 0 for lossless output.
+func (s ThumbnailSpec) quality(format OutputFormat) int {
+	var fallback int
+	switch {
+	case format == FormatJPEG:
+		fallback = DefaultJPEGQuality
+	case format == FormatWebP && !s.Lossless:
+		fallback = DefaultWebPQuality
+	default:
+		return 0
+	}
+	if s.Quality > 0 {
+		return s.Quality
+	}
+	return fallback
+}
+
+// thumbnailPath builds "<base>_<name><ext>" for a spec, where the extension
+// comes from the spec's format or, failing that, fallback.
+func thumbnailPath(baseDstPath string, spec ThumbnailSpec, fallback OutputFormat) string {
+	ext := filepath.Ext(baseDstPath)
+	base := baseDstPath[:len(baseDstPath)-len(ext)]
+	return fmt.Sprintf("%s_%s%s", base, spec.Name, spec.outputFormat(fallback).Ext())
+}
+
+// saveImage encodes img to dstPath using the format implied by the path's
+// extension and the spec's quality settings. It returns the quality used.
+func saveImage(ctx context.Context, img image.Image, dstPath string, spec ThumbnailSpec) (int, error) {
+	format, ok := formatFromPath(dstPath)
+	if !ok {
+		return 0, fmt.Errorf("unsupported output extension %q", filepath.Ext(dstPath))
+	}
+	quality := spec.quality(format)
+
+	switch format {
+	case FormatJPEG:
+		if err := imaging.Save(img, dstPath, imaging.JPEGQuality(quality)); err != nil {
+			return 0, err
+		}
+		if spec.Progressive {
+			if err := makeProgressiveJPEG(ctx, dstPath); err != nil {
+				return 0, err
+			}
+		}
+	case FormatWebP:
+		if err := encodeWebP(ctx, img, dstPath, quality, spec.Lossless); err != nil {
+			return 0, err
+		}
+	default:
+		if err := imaging.Save(img, dstPath); err != nil {
+			return 0, err
+		}
+	}
+	return quality, nil
+}
+
+// makeProgressiveJPEG rewrites a baseline JPEG as progressive in place.
+// The Go encoder only writes baseline JPEGs, so this uses jpegtran, which is
+// lossless and does not re-encode the image data.
+func makeProgressiveJPEG(ctx context.Context, path string) error {
+	if _, err := exec.LookPath("jpegtran"); err != nil {
+		return fmt.Errorf("jpegtran not found in PATH: %w (install with: brew install jpeg-turbo)", err)
+	}
+
+	tmp := path + ".progressive"
+	cmd := exec.CommandContext(ctx, "jpegtran", "-progressive", "-optimize", "-copy", "none", "-outfile", tmp, path)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("jpegtran failed: %w\nOutput: %s", err, string(output))
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replace with progressive jpeg: %w", err)
+	}
+	return nil
+}
+
+// encodeWebP writes img to dstPath as WebP. Neither the standard library nor
+// the imaging library can encode WebP, so the image is handed to cwebp as a
+// PNG. A quality of 0 is only used together with lossless.
+func encodeWebP(ctx context.Context, img image.Image, dstPath string, quality int, lossless bool) error {
+	if _, err := exec.LookPath("cwebp"); err != nil {
+		return fmt.Errorf("cwebp not found in PATH: %w (install with: brew install webp)", err)
+	}
+
+	tmp := dstPath + ".png"
+	if err := imaging.Save(img, tmp); err != nil {
+		return fmt.Errorf("write webp input: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	args := []string{"-quiet", "-metadata", "none"}
+	if lossless {
+		args = append(args, "-lossless")
+	} else {
+		args = append(args, "-q", strconv.Itoa(quality))
+	}
+	args = append(args, tmp, "-o", dstPath)
+
+	cmd := exec.CommandContext(ctx, "cwebp", args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("cwebp failed: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// imageSize reads the pixel dimensions of an encoded image from its header,
+// without decoding the pixel data.
+func imageSize(path string) (int, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, fmt.Errorf("read dimensions of %s: %w", filepath.Base(path), err)
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// parseQuality validates a 1-100 encoder quality value.
+func parseQuality(value string) (int, error) {
+	q, err := strconv.Atoi(value)
+	if err != nil || q < 1 || q > 100 {
+		return 0, fmt.Errorf("invalid quality %q (expected 1-100)", value)
+	}
+	return q, nil
+}
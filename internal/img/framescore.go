@@ -0,0 +1,155 @@
+package img
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"github.com/tendant/simple-thumbnailer/internal/converters"
+)
+
+const (
+	// DefaultBestFrameCandidates is used by the bare "best_frame" size option.
+	DefaultBestFrameCandidates = 8
+	// MaxBestFrameCandidates caps the candidates scored, each of which costs
+	// an FFmpeg run, whatever a spec asks for.
+	MaxBestFrameCandidates = 10
+)
+
+const (
+	// Candidates are scored on a small copy; sharpness is relative to this size.
+	frameScoreSize = 320
+
+	// Frames darker or brighter than these mean luminances, or flatter than
+	// minFrameContrast, are fades, blank frames or plain title cards.
+	minFrameBrightness = 24
+	maxFrameBrightness = 235
+	minFrameContrast   = 12
+	// maxDarkFraction rejects frames that are mostly black, such as white
+	// text on a black card.
+	maxDarkFraction = 0.7
+	darkLuminance   = 24
+)
+
+// FrameScore rates how well a video frame works as a poster.
+type FrameScore struct {
+	// Brightness is the mean luminance (0-255).
+	Brightness float64
+	// Contrast is the standard deviation of luminance.
+	Contrast float64
+	// Sharpness is the variance of the Laplacian of luminance.
+	Sharpness float64
+	// DarkFraction is the share of pixels that are nearly black.
+	DarkFraction float64
+	// Blank is set for fades, blank frames and plain title cards.
+	Blank bool
+	// Score combines the above; higher is better. Blank frames score below
+	// every other frame.
+	Score float64
+}
+
+// ScoreFrame scores img for use as a poster frame.
+func ScoreFrame(img image.Image) FrameScore {
+	gray := imaging.Grayscale(imaging.Fit(img, frameScoreSize, frameScoreSize, imaging.Box))
+	b := gray.Bounds()
+	w, h := b.Dx(), b.Dy()
+	n := float64(w * h)
+
+	lum := func(x, y int) float64 {
+		return float64(gray.Pix[gray.PixOffset(x, y)])
+	}
+
+	var sum, sumSq, dark float64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := lum(x, y)
+			sum += v
+			sumSq += v * v
+			if v < darkLuminance {
+				dark++
+			}
+		}
+	}
+	s := FrameScore{
+		Brightness:   sum / n,
+		DarkFraction: dark / n,
+	}
+	s.Contrast = math.Sqrt(math.Max(sumSq/n-s.Brightness*s.Brightness, 0))
+
+	// 4-neighbour Laplacian over the interior
+	if w > 2 && h > 2 {
+		var lsum, lsumSq float64
+		for y := 1; y < h-1; y++ {
+			for x := 1; x < w-1; x++ {
+				l := lum(x-1, y) + lum(x+1, y) + lum(x, y-1) + lum(x, y+1) - 4*lum(x, y)
+				lsum += l
+				lsumSq += l * l
+			}
+		}
+		m := float64((w - 2) * (h - 2))
+		s.Sharpness = lsumSq/m - (lsum/m)*(lsum/m)
+	}
+
+	s.Blank = s.Brightness < minFrameBrightness || s.Brightness > maxFrameBrightness ||
+		s.Contrast < minFrameContrast || s.DarkFraction > maxDarkFraction
+
+	sharpness := math.Min(math.Log1p(s.Sharpness)/math.Log1p(2000), 1)
+	contrast := math.Min(s.Contrast/64, 1)
+	exposure := 1 - math.Abs(s.Brightness-128)/128
+	s.Score = 0.5*sharpness + 0.3*contrast + 0.2*exposure
+	if s.Blank {
+		s.Score -= 1
+	}
+	return s
+}
+
+// bestFrameTime extracts n candidate frames, at most MaxBestFrameCandidates,
+// spread over the middle 90% of the video, scores them and returns the
+// timestamp of the best one. Candidates
+// that cannot be extracted are skipped; it fails only when none can.
+func (g *VideoGenerator) bestFrameTime(ctx context.Context, srcPath, baseDstPath string, n int, info *converters.FileInfo) (time.Duration, error) {
+	if info == nil || info.Duration <= 0 {
+		return 0, fmt.Errorf("video duration unknown")
+	}
+
+	n = min(n, MaxBestFrameCandidates)
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(baseDstPath), "candidates-")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var width, height int
+	if info.Width > 0 && info.Height > 0 {
+		width, height = fitSize(info.Width, info.Height, frameScoreSize, frameScoreSize)
+	}
+
+	best, bestScore := -1.0, math.Inf(-1)
+	for i := 0; i < n; i++ {
+		at := info.Duration * (0.05 + 0.9*(float64(i)+0.5)/float64(n))
+		framePath := filepath.Join(tmpDir, fmt.Sprintf("%04d.png", i))
+		if err := g.converter.ExtractFrame(ctx, srcPath, framePath, at, width, height); err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			continue
+		}
+		frame, err := imaging.Open(framePath)
+		if err != nil {
+			continue
+		}
+		if score := ScoreFrame(frame).Score; score > bestScore {
+			best, bestScore = at, score
+		}
+	}
+	if best < 0 {
+		return 0, fmt.Errorf("no candidate frames could be extracted")
+	}
+	return time.Duration(best * float64(time.Second)), nil
+}
@@ -0,0 +1,81 @@
+package img
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestScoreFrameRejectsBlankFrames(t *testing.T) {
+	titleCard := imaging.New(320, 180, color.Black)
+	titleCard = imaging.Paste(titleCard, imaging.New(120, 20, color.White), image.Pt(100, 80))
+
+	tests := map[string]image.Image{
+		"black":      imaging.New(320, 180, color.Black),
+		"white":      imaging.New(320, 180, color.White),
+		"flat grey":  imaging.New(320, 180, color.Gray{Y: 128}),
+		"title card": titleCard,
+	}
+	scene := sceneFrame()
+	sceneScore := ScoreFrame(scene)
+	if sceneScore.Blank {
+		t.Fatalf("scene frame should not be blank: %+v", sceneScore)
+	}
+
+	for name, frame := range tests {
+		s := ScoreFrame(frame)
+		if !s.Blank {
+			t.Errorf("%s: expected blank, got %+v", name, s)
+		}
+		if s.Score >= sceneScore.Score {
+			t.Errorf("%s: score %.3f should be below scene score %.3f", name, s.Score, sceneScore.Score)
+		}
+	}
+}
+
+func TestScoreFramePrefersSharpFrames(t *testing.T) {
+	sharp := sceneFrame()
+	blurred := imaging.Blur(sharp, 4)
+
+	s, b := ScoreFrame(sharp), ScoreFrame(blurred)
+	if s.Sharpness <= b.Sharpness || s.Score <= b.Score {
+		t.Errorf("sharp frame should win: sharp %+v, blurred %+v", s, b)
+	}
+}
+
+func TestApplySpecOptionBestFrame(t *testing.T) {
+	spec := ThumbnailSpec{Name: "poster", Width: 320, Height: 180}
+	if err := ApplySpecOption(&spec, "best_frame"); err != nil || spec.BestFrame != DefaultBestFrameCandidates {
+		t.Fatalf("best_frame: got %d candidates, err %v", spec.BestFrame, err)
+	}
+	if err := ApplyHints(&spec, map[string]string{HintBestFrame: "6"}); err != nil || spec.BestFrame != 6 {
+		t.Fatalf("best_frame hint: got %d candidates, err %v", spec.BestFrame, err)
+	}
+	if err := ApplySpecOption(&spec, "best_frame=1000"); err != nil || spec.BestFrame != MaxBestFrameCandidates {
+		t.Errorf("best_frame=1000: got %d candidates, err %v", spec.BestFrame, err)
+	}
+	if err := ApplySpecOption(&spec, "best_frame=false"); err != nil || spec.BestFrame != 0 {
+		t.Errorf("best_frame=false: got %d candidates, err %v", spec.BestFrame, err)
+	}
+	if err := ApplySpecOption(&spec, "best_frame=some"); err == nil {
+		t.Error("expected error for best_frame=some")
+	}
+}
+
+// sceneFrame draws a mid-toned, detailed frame: a colour gradient with a
+// checkerboard of varying contrast.
+func sceneFrame() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 320, 180))
+	for y := 0; y < 180; y++ {
+		for x := 0; x < 320; x++ {
+			v := uint8(60 + x*120/320)
+			if (x/8+y/8)%2 == 0 {
+				v += uint8(40 + y/6)
+			}
+			img.Set(x, y, color.NRGBA{R: v, G: uint8(80 + y/2), B: 255 - v, A: 255})
+		}
+	}
+	return img
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"strings"
+
+	"github.com/tendant/simple-thumbnailer/internal/converters"
 )
 
 // Generator defines the interface for thumbnail generation from various file types.
@@ -22,28 +24,115 @@ type Generator interface {
 // GetGenerator returns the appropriate thumbnail generator for the given MIME type.
 // It routes to the correct implementation based on content type:
 //   - Images: Native Go imaging library (existing)
+//   - SVG: librsvg, rendered at each size
+//   - HEIC, HEIF and AVIF: libheif, then the imaging library
+//   - Camera RAW: embedded preview, or LibRaw
+//   - TIFF and Photoshop: largest page or composite image, decoded in Go
 //   - Videos: FFmpeg converter
+//   - Audio: Cover art or a waveform, via FFmpeg
 //   - PDFs: Poppler converter
+//   - Text, Markdown and source code: typeset in Go
 //   - Unsupported: Returns error
+//
+// The generator enforces DefaultLimits.
 func GetGenerator(mimeType string) (Generator, error) {
-	mimeType = strings.ToLower(mimeType)
+	return GetGeneratorWithLimits(mimeType, DefaultLimits)
+}
+
+// GetGeneratorWithLimits is GetGenerator with explicit source limits.
+func GetGeneratorWithLimits(mimeType string, limits Limits) (Generator, error) {
+	mimeType = baseMimeType(mimeType)
 
 	switch {
+	case mimeType == "image/svg+xml":
+		// Rasterize SVGs at each size with librsvg; the imaging library
+		// cannot decode them
+		gen := NewSVGGenerator()
+		gen.Limits = limits
+		return gen, nil
+
+	case converters.NewHEIFConverter().Supports(mimeType):
+		// Decode HEIC and AVIF photos with libheif, then resize in Go
+		gen := NewHEIFGenerator()
+		gen.Limits = limits
+		return gen, nil
+
+	case converters.NewRawConverter().Supports(mimeType):
+		// Resize the JPEG preview embedded in camera RAW files
+		gen := NewRawGenerator()
+		gen.Limits = limits
+		return gen, nil
+
+	case converters.NewTIFFConverter().Supports(mimeType):
+		// Decode the largest page of multi-page TIFFs in Go
+		gen := NewTIFFGenerator()
+		gen.Limits = limits
+		return gen, nil
+
+	case converters.NewPSDConverter().Supports(mimeType):
+		// Read the composite image stored in Photoshop documents
+		gen := NewPSDGenerator()
+		gen.Limits = limits
+		return gen, nil
+
 	case strings.HasPrefix(mimeType, "image/"):
 		// Use existing image generator (backward compatible)
-		return &ImageGenerator{}, nil
+		return &ImageGenerator{Limits: limits}, nil
 
 	case strings.HasPrefix(mimeType, "video/"):
 		// Use FFmpeg for video thumbnails
-		return NewVideoGenerator(), nil
+		gen := NewVideoGenerator()
+		gen.Limits = limits
+		return gen, nil
+
+	case strings.HasPrefix(mimeType, "audio/"):
+		// Use embedded cover art, or a waveform, for audio thumbnails
+		gen := NewAudioGenerator()
+		gen.Limits = limits
+		return gen, nil
 
 	case mimeType == "application/pdf":
 		// Use Poppler for PDF thumbnails
-		return NewPDFGenerator(), nil
+		gen := NewPDFGenerator()
+		gen.Limits = limits
+		return gen, nil
+
+	case converters.NewLibreOfficeConverter().Supports(mimeType):
+		// Convert office documents to PDF with LibreOffice, then use Poppler
+		gen := NewOfficeGenerator(mimeType)
+		gen.Limits = limits
+		return gen, nil
+
+	case isTextMimeType(mimeType):
+		// Typeset the start of text files onto a page
+		gen := NewTextGenerator(mimeType)
+		gen.Limits = limits
+		return gen, nil
 
 	default:
-		return nil, fmt.Errorf("unsupported MIME type: %s (supported: image/*, video/*, application/pdf)", mimeType)
+		return nil, fmt.Errorf("unsupported MIME type: %s (supported: image/*, Photoshop, video/*, audio/*, application/pdf, office documents, text)", mimeType)
+	}
+}
+
+// baseMimeType lowercases mimeType and drops parameters such as the charset
+// text types often carry.
+func baseMimeType(mimeType string) string {
+	mimeType, _, _ = strings.Cut(strings.ToLower(mimeType), ";")
+	return strings.TrimSpace(mimeType)
+}
+
+// MimeTypeForFile returns the MIME type to pass to GetGenerator for a file:
+// mimeType, unless it is generic and filename has a camera RAW extension.
+// RAW files are usually uploaded as application/octet-stream, and sniff as
+// image/tiff because they are built on TIFF.
+func MimeTypeForFile(mimeType, filename string) string {
+	switch baseMimeType(mimeType) {
+	case "", "application/octet-stream", "image/tiff":
+		if raw := converters.RawMimeType(filename); raw != "" {
+			return raw
+		}
 	}
+	return mimeType
 }
 
 // SupportedMimeTypes returns a list of all MIME types that can be processed
@@ -55,7 +144,20 @@ func SupportedMimeTypes() []string {
 		"image/gif",
 		"image/webp",
 		"image/bmp",
+		// Multi-page TIFF and Photoshop (decoded in Go)
 		"image/tiff",
+		"image/vnd.adobe.photoshop",
+		// SVG (via librsvg)
+		"image/svg+xml",
+		// HEIC, HEIF and AVIF (via libheif)
+		"image/heic",
+		"image/heif",
+		"image/avif",
+		// Camera RAW (embedded preview, or LibRaw)
+		"image/x-canon-cr2",
+		"image/x-nikon-nef",
+		"image/x-sony-arw",
+		"image/x-adobe-dng",
 		// Videos (via FFmpeg)
 		"video/mp4",
 		"video/mpeg",
@@ -64,19 +166,57 @@ func SupportedMimeTypes() []string {
 		"video/webm",
 		"video/x-matroska",
 		"video/x-flv",
+		// Audio (via FFmpeg)
+		"audio/mpeg",
+		"audio/mp4",
+		"audio/aac",
+		"audio/flac",
+		"audio/ogg",
+		"audio/wav",
+		"audio/x-wav",
+		"audio/webm",
 		// PDFs (via Poppler)
 		"application/pdf",
+		// Office documents (via LibreOffice and Poppler)
+		"application/msword",
+		"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		"application/vnd.oasis.opendocument.text",
+		"application/rtf",
+		"text/rtf",
+		"application/vnd.ms-excel",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		"application/vnd.oasis.opendocument.spreadsheet",
+		"application/vnd.ms-powerpoint",
+		"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		"application/vnd.oasis.opendocument.presentation",
+		// Text, Markdown and source code (typeset in Go)
+		"text/plain",
+		"text/markdown",
+		"text/csv",
+		"text/html",
+		"application/json",
+		"application/xml",
+		"application/x-yaml",
+		"application/javascript",
+		"text/x-go",
+		"text/x-python",
+		"text/x-c",
+		"text/x-java-source",
+		"text/x-rust",
+		"text/x-shellscript",
 	}
 }
 
 // ImageGenerator implements Generator for standard image formats using the existing imaging library.
 // This preserves backward compatibility with the current implementation.
-type ImageGenerator struct{}
+type ImageGenerator struct {
+	// Limits bounds the images that will be decoded. The zero value is unlimited.
+	Limits Limits
+}
 
 // Generate implements Generator.Generate for images
 func (g *ImageGenerator) Generate(ctx context.Context, srcPath string, baseDstPath string, specs []ThumbnailSpec) ([]ThumbnailOutput, error) {
-	// Use existing GenerateThumbnails function (unchanged)
-	return GenerateThumbnails(srcPath, baseDstPath, specs)
+	return generateThumbnails(ctx, srcPath, baseDstPath, specs, g.Limits)
 }
 
 // Supports implements Generator.Supports for images
@@ -17,9 +17,23 @@ func TestGetGenerator(t *testing.T) {
 	}{
 		{"image jpeg", "image/jpeg", "image", false},
 		{"image png", "image/png", "image", false},
+		{"svg", "image/svg+xml", "svg", false},
+		{"heic", "image/heic", "heif", false},
+		{"avif", "image/avif", "heif", false},
+		{"cr2", "image/x-canon-cr2", "raw", false},
+		{"dng", "image/x-adobe-dng", "raw", false},
+		{"tiff", "image/tiff", "tiff", false},
+		{"psd", "image/vnd.adobe.photoshop", "psd", false},
 		{"video mp4", "video/mp4", "video", false},
 		{"video quicktime", "video/quicktime", "video", false},
 		{"pdf", "application/pdf", "pdf", false},
+		{"docx", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "office", false},
+		{"odp", "application/vnd.oasis.opendocument.presentation", "office", false},
+		{"mp3", "audio/mpeg", "audio", false},
+		{"flac", "audio/flac", "audio", false},
+		{"text", "text/plain; charset=utf-8", "text", false},
+		{"json", "application/json", "text", false},
+		{"rtf", "text/rtf", "office", false},
 		{"unsupported", "application/zip", "", true},
 	}
 
@@ -137,7 +151,7 @@ func TestSupportedMimeTypes(t *testing.T) {
 	}
 
 	// Verify common types are present
-	requiredTypes := []string{"image/jpeg", "video/mp4", "application/pdf"}
+	requiredTypes := []string{"image/jpeg", "video/mp4", "application/pdf", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"}
 	for _, required := range requiredTypes {
 		found := false
 		for _, supported := range types {
@@ -221,7 +235,33 @@ func TestGeneratorWithRealFiles(t *testing.T) {
 				} else if info.Size() == 0 {
 					t.Errorf("thumbnail is empty")
 				}
+
+				// Reported dimensions must match the file, not the requested box
+				width, height, err := imageSize(result.Path)
+				if err != nil {
+					t.Errorf("read thumbnail dimensions: %v", err)
+				} else if result.Width != width || result.Height != height {
+					t.Errorf("reported %dx%d, file is %dx%d", result.Width, result.Height, width, height)
+				}
 			}
 		})
 	}
 }
+
+func TestMimeTypeForFile(t *testing.T) {
+	tests := []struct {
+		mimeType, filename, want string
+	}{
+		{"application/octet-stream", "DSC_0001.NEF", "image/x-nikon-nef"},
+		{"image/tiff", "IMG_0001.cr2", "image/x-canon-cr2"},
+		{"", "photo.dng", "image/x-adobe-dng"},
+		{"image/tiff", "scan.tif", "image/tiff"},
+		{"image/jpeg", "photo.dng", "image/jpeg"},
+		{"image/x-sony-arw", "upload", "image/x-sony-arw"},
+	}
+	for _, tt := range tests {
+		if got := MimeTypeForFile(tt.mimeType, tt.filename); got != tt.want {
+			t.Errorf("MimeTypeForFile(%q, %q) = %q, want %q", tt.mimeType, tt.filename, got, tt.want)
+		}
+	}
+}
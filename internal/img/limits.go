@@ -0,0 +1,122 @@
+package img
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+
+	"github.com/disintegration/imaging"
+)
+
+// Limits bounds the sources a generator will process, so a small upload that
+// declares huge dimensions cannot exhaust worker memory. Zero fields are
+// unlimited.
+type Limits struct {
+	// MaxPixels caps width*height of decoded images, video frames and
+	// rendered PDF pages.
+	MaxPixels int64
+	// MaxFileSize caps the source file size in bytes.
+	MaxFileSize int64
+	// MaxDuration caps video and audio length in seconds.
+	MaxDuration float64
+	// MaxPages caps the number of pages of a PDF.
+	MaxPages int
+}
+
+// DefaultLimits are used by GetGenerator.
+var DefaultLimits = Limits{
+	MaxPixels:   100_000_000, // 100 MP, ~400 MB as NRGBA
+	MaxFileSize: 1 << 30,
+	MaxDuration: 6 * 60 * 60,
+	MaxPages:    5000,
+}
+
+// ErrLimitExceeded matches every LimitError with errors.Is. Sources that
+// exceed a limit will never succeed, so callers should not retry them.
+var ErrLimitExceeded = errors.New("source exceeds processing limits")
+
+// LimitError reports which limit a source exceeded.
+type LimitError struct {
+	Limit string // "pixels", "file size", "duration" or "pages"
+	Value int64
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: %s %d exceeds limit %d", ErrLimitExceeded, e.Limit, e.Value, e.Max)
+}
+
+// Is lets errors.Is(err, ErrLimitExceeded) match.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+func (l Limits) checkFileSize(path string) error {
+	if l.MaxFileSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() > l.MaxFileSize {
+		return &LimitError{Limit: "file size", Value: info.Size(), Max: l.MaxFileSize}
+	}
+	return nil
+}
+
+func (l Limits) checkPixels(width, height int) error {
+	pixels := int64(width) * int64(height)
+	if l.MaxPixels > 0 && pixels > l.MaxPixels {
+		return &LimitError{Limit: "pixels", Value: pixels, Max: l.MaxPixels}
+	}
+	return nil
+}
+
+// ErrSizeUnknown is returned for sources whose pixel size cannot be read
+// before decoding them, so MaxPixels cannot be enforced. They are not retried.
+var ErrSizeUnknown = errors.New("source pixel size unknown")
+
+// checkProbedPixels is checkPixels for a size read from the source ahead of
+// decoding, which must be known for MaxPixels to protect the decoder.
+func (l Limits) checkProbedPixels(width, height int) error {
+	if l.MaxPixels > 0 && (width <= 0 || height <= 0) {
+		return ErrSizeUnknown
+	}
+	return l.checkPixels(width, height)
+}
+
+func (l Limits) checkDuration(seconds float64) error {
+	if l.MaxDuration > 0 && seconds > l.MaxDuration {
+		return &LimitError{Limit: "duration", Value: int64(math.Ceil(seconds)), Max: int64(l.MaxDuration)}
+	}
+	return nil
+}
+
+func (l Limits) checkPages(pages int) error {
+	if l.MaxPages > 0 && pages > l.MaxPages {
+		return &LimitError{Limit: "pages", Value: int64(pages), Max: int64(l.MaxPages)}
+	}
+	return nil
+}
+
+// openImage decodes the image at path after checking its file size and the
+// dimensions declared in its header, so oversized images are rejected before
+// any pixel memory is allocated.
+func openImage(path string, limits Limits, opts ...imaging.DecodeOption) (image.Image, error) {
+	if err := limits.checkFileSize(path); err != nil {
+		return nil, err
+	}
+	if limits.MaxPixels > 0 {
+		width, height, err := imageSize(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := limits.checkPixels(width, height); err != nil {
+			return nil, err
+		}
+	}
+	return imaging.Open(path, opts...)
+}
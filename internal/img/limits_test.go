@@ -0,0 +1,104 @@
+package img
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateThumbnailsRejectsDecompressionBomb(t *testing.T) {
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "bomb.png")
+	// A few dozen bytes that declare a 50000x50000 image (2.5 gigapixels)
+	if err := os.WriteFile(srcPath, pngHeader(50000, 50000), 0o644); err != nil {
+		t.Fatalf("write png: %v", err)
+	}
+
+	_, err := GenerateThumbnails(srcPath, filepath.Join(tmp, "thumb.png"), []ThumbnailSpec{
+		{Name: "small", Width: 100, Height: 100},
+	})
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("expected ErrLimitExceeded, got %v", err)
+	}
+
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "pixels" || limitErr.Value != 2_500_000_000 {
+		t.Fatalf("expected pixel LimitError, got %#v", err)
+	}
+	if !strings.Contains(err.Error(), "exceeds limit") {
+		t.Errorf("error should explain the limit, got %q", err)
+	}
+}
+
+func TestImageGeneratorLimits(t *testing.T) {
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "source.png")
+	createTestImage(t, srcPath, 400, 200)
+	specs := []ThumbnailSpec{{Name: "small", Width: 100, Height: 100}}
+
+	tests := []struct {
+		name   string
+		limits Limits
+		limit  string
+	}{
+		{"unlimited", Limits{}, ""},
+		{"within limits", Limits{MaxPixels: 80_000, MaxFileSize: 1 << 20}, ""},
+		{"too many pixels", Limits{MaxPixels: 79_999}, "pixels"},
+		{"file too large", Limits{MaxFileSize: 10}, "file size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen := &ImageGenerator{Limits: tt.limits}
+			_, err := gen.Generate(context.Background(), srcPath, filepath.Join(tmp, tt.name+".png"), specs)
+
+			var limitErr *LimitError
+			switch {
+			case tt.limit == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.limit != "" && !errors.As(err, &limitErr):
+				t.Fatalf("expected LimitError, got %v", err)
+			case tt.limit != "" && limitErr.Limit != tt.limit:
+				t.Fatalf("expected %s limit, got %s", tt.limit, limitErr.Limit)
+			}
+		})
+	}
+}
+
+func TestLimitsDurationAndPages(t *testing.T) {
+	l := Limits{MaxDuration: 60, MaxPages: 10}
+	if err := l.checkDuration(60); err != nil {
+		t.Errorf("60s should be within limit: %v", err)
+	}
+	if err := l.checkDuration(60.5); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("60.5s should exceed limit, got %v", err)
+	}
+	if err := l.checkPages(11); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("11 pages should exceed limit, got %v", err)
+	}
+	if err := (Limits{}).checkPages(1 << 20); err != nil {
+		t.Errorf("zero limits should be unlimited: %v", err)
+	}
+}
+
+// pngHeader returns a PNG signature and IHDR chunk declaring a w x h RGBA image.
+func pngHeader(w, h uint32) []byte {
+	var ihdr bytes.Buffer
+	ihdr.WriteString("IHDR")
+	binary.Write(&ihdr, binary.BigEndian, w)
+	binary.Write(&ihdr, binary.BigEndian, h)
+	ihdr.Write([]byte{8, 6, 0, 0, 0}) // 8-bit RGBA, default compression/filter/interlace
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&buf, binary.BigEndian, uint32(ihdr.Len()-4))
+	buf.Write(ihdr.Bytes())
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(ihdr.Bytes()))
+	return buf.Bytes()
+}
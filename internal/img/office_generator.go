@@ -0,0 +1,76 @@
+package img
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tendant/simple-thumbnailer/internal/converters"
+)
+
+// OfficeGenerator implements Generator for office documents (DOCX, XLSX,
+// PPTX, ODT and friends). It converts the pages the specs need to PDF with
+// headless LibreOffice and thumbnails that PDF like PDFGenerator.
+type OfficeGenerator struct {
+	converter *converters.LibreOfficeConverter
+	mimeType  string
+
+	// Limits bounds the sources that will be processed. The zero value is unlimited.
+	Limits Limits
+}
+
+// NewOfficeGenerator creates a new office document thumbnail generator for
+// documents of the given MIME type, which selects LibreOffice's export filter.
+func NewOfficeGenerator(mimeType string) *OfficeGenerator {
+	return &OfficeGenerator{
+		converter: converters.NewLibreOfficeConverter(),
+		mimeType:  mimeType,
+		Limits:    DefaultLimits,
+	}
+}
+
+// Generate implements Generator.Generate for office documents
+func (g *OfficeGenerator) Generate(ctx context.Context, srcPath string, baseDstPath string, specs []ThumbnailSpec) ([]ThumbnailOutput, error) {
+	if err := g.Limits.checkFileSize(srcPath); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(baseDstPath), 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir: %w", err)
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(baseDstPath), "office-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	pdfPath := filepath.Join(tmpDir, "document.pdf")
+	if err := g.converter.ExportPDF(ctx, srcPath, pdfPath, g.mimeType, lastPage(specs)); err != nil {
+		return nil, fmt.Errorf("convert to pdf: %w", err)
+	}
+
+	pdf := NewPDFGenerator()
+	pdf.Limits = g.Limits
+	return pdf.Generate(ctx, pdfPath, baseDstPath, specs)
+}
+
+// lastPage returns the highest page any spec shows, so only that many pages
+// need converting.
+func lastPage(specs []ThumbnailSpec) int {
+	last := 1
+	for _, spec := range specs {
+		last = max(last, spec.page(), spec.Pages, spec.ContactSheet)
+	}
+	return last
+}
+
+// Supports implements Generator.Supports for office documents
+func (g *OfficeGenerator) Supports(mimeType string) bool {
+	return g.converter.Supports(mimeType)
+}
+
+// Name implements Generator.Name
+func (g *OfficeGenerator) Name() string {
+	return "office"
+}
@@ -0,0 +1,20 @@
+package img
+
+import "testing"
+
+func TestLastPage(t *testing.T) {
+	tests := []struct {
+		specs []ThumbnailSpec
+		want  int
+	}{
+		{[]ThumbnailSpec{{Name: "small"}}, 1},
+		{[]ThumbnailSpec{{Name: "small"}, {Name: "cover", Page: 3}}, 3},
+		{[]ThumbnailSpec{{Name: "small", Pages: 4}}, 4},
+		{[]ThumbnailSpec{{Name: "small", ContactSheet: 9}, {Name: "cover", Page: 2}}, 9},
+	}
+	for _, tt := range tests {
+		if got := lastPage(tt.specs); got != tt.want {
+			t.Errorf("lastPage(%+v) = %d, want %d", tt.specs, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package img
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Hint keys understood by ApplyHints. Hints apply to every spec in a job and
+// override whatever the size preset configured.
+const (
+	HintResizeMode          = "resize_mode"
+	HintResizeAnchor        = "resize_anchor"
+	HintResizeBackground    = "resize_background"
+	HintOutputFormat        = "output_format"
+	HintOutputQuality       = "output_quality"
+	HintProgressive         = "output_progressive"
+	HintLossless            = "output_lossless"
+	HintAnimated            = "animated"
+	HintMaxFrames           = "animation_max_frames"
+	HintMaxDuration         = "animation_max_duration"
+	HintPreview             = "preview"
+	HintPreviewDuration     = "preview_duration"
+	HintPreviewSegments     = "preview_segments"
+	HintStoryboard          = "storyboard"
+	HintStoryboardColumns   = "storyboard_columns"
+	HintSeek                = "seek"
+	HintBestFrame           = "best_frame"
+	HintPage                = "page"
+	HintPages               = "pages"
+	HintContactSheet        = "contact_sheet"
+	HintContactSheetColumns = "contact_sheet_columns"
+)
+
+// ApplySpecOption applies a single size preset option to spec. Options are the
+// colon-separated tokens that follow "name:WxH" in THUMBNAIL_SIZES, e.g.
+// "avatar:128x128:fill:anchor=top" or "card:400x300:pad:bg=#000000".
+//
+// Supported options:
+//   - fit, fill, stretch, pad: resize mode
+//   - anchor=<center|top|bottom|left|right|top-left|...|smart>: crop anchor for fill
+//   - bg=<#rrggbb>: letterbox colour for pad
+//   - jpeg, png, gif, webp (or format=<f>): output encoding
+//   - q=<1-100> (or quality=<1-100>): lossy encoder quality
+//   - progressive: write progressive JPEG
+//   - lossless: write lossless WebP
+//   - animated: also write an animated thumbnail of multi-frame GIFs and WebPs
+//   - frames=<n>: cap the animated thumbnail to n frames
+//   - duration=<d>: cap the animated thumbnail's length (e.g. 3s or 2.5)
+//   - preview (or preview=<mp4|webp|gif>): also write a video preview clip
+//   - preview_duration=<d>: preview clip length
+//   - preview_segments=<n>: number of excerpts sampled into the preview clip
+//   - storyboard (or storyboard=<n>): also write a video storyboard sprite of up to 400 frames and WebVTT
+//   - storyboard_columns=<n>: storyboard sprite width in tiles
+//   - seek=<seconds|duration|percent%>: video frame position (e.g. 12, 1m30s or 25%)
+//   - best_frame (or best_frame=<n>): score n candidate video frames, up to 10, and keep the best
+//   - page=<n>: PDF page to thumbnail (1-based)
+//   - pages=<n>: also write the first n PDF pages as separate thumbnails
+//   - contact_sheet (or contact_sheet=<n>): also write a grid of the first n PDF pages
+//   - contact_sheet_columns=<n>: contact sheet width in pages
+func ApplySpecOption(spec *ThumbnailSpec, option string) error {
+	option = strings.ToLower(strings.TrimSpace(option))
+	if option == "" {
+		return nil
+	}
+
+	key, value, hasValue := strings.Cut(option, "=")
+	if !hasValue {
+		switch key {
+		case "progressive":
+			spec.Progressive = true
+			return nil
+		case "lossless":
+			spec.Lossless = true
+			return nil
+		case "animated":
+			spec.Animated = true
+			return nil
+		case "preview":
+			spec.Preview = true
+			return nil
+		case "storyboard":
+			spec.Storyboard = DefaultStoryboardFrames
+			return nil
+		case "best_frame":
+			spec.BestFrame = DefaultBestFrameCandidates
+			return nil
+		case "contact_sheet":
+			spec.ContactSheet = DefaultContactSheetPages
+			return nil
+		}
+		if mode, err := ParseResizeMode(key); err == nil {
+			spec.Mode = mode
+			return nil
+		}
+		if format, err := ParseOutputFormat(key); err == nil {
+			spec.Format = format
+			return nil
+		}
+		return fmt.Errorf("unknown size option %q", option)
+	}
+
+	switch key {
+	case "mode":
+		mode, err := ParseResizeMode(value)
+		if err != nil {
+			return err
+		}
+		spec.Mode = mode
+	case "anchor":
+		anchor, err := ParseAnchor(value)
+		if err != nil {
+			return err
+		}
+		spec.Anchor = anchor
+	case "bg", "background":
+		bg, err := ParseHexColor(value)
+		if err != nil {
+			return err
+		}
+		spec.Background = bg
+	case "format":
+		format, err := ParseOutputFormat(value)
+		if err != nil {
+			return err
+		}
+		spec.Format = format
+	case "q", "quality":
+		quality, err := parseQuality(value)
+		if err != nil {
+			return err
+		}
+		spec.Quality = quality
+	case "progressive":
+		progressive, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid progressive value %q", value)
+		}
+		spec.Progressive = progressive
+	case "lossless":
+		lossless, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid lossless value %q", value)
+		}
+		spec.Lossless = lossless
+	case "animated":
+		animated, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid animated value %q", value)
+		}
+		spec.Animated = animated
+	case "frames":
+		frames, err := strconv.Atoi(value)
+		if err != nil || frames < 1 {
+			return fmt.Errorf("invalid frames value %q (expected a positive integer)", value)
+		}
+		spec.MaxFrames = frames
+	case "duration":
+		duration, err := parseDuration(value)
+		if err != nil {
+			return err
+		}
+		spec.MaxDuration = duration
+	case "preview":
+		// Either a format, which turns previews on, or a boolean
+		if format, err := ParsePreviewFormat(value); err == nil {
+			spec.Preview = true
+			spec.PreviewFormat = format
+			return nil
+		}
+		preview, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid preview value %q (expected mp4, webp, gif or a boolean)", value)
+		}
+		spec.Preview = preview
+	case "preview_duration":
+		duration, err := parseDuration(value)
+		if err != nil {
+			return err
+		}
+		spec.PreviewDuration = duration
+	case "preview_segments":
+		segments, err := strconv.Atoi(value)
+		if err != nil || segments < 1 {
+			return fmt.Errorf("invalid preview_segments value %q (expected a positive integer)", value)
+		}
+		spec.PreviewSegments = segments
+	case "storyboard":
+		// Either a frame count, capped at MaxStoryboardFrames, or a boolean
+		if frames, err := strconv.Atoi(value); err == nil && frames > 0 {
+			spec.Storyboard = min(frames, MaxStoryboardFrames)
+			return nil
+		}
+		storyboard, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid storyboard value %q (expected a frame count or a boolean)", value)
+		}
+		spec.Storyboard = 0
+		if storyboard {
+			spec.Storyboard = DefaultStoryboardFrames
+		}
+	case "storyboard_columns":
+		columns, err := strconv.Atoi(value)
+		if err != nil || columns < 1 {
+			return fmt.Errorf("invalid storyboard_columns value %q (expected a positive integer)", value)
+		}
+		spec.StoryboardColumns = columns
+	case "seek":
+		seek, err := ParseSeekPosition(value)
+		if err != nil {
+			return err
+		}
+		spec.Seek = seek
+	case "best_frame":
+		// Either a candidate count, capped at MaxBestFrameCandidates, or a boolean
+		if candidates, err := strconv.Atoi(value); err == nil && candidates > 0 {
+			spec.BestFrame = min(candidates, MaxBestFrameCandidates)
+			return nil
+		}
+		bestFrame, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid best_frame value %q (expected a candidate count or a boolean)", value)
+		}
+		spec.BestFrame = 0
+		if bestFrame {
+			spec.BestFrame = DefaultBestFrameCandidates
+		}
+	case "page":
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return fmt.Errorf("invalid page value %q (expected a positive integer)", value)
+		}
+		spec.Page = page
+	case "pages":
+		pages, err := strconv.Atoi(value)
+		if err != nil || pages < 1 {
+			return fmt.Errorf("invalid pages value %q (expected a positive integer)", value)
+		}
+		spec.Pages = pages
+	case "contact_sheet":
+		// Either a page count or a boolean
+		if pages, err := strconv.Atoi(value); err == nil && pages > 0 {
+			spec.ContactSheet = pages
+			return nil
+		}
+		contactSheet, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid contact_sheet value %q (expected a page count or a boolean)", value)
+		}
+		spec.ContactSheet = 0
+		if contactSheet {
+			spec.ContactSheet = DefaultContactSheetPages
+		}
+	case "contact_sheet_columns":
+		columns, err := strconv.Atoi(value)
+		if err != nil || columns < 1 {
+			return fmt.Errorf("invalid contact_sheet_columns value %q (expected a positive integer)", value)
+		}
+		spec.ContactSheetColumns = columns
+	default:
+		return fmt.Errorf("unknown size option %q", option)
+	}
+	return nil
+}
+
+// hintOptions maps each hint key to the size option it sets.
+var hintOptions = []struct {
+	hint   string
+	option string
+}{
+	{HintResizeMode, "mode"},
+	{HintResizeAnchor, "anchor"},
+	{HintResizeBackground, "bg"},
+	{HintOutputFormat, "format"},
+	{HintOutputQuality, "q"},
+	{HintProgressive, "progressive"},
+	{HintLossless, "lossless"},
+	{HintAnimated, "animated"},
+	{HintMaxFrames, "frames"},
+	{HintMaxDuration, "duration"},
+	{HintPreview, "preview"},
+	{HintPreviewDuration, "preview_duration"},
+	{HintPreviewSegments, "preview_segments"},
+	{HintStoryboard, "storyboard"},
+	{HintStoryboardColumns, "storyboard_columns"},
+	{HintSeek, "seek"},
+	{HintBestFrame, "best_frame"},
+	{HintPage, "page"},
+	{HintPages, "pages"},
+	{HintContactSheet, "contact_sheet"},
+	{HintContactSheetColumns, "contact_sheet_columns"},
+}
+
+// ApplyHints applies job-level hints to spec.
+func ApplyHints(spec *ThumbnailSpec, hints map[string]string) error {
+	if hints == nil {
+		return nil
+	}
+
+	for _, h := range hintOptions {
+		v := strings.TrimSpace(hints[h.hint])
+		if v == "" {
+			continue
+		}
+		if err := ApplySpecOption(spec, h.option+"="+v); err != nil {
+			return fmt.Errorf("hint %s: %w", h.hint, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package img
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestApplySpecOption(t *testing.T) {
+	spec := ThumbnailSpec{Name: "card", Width: 400, Height: 300}
+
+	for _, opt := range []string{"pad", "bg=#102030", "anchor=top"} {
+		if err := ApplySpecOption(&spec, opt); err != nil {
+			t.Fatalf("ApplySpecOption(%q) returned error: %v", opt, err)
+		}
+	}
+
+	if spec.Mode != ResizePad {
+		t.Errorf("Mode = %q, want %q", spec.Mode, ResizePad)
+	}
+	if spec.Anchor != AnchorTop {
+		t.Errorf("Anchor = %q, want %q", spec.Anchor, AnchorTop)
+	}
+	if spec.Background != (color.NRGBA{R: 0x10, G: 0x20, B: 0x30, A: 0xff}) {
+		t.Errorf("Background = %v, want #102030", spec.Background)
+	}
+
+	for _, opt := range []string{"crop", "anchor=middle", "bg=blue", "size=4"} {
+		if err := ApplySpecOption(&spec, opt); err == nil {
+			t.Errorf("ApplySpecOption(%q) expected error", opt)
+		}
+	}
+}
+
+func TestApplySpecOptionEncoding(t *testing.T) {
+	spec := ThumbnailSpec{Name: "grid", Width: 300, Height: 300}
+
+	for _, opt := range []string{"jpeg", "q=82", "progressive"} {
+		if err := ApplySpecOption(&spec, opt); err != nil {
+			t.Fatalf("ApplySpecOption(%q) returned error: %v", opt, err)
+		}
+	}
+	if spec.Format != FormatJPEG || spec.Quality != 82 || !spec.Progressive {
+		t.Errorf("got format=%q quality=%d progressive=%v, want jpeg/82/true", spec.Format, spec.Quality, spec.Progressive)
+	}
+
+	for _, opt := range []string{"q=0", "q=101", "format=bmp"} {
+		if err := ApplySpecOption(&spec, opt); err == nil {
+			t.Errorf("ApplySpecOption(%q) expected error", opt)
+		}
+	}
+}
+
+func TestApplySpecOptionWebP(t *testing.T) {
+	spec := ThumbnailSpec{Name: "grid", Width: 300, Height: 300}
+	if err := ApplySpecOption(&spec, "webp"); err != nil {
+		t.Fatalf("ApplySpecOption(webp) returned error: %v", err)
+	}
+	if spec.Format != FormatWebP || spec.quality(FormatWebP) != DefaultWebPQuality {
+		t.Errorf("got format=%q quality=%d, want webp/%d", spec.Format, spec.quality(FormatWebP), DefaultWebPQuality)
+	}
+
+	if err := ApplyHints(&spec, map[string]string{HintLossless: "true"}); err != nil {
+		t.Fatalf("ApplyHints returned error: %v", err)
+	}
+	if !spec.Lossless || spec.quality(FormatWebP) != 0 {
+		t.Errorf("got lossless=%v quality=%d, want true/0", spec.Lossless, spec.quality(FormatWebP))
+	}
+}
+
+func TestApplyHintsOverridesPreset(t *testing.T) {
+	spec := ThumbnailSpec{Name: "avatar", Width: 128, Height: 128, Mode: ResizeFit}
+
+	err := ApplyHints(&spec, map[string]string{
+		HintResizeMode:   "fill",
+		HintResizeAnchor: "bottom-right",
+	})
+	if err != nil {
+		t.Fatalf("ApplyHints returned error: %v", err)
+	}
+	if spec.Mode != ResizeFill || spec.Anchor != AnchorBottomRight {
+		t.Errorf("got mode=%q anchor=%q, want fill/bottom-right", spec.Mode, spec.Anchor)
+	}
+
+	if err := ApplyHints(&spec, map[string]string{HintResizeMode: "zoom"}); err == nil {
+		t.Error("expected error for invalid resize_mode hint")
+	}
+}
+
+func TestApplyHintsSeek(t *testing.T) {
+	tests := []struct {
+		value string
+		want  SeekPosition
+	}{
+		{"25%", SeekPosition{Percent: 25}},
+		{"12", SeekPosition{Seconds: 12}},
+		{"2.5", SeekPosition{Seconds: 2.5}},
+		{"1m30s", SeekPosition{Seconds: 90}},
+	}
+	for _, tt := range tests {
+		spec := ThumbnailSpec{Name: "poster", Width: 320, Height: 180}
+		if err := ApplyHints(&spec, map[string]string{HintSeek: tt.value}); err != nil {
+			t.Fatalf("ApplyHints(seek=%s) returned error: %v", tt.value, err)
+		}
+		if spec.Seek != tt.want {
+			t.Errorf("seek=%s: got %+v, want %+v", tt.value, spec.Seek, tt.want)
+		}
+	}
+
+	for _, value := range []string{"0", "-3", "100%", "0%", "soon"} {
+		if _, err := ParseSeekPosition(value); err == nil {
+			t.Errorf("ParseSeekPosition(%q) expected error", value)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package img
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+)
+
+// VariantContactSheet marks the grid of pages generated for specs that set
+// ContactSheet. Extra pages written for specs that set Pages use PageVariant.
+const VariantContactSheet = "contact_sheet"
+
+const (
+	// DefaultContactSheetPages is used by the bare "contact_sheet" size option.
+	DefaultContactSheetPages = 9
+
+	// contactSheetGap separates the tiles of a contact sheet and its border.
+	contactSheetGap = 4
+)
+
+// ErrPageOutOfRange is returned when a spec selects a page past the end of a
+// document. Retrying will not help.
+var ErrPageOutOfRange = errors.New("page out of range")
+
+// PageVariant returns the variant of the extra output showing page n.
+func PageVariant(n int) string {
+	return "page_" + strconv.Itoa(n)
+}
+
+// page returns the 1-based page a spec thumbnails.
+func (s ThumbnailSpec) page() int {
+	if s.Page > 0 {
+		return s.Page
+	}
+	return 1
+}
+
+// checkPageRange rejects specs that select a page past the end of a document
+// with pageCount pages. Page counts (Pages, ContactSheet) are not checked;
+// they are capped to the document by clampPages instead. A pageCount of zero
+// means unknown and accepts every page.
+func checkPageRange(spec ThumbnailSpec, pageCount int) error {
+	if pageCount > 0 && spec.page() > pageCount {
+		return fmt.Errorf("size %s: %w: page %d, document has %d pages", spec.Name, ErrPageOutOfRange, spec.page(), pageCount)
+	}
+	return nil
+}
+
+// clampPages caps n to the document's page count, when known.
+func clampPages(n, pageCount int) int {
+	if pageCount > 0 {
+		return min(n, pageCount)
+	}
+	return n
+}
+
+// contactSheet lays out pages in a grid inside the spec's box. Each page fits
+// its tile without upscaling and is centred on the spec's background colour
+// (white by default). Columns default to a square grid.
+func contactSheet(pages []image.Image, spec ThumbnailSpec) (*image.NRGBA, error) {
+	n := len(pages)
+	columns := spec.ContactSheetColumns
+	if columns <= 0 {
+		columns = int(math.Ceil(math.Sqrt(float64(n))))
+	}
+	columns = min(columns, n)
+	rows := (n + columns - 1) / columns
+
+	tileW := (spec.Width - (columns+1)*contactSheetGap) / columns
+	tileH := (spec.Height - (rows+1)*contactSheetGap) / rows
+	if tileW < 1 || tileH < 1 {
+		return nil, fmt.Errorf("%dx%d is too small for a %dx%d contact sheet", spec.Width, spec.Height, columns, rows)
+	}
+
+	bg := defaultBackground
+	if spec.Background != nil {
+		bg = color.NRGBAModel.Convert(spec.Background).(color.NRGBA)
+	}
+	sheet := imaging.New(spec.Width, spec.Height, bg)
+
+	// Centre the grid; integer tile sizes leave a few spare pixels
+	gridW := columns*tileW + (columns+1)*contactSheetGap
+	gridH := rows*tileH + (rows+1)*contactSheetGap
+	origin := image.Pt((spec.Width-gridW)/2, (spec.Height-gridH)/2)
+
+	for i, page := range pages {
+		tile := imaging.Fit(page, tileW, tileH, imaging.Lanczos)
+		x := origin.X + contactSheetGap + i%columns*(tileW+contactSheetGap) + (tileW-tile.Bounds().Dx())/2
+		y := origin.Y + contactSheetGap + i/columns*(tileH+contactSheetGap) + (tileH-tile.Bounds().Dy())/2
+		sheet = imaging.Overlay(sheet, tile, image.Pt(x, y), 1)
+	}
+	return sheet, nil
+}
+
+// pageThumbnail resizes page for spec and writes it under name. The source
+// size is that of page as rendered, the space Crop is expressed in.
+func pageThumbnail(ctx context.Context, page image.Image, baseDstPath string, spec ThumbnailSpec, name string) (ThumbnailOutput, error) {
+	spec.Name = name
+	outputPath := thumbnailPath(baseDstPath, spec, FormatPNG)
+
+	thumb, crop := resizeImage(page, spec)
+	quality, err := saveImage(ctx, thumb, outputPath, spec)
+	if err != nil {
+		return ThumbnailOutput{}, fmt.Errorf("save %s: %w", name, err)
+	}
+
+	b := thumb.Bounds()
+	return ThumbnailOutput{
+		Name:         name,
+		Path:         outputPath,
+		Width:        b.Dx(),
+		Height:       b.Dy(),
+		SourceWidth:  page.Bounds().Dx(),
+		SourceHeight: page.Bounds().Dy(),
+		Mode:         spec.resizeMode(),
+		Crop:         crop,
+		Format:       spec.outputFormat(FormatPNG),
+		Quality:      quality,
+	}, nil
+}
@@ -0,0 +1,147 @@
+package img
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestApplySpecOptionPages(t *testing.T) {
+	spec := ThumbnailSpec{Name: "doc", Width: 256, Height: 256}
+	if err := ApplySpecOption(&spec, "contact_sheet"); err != nil {
+		t.Fatalf("ApplySpecOption(contact_sheet) returned error: %v", err)
+	}
+	if spec.ContactSheet != DefaultContactSheetPages {
+		t.Errorf("got contact sheet of %d pages, want %d", spec.ContactSheet, DefaultContactSheetPages)
+	}
+
+	hints := map[string]string{HintPage: "3", HintPages: "4", HintContactSheet: "6", HintContactSheetColumns: "3"}
+	if err := ApplyHints(&spec, hints); err != nil {
+		t.Fatalf("ApplyHints returned error: %v", err)
+	}
+	if spec.Page != 3 || spec.Pages != 4 || spec.ContactSheet != 6 || spec.ContactSheetColumns != 3 {
+		t.Errorf("got page=%d pages=%d contact_sheet=%d columns=%d", spec.Page, spec.Pages, spec.ContactSheet, spec.ContactSheetColumns)
+	}
+
+	if err := ApplySpecOption(&spec, "contact_sheet=false"); err != nil || spec.ContactSheet != 0 {
+		t.Errorf("contact_sheet=false: got %d pages, err %v", spec.ContactSheet, err)
+	}
+	for _, opt := range []string{"page=0", "pages=-1", "contact_sheet=maybe", "contact_sheet_columns=0"} {
+		if err := ApplySpecOption(&spec, opt); err == nil {
+			t.Errorf("ApplySpecOption(%q) expected error", opt)
+		}
+	}
+}
+
+func TestCheckPageRange(t *testing.T) {
+	spec := ThumbnailSpec{Name: "doc", Page: 5}
+	if err := checkPageRange(spec, 4); !errors.Is(err, ErrPageOutOfRange) {
+		t.Errorf("page 5 of 4: got %v, want ErrPageOutOfRange", err)
+	}
+	if err := checkPageRange(spec, 5); err != nil {
+		t.Errorf("page 5 of 5: got %v", err)
+	}
+	if err := checkPageRange(spec, 0); err != nil {
+		t.Errorf("unknown page count: got %v", err)
+	}
+	if got := clampPages(9, 4); got != 4 {
+		t.Errorf("clampPages(9, 4) = %d, want 4", got)
+	}
+}
+
+func TestContactSheet(t *testing.T) {
+	colours := []color.NRGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+		{B: 255, A: 255},
+	}
+	pages := make([]image.Image, len(colours))
+	for i, c := range colours {
+		pages[i] = imaging.New(100, 141, c)
+	}
+
+	sheet, err := contactSheet(pages, ThumbnailSpec{Width: 200, Height: 200})
+	if err != nil {
+		t.Fatalf("contactSheet returned error: %v", err)
+	}
+	if b := sheet.Bounds(); b.Dx() != 200 || b.Dy() != 200 {
+		t.Fatalf("got %dx%d, want the 200x200 box", b.Dx(), b.Dy())
+	}
+
+	// Three pages make a 2x2 grid of 94x94 tiles; the fourth cell stays empty
+	if got := sheet.NRGBAAt(50, 50); got != colours[0] {
+		t.Errorf("first tile centre = %v, want %v", got, colours[0])
+	}
+	if got := sheet.NRGBAAt(150, 50); got != colours[1] {
+		t.Errorf("second tile centre = %v, want %v", got, colours[1])
+	}
+	if got := sheet.NRGBAAt(50, 150); got != colours[2] {
+		t.Errorf("third tile centre = %v, want %v", got, colours[2])
+	}
+	if got := sheet.NRGBAAt(150, 150); got != defaultBackground {
+		t.Errorf("empty cell = %v, want background", got)
+	}
+
+	if _, err := contactSheet(pages, ThumbnailSpec{Width: 10, Height: 10}); err == nil {
+		t.Error("expected error for a box too small to tile")
+	}
+}
+
+func TestPageThumbnailSourceSize(t *testing.T) {
+	// A page rendered at 400x300 pixels, whatever its size in points
+	page := imaging.New(400, 300, color.White)
+	output, err := pageThumbnail(context.Background(), page, filepath.Join(t.TempDir(), "thumb.png"), ThumbnailSpec{Width: 100, Height: 100, Mode: ResizeFill}, "doc_page_2")
+	if err != nil {
+		t.Fatalf("pageThumbnail returned error: %v", err)
+	}
+	if output.SourceWidth != 400 || output.SourceHeight != 300 {
+		t.Errorf("got source %dx%d, want 400x300", output.SourceWidth, output.SourceHeight)
+	}
+	if !output.Crop.In(image.Rect(0, 0, 400, 300)) || output.Crop.Dy() != 300 {
+		t.Errorf("crop %v is not within the rendered page", output.Crop)
+	}
+}
+
+func TestPDFGeneratorPages(t *testing.T) {
+	if _, err := exec.LookPath("pdftoppm"); err != nil {
+		t.Skip("pdftoppm not installed")
+	}
+	sample := "../../scripts/test-samples/sample.pdf"
+	if _, err := os.Stat(sample); err != nil {
+		t.Skipf("sample file not found: %s", sample)
+	}
+
+	results, err := NewPDFGenerator().Generate(context.Background(), sample, filepath.Join(t.TempDir(), "thumb.png"), []ThumbnailSpec{
+		{Name: "doc", Width: 256, Height: 256, ContactSheet: 4},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected page and contact sheet, got %d outputs", len(results))
+	}
+	if results[0].Page != 1 {
+		t.Errorf("got page %d, want 1", results[0].Page)
+	}
+	if results[0].SourceWidth > 256 && results[0].SourceHeight > 256 {
+		t.Errorf("got source %dx%d, want the page as rendered for 256x256", results[0].SourceWidth, results[0].SourceHeight)
+	}
+	sheet := results[1]
+	if sheet.Name != "doc_contact_sheet" || sheet.Variant != VariantContactSheet || sheet.Width != 256 || sheet.Height != 256 {
+		t.Errorf("got name=%q variant=%q %dx%d", sheet.Name, sheet.Variant, sheet.Width, sheet.Height)
+	}
+
+	_, err = NewPDFGenerator().Generate(context.Background(), sample, filepath.Join(t.TempDir(), "thumb.png"), []ThumbnailSpec{
+		{Name: "doc", Width: 256, Height: 256, Page: 10000},
+	})
+	if !errors.Is(err, ErrPageOutOfRange) {
+		t.Errorf("page 10000: got %v, want ErrPageOutOfRange", err)
+	}
+}
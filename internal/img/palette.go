@@ -0,0 +1,147 @@
+package img
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+// Palette is the set of dominant colours of a source.
+type Palette struct {
+	// Dominant is the colour covering the most pixels, as "#rrggbb".
+	Dominant string
+	// Colors holds up to PaletteSize colours as "#rrggbb", most common first.
+	Colors []string
+}
+
+const (
+	// PaletteSize is the number of colours ComputePalette extracts.
+	PaletteSize = 5
+
+	paletteSampleSize = 64
+	// paletteMinAlpha skips mostly transparent pixels, whose colour is not
+	// visible and would skew the palette towards black.
+	paletteMinAlpha = 128
+)
+
+// ComputePalette extracts the dominant colour and a PaletteSize-colour palette
+// from src. Generators pass the decoded source, whose colours a cropped or
+// letterboxed thumbnail would misrepresent. The palette is empty if src has
+// no opaque pixels.
+func ComputePalette(src image.Image) Palette {
+	colors := ExtractPalette(src, PaletteSize)
+	if len(colors) == 0 {
+		return Palette{}
+	}
+
+	p := Palette{Colors: make([]string, len(colors))}
+	for i, c := range colors {
+		p.Colors[i] = hexColor(c)
+	}
+	p.Dominant = p.Colors[0]
+	return p
+}
+
+// ExtractPalette quantises img to at most n colours with median cut and
+// returns them ordered by how many pixels they represent.
+func ExtractPalette(img image.Image, n int) []color.NRGBA {
+	sample := imaging.Fit(img, paletteSampleSize, paletteSampleSize, imaging.Box)
+
+	pixels := make([][3]uint8, 0, len(sample.Pix)/4)
+	for i := 0; i < len(sample.Pix); i += 4 {
+		if sample.Pix[i+3] < paletteMinAlpha {
+			continue
+		}
+		pixels = append(pixels, [3]uint8{sample.Pix[i], sample.Pix[i+1], sample.Pix[i+2]})
+	}
+	if len(pixels) == 0 || n <= 0 {
+		return nil
+	}
+
+	boxes := []colorBox{{pixels: pixels}}
+	for len(boxes) < n {
+		// Split the box with the widest channel range; stop once every box is a
+		// single colour.
+		split, channel, widest := -1, 0, 0
+		for i, b := range boxes {
+			if len(b.pixels) < 2 {
+				continue
+			}
+			if c, r := b.widestChannel(); r > widest {
+				split, channel, widest = i, c, r
+			}
+		}
+		if split < 0 {
+			break
+		}
+
+		b := boxes[split].pixels
+		sort.Slice(b, func(i, j int) bool { return b[i][channel] < b[j][channel] })
+		mid := len(b) / 2
+		boxes[split] = colorBox{pixels: b[:mid]}
+		boxes = append(boxes, colorBox{pixels: b[mid:]})
+	}
+
+	// A median can fall inside a run of one colour, leaving the same colour in
+	// two boxes; merge those so the palette only has distinct entries.
+	counts := make(map[color.NRGBA]int, len(boxes))
+	colors := make([]color.NRGBA, 0, len(boxes))
+	for _, b := range boxes {
+		c := b.average()
+		if _, ok := counts[c]; !ok {
+			colors = append(colors, c)
+		}
+		counts[c] += len(b.pixels)
+	}
+	sort.SliceStable(colors, func(i, j int) bool { return counts[colors[i]] > counts[colors[j]] })
+	return colors
+}
+
+// colorBox is a median cut bucket of RGB pixels.
+type colorBox struct {
+	pixels [][3]uint8
+}
+
+// widestChannel returns the RGB channel with the largest value range and
+// that range.
+func (b colorBox) widestChannel() (int, int) {
+	lo := [3]int{255, 255, 255}
+	hi := [3]int{}
+	for _, p := range b.pixels {
+		for c := 0; c < 3; c++ {
+			lo[c] = min(lo[c], int(p[c]))
+			hi[c] = max(hi[c], int(p[c]))
+		}
+	}
+
+	channel := 0
+	for c := 1; c < 3; c++ {
+		if hi[c]-lo[c] > hi[channel]-lo[channel] {
+			channel = c
+		}
+	}
+	return channel, hi[channel] - lo[channel]
+}
+
+func (b colorBox) average() color.NRGBA {
+	var sum [3]int
+	for _, p := range b.pixels {
+		for c := 0; c < 3; c++ {
+			sum[c] += int(p[c])
+		}
+	}
+	n := len(b.pixels)
+	return color.NRGBA{
+		R: uint8((sum[0] + n/2) / n),
+		G: uint8((sum[1] + n/2) / n),
+		B: uint8((sum[2] + n/2) / n),
+		A: 255,
+	}
+}
+
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
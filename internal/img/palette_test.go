@@ -0,0 +1,56 @@
+package img
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestExtractPaletteOrdersByCoverage(t *testing.T) {
+	// Three quarters red, one quarter blue
+	src := imaging.New(100, 100, color.NRGBA{R: 255, A: 255})
+	blue := imaging.New(100, 25, color.NRGBA{B: 255, A: 255})
+	src = imaging.Paste(src, blue, image.Pt(0, 75))
+
+	colors := ExtractPalette(src, 5)
+	if len(colors) != 2 {
+		t.Fatalf("expected 2 distinct colours, got %v", colors)
+	}
+	if hexColor(colors[0]) != "#ff0000" || hexColor(colors[1]) != "#0000ff" {
+		t.Errorf("got %s, %s; want #ff0000 then #0000ff", hexColor(colors[0]), hexColor(colors[1]))
+	}
+}
+
+func TestExtractPaletteSkipsTransparentPixels(t *testing.T) {
+	src := imaging.New(50, 50, color.NRGBA{})
+	if colors := ExtractPalette(src, 5); len(colors) != 0 {
+		t.Errorf("expected no colours for a transparent image, got %v", colors)
+	}
+}
+
+func TestComputePaletteFromSource(t *testing.T) {
+	// Red in the middle, blue at both ends: a centre crop to a square keeps
+	// only red, but most of the source is blue
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "source.png")
+	src := imaging.New(400, 100, color.NRGBA{B: 255, A: 255})
+	src = imaging.Paste(src, imaging.New(100, 100, color.NRGBA{R: 255, A: 255}), image.Pt(150, 0))
+	if err := imaging.Save(src, srcPath); err != nil {
+		t.Fatal(err)
+	}
+
+	thumbs, err := GenerateThumbnails(srcPath, filepath.Join(tmp, "thumb.png"), []ThumbnailSpec{
+		{Name: "square", Width: 50, Height: 50, Mode: ResizeFill},
+	})
+	if err != nil {
+		t.Fatalf("GenerateThumbnails returned error: %v", err)
+	}
+
+	p := thumbs[0].Palette
+	if p.Dominant != "#0000ff" || len(p.Colors) != 2 || p.Colors[1] != "#ff0000" {
+		t.Errorf("got dominant %s palette %v, want #0000ff then #ff0000", p.Dominant, p.Colors)
+	}
+}
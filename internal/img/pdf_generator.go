@@ -2,7 +2,10 @@ package img
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"image"
+	"math"
 	"os"
 	"path/filepath"
 
@@ -13,66 +16,239 @@ import (
 // It adapts the converters.PopplerConverter to the img.Generator interface.
 type PDFGenerator struct {
 	converter *converters.PopplerConverter
+
+	// Limits bounds the sources that will be processed. The zero value is unlimited.
+	Limits Limits
+	// Password is the user password for encrypted PDFs, which the workers
+	// take from the job's pdf_password attribute. PDFs that only restrict
+	// permissions open without one. Poppler only accepts it on the command
+	// line, so it is visible to other local users (ps, /proc/<pid>/cmdline)
+	// while pdfinfo and pdftoppm run; it is never logged.
+	Password string
 }
 
+// errPastLastPage marks an extra page that is past the end of a document of
+// unknown length.
+var errPastLastPage = errors.New("page past the end of the document")
+
 // NewPDFGenerator creates a new PDF thumbnail generator
 func NewPDFGenerator() *PDFGenerator {
 	return &PDFGenerator{
 		converter: converters.NewPopplerConverter(),
+		Limits:    DefaultLimits,
 	}
 }
 
 // Generate implements Generator.Generate for PDFs
-// It generates thumbnails from the first page of the PDF
+// Each page is rendered once, at the resolution the largest spec needs, and
+// every size is derived from it in Go through the same resampling path as
+// images. Specs thumbnail their Page (the first by default) and may add
+// further pages and a contact sheet; see ThumbnailSpec.
 func (g *PDFGenerator) Generate(ctx context.Context, srcPath string, baseDstPath string, specs []ThumbnailSpec) ([]ThumbnailOutput, error) {
 	var results []ThumbnailOutput
 
-	// Get source dimensions for output metadata
+	if err := g.Limits.checkFileSize(srcPath); err != nil {
+		return nil, err
+	}
+
+	g.converter.SetPassword(g.Password)
+
+	// Get source dimensions for output metadata and enforce limits before
+	// handing the file to the external tool. Probing is optional, but a PDF
+	// that cannot be opened will not render either.
 	fileInfo, err := g.converter.Probe(ctx, srcPath)
-	sourceWidth := 0
-	sourceHeight := 0
+	if errors.Is(err, converters.ErrPDFEncrypted) || errors.Is(err, converters.ErrPDFMalformed) {
+		return nil, err
+	}
+	pageCount := 0
 	if err == nil {
-		sourceWidth = fileInfo.Width
-		sourceHeight = fileInfo.Height
+		if err := g.Limits.checkPages(fileInfo.Pages); err != nil {
+			return nil, err
+		}
+		if err := g.Limits.checkPixels(fileInfo.Width, fileInfo.Height); err != nil {
+			return nil, err
+		}
+		pageCount = fileInfo.Pages
+	}
+	for _, spec := range specs {
+		if err := checkPageRange(spec, pageCount); err != nil {
+			return nil, err
+		}
+	}
+
+	// Ensure output directory exists
+	if err := os.MkdirAll(filepath.Dir(baseDstPath), 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir: %w", err)
 	}
 
-	// Generate thumbnail for each size specification
+	size := renderSize(specs, fileInfo)
+	pages := make(map[int]image.Image)
+	page := func(n int) (image.Image, error) {
+		if p, ok := pages[n]; ok {
+			return p, nil
+		}
+		p, err := g.renderPage(ctx, srcPath, baseDstPath, n, size)
+		if err != nil {
+			return nil, fmt.Errorf("render page %d: %w", n, err)
+		}
+		pages[n] = p
+		return p, nil
+	}
+	// Further pages are capped to the document, but when its length is
+	// unknown a page past the first that fails to render is taken to be
+	// past the end
+	extraPage := func(n int) (image.Image, error) {
+		p, err := page(n)
+		if err != nil && pageCount == 0 && n > 1 {
+			return nil, errPastLastPage
+		}
+		return p, err
+	}
+
+	var hashed image.Image
 	for _, spec := range specs {
-		// Build output path: base_sizename.png
-		// Use PNG for PDFs as it preserves text quality better
-		ext := filepath.Ext(baseDstPath)
-		base := baseDstPath[:len(baseDstPath)-len(ext)]
-		outputPath := fmt.Sprintf("%s_%s.png", base, spec.Name)
-
-		// Ensure output directory exists
-		outputDir := filepath.Dir(outputPath)
-		if err := os.MkdirAll(outputDir, 0o755); err != nil {
-			return nil, fmt.Errorf("mkdir for %s: %w", spec.Name, err)
+		main, err := page(spec.page())
+		if err != nil {
+			return nil, err
+		}
+		if hashed == nil {
+			hashed = main
 		}
 
-		// Convert PDF to thumbnail
-		err := g.converter.Convert(ctx, srcPath, outputPath, spec.Width, spec.Height)
+		// PNG by default for PDFs as it preserves text quality better
+		output, err := pageThumbnail(ctx, main, baseDstPath, spec, spec.Name)
 		if err != nil {
-			return nil, fmt.Errorf("generate thumbnail %s: %w", spec.Name, err)
+			return nil, err
+		}
+		output.Page = spec.page()
+		results = append(results, output)
+
+		// Further pages, skipping the one already written under spec.Name
+		for n := 1; n <= clampPages(spec.Pages, pageCount); n++ {
+			if n == spec.page() {
+				continue
+			}
+			p, err := extraPage(n)
+			if errors.Is(err, errPastLastPage) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			output, err := pageThumbnail(ctx, p, baseDstPath, spec, spec.Name+"_"+PageVariant(n))
+			if err != nil {
+				return nil, err
+			}
+			output.Page = n
+			output.Variant = PageVariant(n)
+			results = append(results, output)
 		}
 
-		// For PDFs, the output dimensions match the spec (Poppler scales to fit)
-		actualWidth := spec.Width
-		actualHeight := spec.Height
+		if spec.ContactSheet > 0 {
+			sheet, err := g.contactSheetOutput(ctx, extraPage, baseDstPath, spec, clampPages(spec.ContactSheet, pageCount))
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, sheet)
+		}
+	}
 
-		results = append(results, ThumbnailOutput{
-			Name:         spec.Name,
-			Path:         outputPath,
-			Width:        actualWidth,
-			Height:       actualHeight,
-			SourceWidth:  sourceWidth,
-			SourceHeight: sourceHeight,
-		})
+	if hashed == nil {
+		return results, nil
+	}
+
+	// Perceptual hash of the first page thumbnailed, for duplicate detection,
+	// and its palette
+	hash := ComputePerceptualHash(hashed)
+	palette := ComputePalette(hashed)
+	for i := range results {
+		results[i].SourceHash = hash
+		results[i].Palette = palette
 	}
 
 	return results, nil
 }
 
+// contactSheetOutput writes a grid of the first n pages, or of those before
+// errPastLastPage, named Name+"_contact_sheet".
+func (g *PDFGenerator) contactSheetOutput(ctx context.Context, page func(int) (image.Image, error), baseDstPath string, spec ThumbnailSpec, n int) (ThumbnailOutput, error) {
+	name := spec.Name + "_" + VariantContactSheet
+	var tiles []image.Image
+	for i := 1; i <= n; i++ {
+		p, err := page(i)
+		if errors.Is(err, errPastLastPage) {
+			break
+		}
+		if err != nil {
+			return ThumbnailOutput{}, err
+		}
+		tiles = append(tiles, p)
+	}
+
+	sheet, err := contactSheet(tiles, spec)
+	if err != nil {
+		return ThumbnailOutput{}, fmt.Errorf("generate %s: %w", name, err)
+	}
+
+	sheetSpec := spec
+	sheetSpec.Name = name
+	outputPath := thumbnailPath(baseDstPath, sheetSpec, FormatPNG)
+	quality, err := saveImage(ctx, sheet, outputPath, spec)
+	if err != nil {
+		return ThumbnailOutput{}, fmt.Errorf("save %s: %w", name, err)
+	}
+
+	return ThumbnailOutput{
+		Name:      name,
+		Path:      outputPath,
+		Width:     sheet.Bounds().Dx(),
+		Height:    sheet.Bounds().Dy(),
+		Mode:      ResizePad,
+		Format:    spec.outputFormat(FormatPNG),
+		Quality:   quality,
+		Variant:   VariantContactSheet,
+		PageCount: len(tiles),
+	}, nil
+}
+
+// renderPage renders page n (1-based) with its longer side at size pixels,
+// or at the converter's DPI when size is 0, and decodes it.
+func (g *PDFGenerator) renderPage(ctx context.Context, srcPath, baseDstPath string, n, size int) (image.Image, error) {
+	tmpDir, err := os.MkdirTemp(filepath.Dir(baseDstPath), "page-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	pagePath := filepath.Join(tmpDir, "page.png")
+	if err := g.converter.ConvertWithOptions(ctx, srcPath, pagePath, size, size, converters.ConversionOptions{Page: n}); err != nil {
+		return nil, err
+	}
+	return openImage(pagePath, Limits{MaxPixels: g.Limits.MaxPixels})
+}
+
+// renderSize returns the longer side, in pixels, a page must be rendered at
+// so that no spec has to upscale it: fit and pad specs scale the page to fit
+// inside their box, fill and stretch specs to cover it. It returns 0, meaning
+// the converter's DPI, when the page size is unknown.
+func renderSize(specs []ThumbnailSpec, info *converters.FileInfo) int {
+	if info == nil || info.Width <= 0 || info.Height <= 0 {
+		return 0
+	}
+	w, h := float64(info.Width), float64(info.Height)
+
+	size := 0
+	for _, spec := range specs {
+		sx, sy := float64(spec.Width)/w, float64(spec.Height)/h
+		scale := math.Min(sx, sy)
+		if mode := spec.resizeMode(); mode == ResizeFill || mode == ResizeStretch {
+			scale = math.Max(sx, sy)
+		}
+		size = max(size, int(math.Ceil(scale*math.Max(w, h))))
+	}
+	return size
+}
+
 // Supports implements Generator.Supports for PDFs
 func (g *PDFGenerator) Supports(mimeType string) bool {
 	return g.converter.Supports(mimeType)
@@ -0,0 +1,29 @@
+package img
+
+import (
+	"testing"
+
+	"github.com/tendant/simple-thumbnailer/internal/converters"
+)
+
+func TestRenderSize(t *testing.T) {
+	// A4 portrait at 96 DPI
+	page := &converters.FileInfo{Width: 793, Height: 1122}
+
+	tests := []struct {
+		name  string
+		specs []ThumbnailSpec
+		info  *converters.FileInfo
+		want  int
+	}{
+		{"fit uses the tighter side", []ThumbnailSpec{{Width: 300, Height: 300}}, page, 300},
+		{"fill covers the box", []ThumbnailSpec{{Width: 300, Height: 300, Mode: ResizeFill}}, page, 425},
+		{"largest spec wins", []ThumbnailSpec{{Width: 64, Height: 64}, {Width: 512, Height: 512}, {Width: 128, Height: 128, Mode: ResizeFill}}, page, 512},
+		{"unknown page size", []ThumbnailSpec{{Width: 300, Height: 300}}, nil, 0},
+	}
+	for _, tt := range tests {
+		if got := renderSize(tt.specs, tt.info); got != tt.want {
+			t.Errorf("%s: renderSize = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package img
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+)
+
+// PerceptualHash holds 64-bit perceptual hashes of a source, as 16 hex
+// digits. Near-duplicate images have hashes a small Hamming distance apart.
+type PerceptualHash struct {
+	// PHash compares low DCT frequencies against their median. It is robust
+	// to scaling, re-encoding and small colour changes. Its top bit stands
+	// for the DC term and is always zero.
+	PHash string
+	// DHash compares neighbouring pixels of a 9x8 grayscale copy. It is cheap
+	// and robust to scaling and brightness changes.
+	DHash string
+}
+
+const (
+	pHashSize    = 32
+	pHashLowFreq = 8
+)
+
+// ComputePerceptualHash computes the pHash and dHash of img.
+func ComputePerceptualHash(img image.Image) PerceptualHash {
+	return PerceptualHash{
+		PHash: formatHash(pHash(img)),
+		DHash: formatHash(dHash(img)),
+	}
+}
+
+// HammingDistance returns the number of differing bits between two hashes
+// produced by ComputePerceptualHash.
+func HammingDistance(a, b string) (int, error) {
+	x, err := strconv.ParseUint(a, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hash %q: %w", a, err)
+	}
+	y, err := strconv.ParseUint(b, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hash %q: %w", b, err)
+	}
+	return bits.OnesCount64(x ^ y), nil
+}
+
+func pHash(img image.Image) uint64 {
+	// Shrink before converting to grayscale, which copies the whole image
+	small := imaging.Grayscale(imaging.Resize(img, pHashSize, pHashSize, imaging.Lanczos))
+
+	// Separable 2D DCT-II, keeping only the 8x8 lowest frequencies
+	var rows [pHashSize][pHashLowFreq]float64
+	for y := 0; y < pHashSize; y++ {
+		for u := 0; u < pHashLowFreq; u++ {
+			var sum float64
+			for x := 0; x < pHashSize; x++ {
+				sum += float64(small.Pix[small.PixOffset(x, y)]) * dctCos(x, u)
+			}
+			rows[y][u] = sum
+		}
+	}
+	coeffs := make([]float64, 0, pHashLowFreq*pHashLowFreq)
+	for v := 0; v < pHashLowFreq; v++ {
+		for u := 0; u < pHashLowFreq; u++ {
+			var sum float64
+			for y := 0; y < pHashSize; y++ {
+				sum += rows[y][u] * dctCos(y, v)
+			}
+			coeffs = append(coeffs, sum)
+		}
+	}
+
+	// The DC term only reflects overall brightness, so leave it out of the
+	// hash; its top bit is always zero
+	ac := coeffs[1:]
+	sorted := append([]float64(nil), ac...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	var hash uint64
+	for i, c := range ac {
+		if c > median {
+			hash |= 1 << uint(62-i)
+		}
+	}
+	return hash
+}
+
+func dctCos(x, u int) float64 {
+	return math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * pHashSize))
+}
+
+func dHash(img image.Image) uint64 {
+	small := imaging.Grayscale(imaging.Resize(img, 9, 8, imaging.Lanczos))
+
+	var hash uint64
+	i := 0
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if small.Pix[small.PixOffset(x, y)] > small.Pix[small.PixOffset(x+1, y)] {
+				hash |= 1 << uint(63-i)
+			}
+			i++
+		}
+	}
+	return hash
+}
+
+func formatHash(h uint64) string {
+	return fmt.Sprintf("%016x", h)
+}
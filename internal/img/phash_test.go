@@ -0,0 +1,107 @@
+package img
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestPerceptualHashNearDuplicates(t *testing.T) {
+	original := hashTestImage(false)
+	// Same picture, scaled down, brightened and JPEG-compressed
+	path := filepath.Join(t.TempDir(), "copy.jpg")
+	if err := imaging.Save(imaging.AdjustBrightness(imaging.Resize(original, 160, 120, imaging.Lanczos), 10), path, imaging.JPEGQuality(60)); err != nil {
+		t.Fatalf("save copy: %v", err)
+	}
+	copyImg, err := imaging.Open(path)
+	if err != nil {
+		t.Fatalf("open copy: %v", err)
+	}
+	different := hashTestImage(true)
+
+	a := ComputePerceptualHash(original)
+	b := ComputePerceptualHash(copyImg)
+	c := ComputePerceptualHash(different)
+	if a.PHash[0] > '7' {
+		t.Errorf("pHash %s sets the DC bit", a.PHash)
+	}
+
+	for _, tc := range []struct {
+		name string
+		x, y string
+		near bool
+	}{
+		{"phash copy", a.PHash, b.PHash, true},
+		{"dhash copy", a.DHash, b.DHash, true},
+		{"phash different", a.PHash, c.PHash, false},
+		{"dhash different", a.DHash, c.DHash, false},
+	} {
+		d, err := HammingDistance(tc.x, tc.y)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if tc.near && d > 8 {
+			t.Errorf("%s: distance %d, expected near-duplicate (<= 8)", tc.name, d)
+		}
+		if !tc.near && d < 16 {
+			t.Errorf("%s: distance %d, expected distinct images (>= 16)", tc.name, d)
+		}
+	}
+}
+
+func TestHammingDistanceRejectsInvalidHash(t *testing.T) {
+	if _, err := HammingDistance("zz", "0000000000000000"); err == nil {
+		t.Error("expected error for invalid hash")
+	}
+}
+
+func TestGenerateThumbnailsSetsSourceHash(t *testing.T) {
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "source.png")
+	src := hashTestImage(false)
+	if err := imaging.Save(src, srcPath); err != nil {
+		t.Fatalf("save source: %v", err)
+	}
+
+	results, err := GenerateThumbnails(srcPath, filepath.Join(tmp, "thumb.png"), []ThumbnailSpec{
+		{Name: "small", Width: 50, Height: 50},
+		{Name: "square", Width: 80, Height: 80, Mode: ResizeFill},
+	})
+	if err != nil {
+		t.Fatalf("GenerateThumbnails returned error: %v", err)
+	}
+
+	want := ComputePerceptualHash(src)
+	for _, r := range results {
+		if r.SourceHash != want {
+			t.Errorf("%s: SourceHash = %+v, want hash of source %+v", r.Name, r.SourceHash, want)
+		}
+	}
+}
+
+// hashTestImage draws a 320x240 scene of blocks on a gradient. The flipped
+// variant mirrors the layout so it shares colours but not structure.
+func hashTestImage(flipped bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 320, 240))
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 320; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 255 / 320), G: uint8(y * 255 / 240), B: 128, A: 255})
+		}
+	}
+	blocks := []image.Rectangle{
+		image.Rect(20, 20, 120, 100),
+		image.Rect(200, 140, 300, 220),
+		image.Rect(140, 60, 180, 200),
+	}
+	for i, r := range blocks {
+		if flipped {
+			r = image.Rect(320-r.Max.X, r.Min.Y, 320-r.Min.X, r.Max.Y)
+		}
+		c := color.NRGBA{R: uint8(60 * i), G: 20, B: uint8(255 - 80*i), A: 255}
+		img = imaging.Paste(img, imaging.New(r.Dx(), r.Dy(), c), r.Min)
+	}
+	return img
+}
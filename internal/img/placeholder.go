@@ -0,0 +1,298 @@
+package img
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// Placeholders are compact, blurred previews that clients can render while
+// the real thumbnail loads.
+type Placeholders struct {
+	// BlurHash is a 4x3 component BlurHash string (https://blurha.sh).
+	BlurHash string
+	// ThumbHash is a standard base64-encoded ThumbHash
+	// (https://evanw.github.io/thumbhash/). It also encodes aspect ratio and alpha.
+	ThumbHash string
+}
+
+const (
+	blurHashXComponents = 4
+	blurHashYComponents = 3
+
+	// Both encoders only look at low frequencies, so they run on a small copy.
+	blurHashMaxSize  = 64
+	thumbHashMaxSize = 100
+)
+
+// ComputePlaceholders computes the BlurHash and ThumbHash of the smallest
+// generated thumbnail. Thumbnails are read from disk, so call it before the
+// files are uploaded and removed.
+func ComputePlaceholders(thumbs []ThumbnailOutput) (Placeholders, error) {
+	src, err := openSmallestThumbnail(thumbs)
+	if err != nil {
+		return Placeholders{}, fmt.Errorf("placeholders: %w", err)
+	}
+
+	small := imaging.Fit(src, thumbHashMaxSize, thumbHashMaxSize, imaging.Box)
+	return Placeholders{
+		BlurHash:  BlurHash(imaging.Fit(small, blurHashMaxSize, blurHashMaxSize, imaging.Box), blurHashXComponents, blurHashYComponents),
+		ThumbHash: base64.StdEncoding.EncodeToString(ThumbHash(small)),
+	}, nil
+}
+
+// openSmallestThumbnail decodes the generated still thumbnail with the fewest
+// pixels. Per-source analyses run on it instead of the original, which may be
+// a video or document and is usually far larger. Variants such as animations
+// and preview clips are skipped.
+func openSmallestThumbnail(thumbs []ThumbnailOutput) (image.Image, error) {
+	var smallest *ThumbnailOutput
+	for i, t := range thumbs {
+		if t.Variant != "" {
+			continue
+		}
+		if smallest == nil || t.Width*t.Height < smallest.Width*smallest.Height {
+			smallest = &thumbs[i]
+		}
+	}
+	if smallest == nil {
+		return nil, fmt.Errorf("no thumbnails to analyse")
+	}
+
+	src, err := imaging.Open(smallest.Path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", smallest.Name, err)
+	}
+	return src, nil
+}
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// BlurHash encodes img with xComponents x yComponents DCT components (1-9 each).
+func BlurHash(img image.Image, xComponents, yComponents int) string {
+	xComponents = clampInt(xComponents, 1, 9)
+	yComponents = clampInt(yComponents, 1, 9)
+
+	src := imaging.Clone(img)
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			norm := 2.0
+			if i == 0 && j == 0 {
+				norm = 1
+			}
+			var f [3]float64
+			for y := 0; y < h; y++ {
+				fy := math.Cos(math.Pi * float64(j) * float64(y) / float64(h))
+				for x := 0; x < w; x++ {
+					basis := norm * fy * math.Cos(math.Pi*float64(i)*float64(x)/float64(w))
+					p := src.PixOffset(x, y)
+					f[0] += basis * srgbToLinear(src.Pix[p])
+					f[1] += basis * srgbToLinear(src.Pix[p+1])
+					f[2] += basis * srgbToLinear(src.Pix[p+2])
+				}
+			}
+			scale := 1 / float64(w*h)
+			factors = append(factors, [3]float64{f[0] * scale, f[1] * scale, f[2] * scale})
+		}
+	}
+
+	var sb strings.Builder
+	encodeBase83(&sb, (xComponents-1)+(yComponents-1)*9, 1)
+
+	dc, ac := factors[0], factors[1:]
+	maxValue := 1.0
+	if len(ac) > 0 {
+		var actualMax float64
+		for _, f := range ac {
+			actualMax = math.Max(actualMax, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+		}
+		quantisedMax := clampInt(int(math.Floor(actualMax*166-0.5)), 0, 82)
+		maxValue = float64(quantisedMax+1) / 166
+		encodeBase83(&sb, quantisedMax, 1)
+	} else {
+		encodeBase83(&sb, 0, 1)
+	}
+
+	encodeBase83(&sb, linearToSRGB(dc[0])<<16|linearToSRGB(dc[1])<<8|linearToSRGB(dc[2]), 4)
+	for _, f := range ac {
+		q := func(v float64) int {
+			return clampInt(int(math.Floor(signPow(v/maxValue, 0.5)*9+9.5)), 0, 18)
+		}
+		encodeBase83(&sb, q(f[0])*19*19+q(f[1])*19+q(f[2]), 2)
+	}
+	return sb.String()
+}
+
+func encodeBase83(sb *strings.Builder, value, length int) {
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		sb.WriteByte(base83Chars[digit])
+	}
+}
+
+func srgbToLinear(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) int {
+	c := math.Max(0, math.Min(1, v))
+	if c <= 0.0031308 {
+		return int(c*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(c, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
+
+// ThumbHash encodes img as a ThumbHash. Images larger than 100x100 are
+// downscaled first, as the format requires.
+func ThumbHash(img image.Image) []byte {
+	src := imaging.Clone(img)
+	if src.Bounds().Dx() > thumbHashMaxSize || src.Bounds().Dy() > thumbHashMaxSize {
+		src = imaging.Fit(src, thumbHashMaxSize, thumbHashMaxSize, imaging.Box)
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	n := w * h
+
+	// Average colour, weighted by alpha
+	var avgR, avgG, avgB, avgA float64
+	for i := 0; i < n; i++ {
+		p := src.Pix[i*4 : i*4+4]
+		alpha := float64(p[3]) / 255
+		avgR += alpha / 255 * float64(p[0])
+		avgG += alpha / 255 * float64(p[1])
+		avgB += alpha / 255 * float64(p[2])
+		avgA += alpha
+	}
+	if avgA > 0 {
+		avgR /= avgA
+		avgG /= avgA
+		avgB /= avgA
+	}
+
+	hasAlpha := avgA < float64(n)
+	lLimit := 7.0
+	if hasAlpha {
+		lLimit = 5 // fewer luminance bits when alpha needs room
+	}
+	maxSide := float64(max(w, h))
+	lx := max(1, int(math.Round(lLimit*float64(w)/maxSide)))
+	ly := max(1, int(math.Round(lLimit*float64(h)/maxSide)))
+
+	// Convert to LPQA, composited over the average colour
+	l := make([]float64, n)
+	pc := make([]float64, n)
+	qc := make([]float64, n)
+	a := make([]float64, n)
+	for i := 0; i < n; i++ {
+		p := src.Pix[i*4 : i*4+4]
+		alpha := float64(p[3]) / 255
+		r := avgR*(1-alpha) + alpha/255*float64(p[0])
+		g := avgG*(1-alpha) + alpha/255*float64(p[1])
+		b := avgB*(1-alpha) + alpha/255*float64(p[2])
+		l[i] = (r + g + b) / 3
+		pc[i] = (r+g)/2 - b
+		qc[i] = r - g
+		a[i] = alpha
+	}
+
+	encodeChannel := func(channel []float64, nx, ny int) (dc float64, ac []float64, scale float64) {
+		fx := make([]float64, w)
+		for cy := 0; cy < ny; cy++ {
+			for cx := 0; cx*ny < nx*(ny-cy); cx++ {
+				for x := 0; x < w; x++ {
+					fx[x] = math.Cos(math.Pi / float64(w) * float64(cx) * (float64(x) + 0.5))
+				}
+				var f float64
+				for y := 0; y < h; y++ {
+					fy := math.Cos(math.Pi / float64(h) * float64(cy) * (float64(y) + 0.5))
+					for x := 0; x < w; x++ {
+						f += channel[x+y*w] * fx[x] * fy
+					}
+				}
+				f /= float64(n)
+				if cx > 0 || cy > 0 {
+					ac = append(ac, f)
+					scale = math.Max(scale, math.Abs(f))
+				} else {
+					dc = f
+				}
+			}
+		}
+		if scale > 0 {
+			for i := range ac {
+				ac[i] = 0.5 + 0.5/scale*ac[i]
+			}
+		}
+		return dc, ac, scale
+	}
+
+	lDC, lAC, lScale := encodeChannel(l, max(3, lx), max(3, ly))
+	pDC, pAC, pScale := encodeChannel(pc, 3, 3)
+	qDC, qAC, qScale := encodeChannel(qc, 3, 3)
+	var aDC, aScale float64
+	var aAC []float64
+	if hasAlpha {
+		aDC, aAC, aScale = encodeChannel(a, 5, 5)
+	}
+
+	isLandscape := w > h
+	header24 := int(math.Round(63*lDC)) |
+		int(math.Round(31.5+31.5*pDC))<<6 |
+		int(math.Round(31.5+31.5*qDC))<<12 |
+		int(math.Round(31*lScale))<<18 |
+		boolBit(hasAlpha)<<23
+	header16 := ly
+	if !isLandscape {
+		header16 = lx
+	}
+	header16 |= int(math.Round(63*pScale))<<3 |
+		int(math.Round(63*qScale))<<9 |
+		boolBit(isLandscape)<<15
+
+	hash := []byte{
+		byte(header24), byte(header24 >> 8), byte(header24 >> 16),
+		byte(header16), byte(header16 >> 8),
+	}
+	if hasAlpha {
+		hash = append(hash, byte(int(math.Round(15*aDC))|int(math.Round(15*aScale))<<4))
+	}
+
+	channels := [][]float64{lAC, pAC, qAC}
+	if hasAlpha {
+		channels = append(channels, aAC)
+	}
+	acStart := len(hash)
+	acIndex := 0
+	for _, ac := range channels {
+		for _, f := range ac {
+			i := acStart + acIndex>>1
+			if i == len(hash) {
+				hash = append(hash, 0)
+			}
+			hash[i] |= byte(int(math.Round(15*f)) << ((acIndex & 1) << 2))
+			acIndex++
+		}
+	}
+	return hash
+}
+
+func boolBit(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,78 @@
+package img
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func TestBlurHashSolidColour(t *testing.T) {
+	white := imaging.New(32, 32, color.White)
+
+	// Size flag "L" is 4x3 components; "TSUA" is a DC of #ffffff.
+	// Then one max-AC digit and 11 two-digit AC terms.
+	got := BlurHash(white, 4, 3)
+	if len(got) != 1+1+4+2*11 {
+		t.Fatalf("BlurHash = %q, want 28 characters", got)
+	}
+	if got[0] != 'L' || got[2:6] != "TSUA" {
+		t.Errorf("BlurHash = %q, want size flag L and DC TSUA", got)
+	}
+}
+
+func TestThumbHashSolidColour(t *testing.T) {
+	white := imaging.New(32, 32, color.White)
+
+	// Header: L=63, P=Q=32 (neutral), no alpha; lx=7, square.
+	// 27 luminance and 2x5 chroma AC terms follow, two per byte.
+	got := ThumbHash(white)
+	if len(got) != 5+19 {
+		t.Fatalf("ThumbHash = %x, want 24 bytes", got)
+	}
+	if want := []byte{0x3f, 0x08, 0x02, 0x07, 0x00}; !bytes.Equal(got[:5], want) {
+		t.Errorf("ThumbHash header = %x, want %x", got[:5], want)
+	}
+
+	wide := ThumbHash(imaging.New(200, 100, color.White))
+	if wide[4]&0x80 == 0 {
+		t.Errorf("expected landscape flag for 200x100 image, got header %x", wide[:5])
+	}
+}
+
+func TestComputePlaceholdersUsesSmallestThumbnail(t *testing.T) {
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "source.png")
+	createTestImage(t, srcPath, 400, 200)
+
+	thumbs, err := GenerateThumbnails(srcPath, filepath.Join(tmp, "thumb.png"), []ThumbnailSpec{
+		{Name: "large", Width: 300, Height: 300},
+		{Name: "small", Width: 40, Height: 40},
+	})
+	if err != nil {
+		t.Fatalf("GenerateThumbnails returned error: %v", err)
+	}
+
+	got, err := ComputePlaceholders(thumbs)
+	if err != nil {
+		t.Fatalf("ComputePlaceholders returned error: %v", err)
+	}
+
+	small, err := imaging.Open(thumbs[1].Path)
+	if err != nil {
+		t.Fatalf("open small thumbnail: %v", err)
+	}
+	if want := BlurHash(small, 4, 3); got.BlurHash != want {
+		t.Errorf("BlurHash = %q, want hash of smallest thumbnail %q", got.BlurHash, want)
+	}
+	if want := base64.StdEncoding.EncodeToString(ThumbHash(small)); got.ThumbHash != want {
+		t.Errorf("ThumbHash = %q, want hash of smallest thumbnail %q", got.ThumbHash, want)
+	}
+
+	if _, err := ComputePlaceholders(nil); err == nil {
+		t.Error("expected error for no thumbnails")
+	}
+}
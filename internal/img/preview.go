@@ -0,0 +1,116 @@
+package img
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/tendant/simple-thumbnailer/internal/converters"
+)
+
+// VariantPreview marks the preview clip generated next to a spec's poster
+// frame when the spec sets Preview and the source is a video.
+const VariantPreview = "preview"
+
+const (
+	// DefaultPreviewDuration is the preview clip length when a spec does not set one.
+	DefaultPreviewDuration = 4 * time.Second
+	// DefaultPreviewSegments is how many points of the video the clip samples.
+	DefaultPreviewSegments = 4
+
+	previewFPS = 12
+)
+
+// ParsePreviewFormat converts a config or hint value into a preview clip format.
+func ParsePreviewFormat(value string) (OutputFormat, error) {
+	switch strings.ToLower(strings.TrimPrefix(value, ".")) {
+	case "mp4":
+		return FormatMP4, nil
+	case "webp":
+		return FormatWebP, nil
+	case "gif":
+		return FormatGIF, nil
+	default:
+		return "", fmt.Errorf("unsupported preview format %q (supported: mp4, webp, gif)", value)
+	}
+}
+
+func (s ThumbnailSpec) previewFormat() OutputFormat {
+	if s.PreviewFormat != "" {
+		return s.PreviewFormat
+	}
+	return FormatMP4
+}
+
+// generatePreview writes the preview clip for spec. The clip fits inside the
+// spec's box without upscaling; MP4 dimensions are rounded down to even
+// numbers as H.264 requires.
+func (g *VideoGenerator) generatePreview(ctx context.Context, srcPath, baseDstPath string, spec ThumbnailSpec, info *converters.FileInfo) (ThumbnailOutput, error) {
+	name := spec.Name + "_" + VariantPreview
+	if info == nil || info.Width <= 0 || info.Height <= 0 {
+		return ThumbnailOutput{}, fmt.Errorf("generate %s: video dimensions unknown", name)
+	}
+
+	format := spec.previewFormat()
+	width, height := fitSize(info.Width, info.Height, spec.Width, spec.Height)
+	if format == FormatMP4 {
+		width, height = max(width&^1, 2), max(height&^1, 2)
+	}
+
+	clip := spec.PreviewDuration
+	if clip <= 0 {
+		clip = DefaultPreviewDuration
+	}
+	if info.Duration > 0 && info.Duration < clip.Seconds() {
+		clip = time.Duration(info.Duration * float64(time.Second))
+	}
+	segments := spec.PreviewSegments
+	if segments <= 0 {
+		segments = DefaultPreviewSegments
+	}
+
+	var quality int
+	if format != FormatGIF {
+		quality = spec.Quality
+	}
+	dstPath := thumbnailPath(baseDstPath, ThumbnailSpec{Name: name}, format)
+	err := g.converter.Preview(ctx, srcPath, dstPath, width, height, converters.PreviewOptions{
+		Format:        string(format),
+		Duration:      clip.Seconds(),
+		Segments:      segments,
+		VideoDuration: info.Duration,
+		FPS:           previewFPS,
+		Quality:       quality,
+	})
+	if err != nil {
+		return ThumbnailOutput{}, fmt.Errorf("generate %s: %w", name, err)
+	}
+
+	return ThumbnailOutput{
+		Name:         name,
+		Path:         dstPath,
+		Width:        width,
+		Height:       height,
+		SourceWidth:  info.Width,
+		SourceHeight: info.Height,
+		Mode:         ResizeFit,
+		Format:       format,
+		Quality:      quality,
+		Variant:      VariantPreview,
+		Animated:     true,
+		FrameCount:   int(math.Round(clip.Seconds() * previewFPS)),
+		Duration:     clip,
+	}, nil
+}
+
+// fitSize scales w x h to fit inside boxW x boxH, keeping the aspect ratio and
+// never upscaling, like imaging.Fit.
+func fitSize(w, h, boxW, boxH int) (int, int) {
+	if w <= boxW && h <= boxH {
+		return w, h
+	}
+	scale := math.Min(float64(boxW)/float64(w), float64(boxH)/float64(h))
+	return max(int(math.Round(float64(w)*scale)), 1), max(int(math.Round(float64(h)*scale)), 1)
+}
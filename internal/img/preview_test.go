@@ -0,0 +1,107 @@
+package img
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestApplySpecOptionPreview(t *testing.T) {
+	spec := ThumbnailSpec{Name: "card", Width: 320, Height: 180}
+	for _, opt := range []string{"preview=webp", "preview_duration=3s", "preview_segments=3"} {
+		if err := ApplySpecOption(&spec, opt); err != nil {
+			t.Fatalf("ApplySpecOption(%q) returned error: %v", opt, err)
+		}
+	}
+	if !spec.Preview || spec.PreviewFormat != FormatWebP || spec.PreviewDuration != 3*time.Second || spec.PreviewSegments != 3 {
+		t.Errorf("got preview=%v format=%q duration=%v segments=%d", spec.Preview, spec.PreviewFormat, spec.PreviewDuration, spec.PreviewSegments)
+	}
+
+	spec = ThumbnailSpec{Name: "card", Width: 320, Height: 180}
+	if err := ApplyHints(&spec, map[string]string{HintPreview: "true"}); err != nil {
+		t.Fatalf("ApplyHints returned error: %v", err)
+	}
+	if !spec.Preview || spec.previewFormat() != FormatMP4 {
+		t.Errorf("got preview=%v format=%q, want true/mp4", spec.Preview, spec.previewFormat())
+	}
+
+	for _, opt := range []string{"preview=avi", "preview_segments=0", "preview_duration=-1"} {
+		if err := ApplySpecOption(&spec, opt); err == nil {
+			t.Errorf("ApplySpecOption(%q) expected error", opt)
+		}
+	}
+}
+
+func TestFitSize(t *testing.T) {
+	tests := []struct {
+		w, h, boxW, boxH int
+		wantW, wantH     int
+	}{
+		{1920, 1080, 320, 320, 320, 180},
+		{1080, 1920, 320, 320, 180, 320},
+		{200, 100, 320, 320, 200, 100}, // never upscales
+	}
+	for _, tt := range tests {
+		if w, h := fitSize(tt.w, tt.h, tt.boxW, tt.boxH); w != tt.wantW || h != tt.wantH {
+			t.Errorf("fitSize(%d, %d, %d, %d) = %dx%d, want %dx%d", tt.w, tt.h, tt.boxW, tt.boxH, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestComputePlaceholdersSkipsVariants(t *testing.T) {
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "source.png")
+	createTestImage(t, srcPath, 400, 200)
+
+	thumbs, err := GenerateThumbnails(srcPath, filepath.Join(tmp, "thumb.png"), []ThumbnailSpec{
+		{Name: "small", Width: 100, Height: 100},
+	})
+	if err != nil {
+		t.Fatalf("GenerateThumbnails returned error: %v", err)
+	}
+
+	// A smaller clip that images cannot decode must not be picked
+	clip := filepath.Join(tmp, "thumb_small_preview.mp4")
+	if err := os.WriteFile(clip, []byte("not an image"), 0o644); err != nil {
+		t.Fatalf("write clip: %v", err)
+	}
+	thumbs = append(thumbs, ThumbnailOutput{Name: "small_preview", Path: clip, Width: 10, Height: 5, Variant: VariantPreview})
+
+	if _, err := ComputePlaceholders(thumbs); err != nil {
+		t.Errorf("ComputePlaceholders returned error: %v", err)
+	}
+}
+
+func TestVideoGeneratorPreview(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not installed")
+	}
+	sample := "../../scripts/test-samples/sample.mp4"
+	if _, err := os.Stat(sample); err != nil {
+		t.Skipf("sample file not found: %s", sample)
+	}
+
+	results, err := NewVideoGenerator().Generate(context.Background(), sample, filepath.Join(t.TempDir(), "thumb.jpg"), []ThumbnailSpec{
+		{Name: "card", Width: 320, Height: 320, Preview: true, PreviewDuration: 2 * time.Second},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected poster and preview, got %d outputs", len(results))
+	}
+
+	preview := results[1]
+	if preview.Name != "card_preview" || preview.Variant != VariantPreview || preview.Format != FormatMP4 {
+		t.Errorf("got name=%q variant=%q format=%q", preview.Name, preview.Variant, preview.Format)
+	}
+	if preview.Width%2 != 0 || preview.Height%2 != 0 || preview.Width > 320 || preview.Height > 320 {
+		t.Errorf("got %dx%d, want even dimensions inside 320x320", preview.Width, preview.Height)
+	}
+	if info, err := os.Stat(preview.Path); err != nil || info.Size() == 0 {
+		t.Errorf("preview clip missing or empty: %v", err)
+	}
+}
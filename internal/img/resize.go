@@ -0,0 +1,161 @@
+package img
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+)
+
+// ResizeMode controls how a source image is mapped onto a thumbnail box.
+type ResizeMode string
+
+const (
+	// ResizeFit scales the image to fit inside the box, preserving aspect ratio.
+	// The result may be smaller than the box on one axis. This is the default.
+	ResizeFit ResizeMode = "fit"
+	// ResizeFill scales the image to cover the box and crops the overflow,
+	// producing exactly Width x Height pixels.
+	ResizeFill ResizeMode = "fill"
+	// ResizeStretch scales the image to exactly Width x Height, ignoring aspect ratio.
+	ResizeStretch ResizeMode = "stretch"
+	// ResizePad fits the image inside the box and letterboxes it with the
+	// spec's background colour, producing exactly Width x Height pixels.
+	ResizePad ResizeMode = "pad"
+)
+
+// Anchor selects which part of the image is kept when ResizeFill crops.
+type Anchor string
+
+const (
+	AnchorCenter      Anchor = "center"
+	AnchorTop         Anchor = "top"
+	AnchorBottom      Anchor = "bottom"
+	AnchorLeft        Anchor = "left"
+	AnchorRight       Anchor = "right"
+	AnchorTopLeft     Anchor = "top-left"
+	AnchorTopRight    Anchor = "top-right"
+	AnchorBottomLeft  Anchor = "bottom-left"
+	AnchorBottomRight Anchor = "bottom-right"
+)
+
+// defaultBackground is used by ResizePad when the spec has no background colour.
+var defaultBackground = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+
+// ParseResizeMode converts a config or hint value into a ResizeMode.
+// An empty string yields ResizeFit.
+func ParseResizeMode(value string) (ResizeMode, error) {
+	switch mode := ResizeMode(value); mode {
+	case "":
+		return ResizeFit, nil
+	case ResizeFit, ResizeFill, ResizeStretch, ResizePad:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported resize mode %q (supported: fit, fill, stretch, pad)", value)
+	}
+}
+
+// ParseAnchor converts a config or hint value into an Anchor.
+// An empty string yields AnchorCenter.
+func ParseAnchor(value string) (Anchor, error) {
+	if value == "" {
+		return AnchorCenter, nil
+	}
+	anchor := Anchor(value)
+	if _, ok := anchorOffsets[anchor]; !ok && anchor != AnchorSmart {
+		return "", fmt.Errorf("unsupported anchor %q", value)
+	}
+	return anchor, nil
+}
+
+// anchorOffsets positions a crop window along each axis: 0 keeps the
+// left/top edge, 1 the right/bottom edge and 0.5 centres the window.
+var anchorOffsets = map[Anchor][2]float64{
+	AnchorCenter:      {0.5, 0.5},
+	AnchorTop:         {0.5, 0},
+	AnchorBottom:      {0.5, 1},
+	AnchorLeft:        {0, 0.5},
+	AnchorRight:       {1, 0.5},
+	AnchorTopLeft:     {0, 0},
+	AnchorTopRight:    {1, 0},
+	AnchorBottomLeft:  {0, 1},
+	AnchorBottomRight: {1, 1},
+}
+
+// ParseHexColor parses "#rgb", "#rrggbb" or "#rrggbbaa" (the leading '#' is optional).
+func ParseHexColor(value string) (color.NRGBA, error) {
+	s := value
+	if len(s) > 0 && s[0] == '#' {
+		s = s[1:]
+	}
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+	if len(s) != 8 {
+		return color.NRGBA{}, fmt.Errorf("invalid colour %q, expected #rrggbb", value)
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid colour %q: %w", value, err)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// resizeImage maps src onto the spec's box according to spec.Mode. For
+// ResizeFill it also returns the region of src that was kept; for every other
+// mode the returned rectangle is empty.
+func resizeImage(src image.Image, spec ThumbnailSpec) (*image.NRGBA, image.Rectangle) {
+	switch spec.Mode {
+	case ResizeFill:
+		crop := fillCropRect(src, spec)
+		thumb := imaging.Resize(imaging.Crop(src, crop), spec.Width, spec.Height, imaging.Lanczos)
+		return thumb, crop.Sub(src.Bounds().Min)
+
+	case ResizeStretch:
+		return imaging.Resize(src, spec.Width, spec.Height, imaging.Lanczos), image.Rectangle{}
+
+	case ResizePad:
+		bg := defaultBackground
+		if spec.Background != nil {
+			bg = color.NRGBAModel.Convert(spec.Background).(color.NRGBA)
+		}
+		fitted := imaging.Fit(src, spec.Width, spec.Height, imaging.Lanczos)
+		canvas := imaging.New(spec.Width, spec.Height, bg)
+		return imaging.PasteCenter(canvas, fitted), image.Rectangle{}
+
+	default:
+		return imaging.Fit(src, spec.Width, spec.Height, imaging.Lanczos), image.Rectangle{}
+	}
+}
+
+// fillCropRect picks the largest region of src with the spec's aspect ratio,
+// positioned by the spec's anchor.
+func fillCropRect(src image.Image, spec ThumbnailSpec) image.Rectangle {
+	if spec.Anchor == AnchorSmart {
+		return smartCropRect(src, spec.Width, spec.Height)
+	}
+
+	b := src.Bounds()
+	cropW, cropH := coverCropSize(b.Dx(), b.Dy(), spec.Width, spec.Height)
+	offset, ok := anchorOffsets[spec.Anchor]
+	if !ok {
+		offset = anchorOffsets[AnchorCenter]
+	}
+	x0 := b.Min.X + int(float64(b.Dx()-cropW)*offset[0])
+	y0 := b.Min.Y + int(float64(b.Dy()-cropH)*offset[1])
+	return image.Rect(x0, y0, x0+cropW, y0+cropH)
+}
+
+// resizeMode returns the spec's resize mode, defaulting to ResizeFit.
+func (s ThumbnailSpec) resizeMode() ResizeMode {
+	if s.Mode == "" {
+		return ResizeFit
+	}
+	return s.Mode
+}
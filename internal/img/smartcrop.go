@@ -0,0 +1,189 @@
+package img
+
+import (
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+// AnchorSmart asks ResizeFill to pick the crop window by content instead of a
+// fixed position. See smartCropRect.
+const AnchorSmart Anchor = "smart"
+
+const (
+	// smartCropAnalysisSize bounds the image the scorer works on. Scoring a
+	// downscaled copy keeps the cost independent of the source resolution.
+	smartCropAnalysisSize = 256
+
+	smartCropEdgeWeight       = 1.0
+	smartCropSkinWeight       = 1.8
+	smartCropSaturationWeight = 0.3
+	// smartCropCenterBias slightly favours central windows so that flat
+	// images (and ties) still crop around the middle.
+	smartCropCenterBias = 0.05
+)
+
+// skinColor is the normalised RGB direction of typical skin tones.
+var skinColor = [3]float64{0.78, 0.57, 0.44}
+
+// smartCropRect returns the region of src, with the aspect ratio of w x h and
+// the largest possible size, that scores highest on edge detail, skin tones
+// and saturation. The scorer is pure Go and runs on a downscaled copy of src.
+func smartCropRect(src image.Image, w, h int) image.Rectangle {
+	b := src.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+	if w <= 0 || h <= 0 || srcW == 0 || srcH == 0 {
+		return b
+	}
+
+	cropW, cropH := coverCropSize(srcW, srcH, w, h)
+	if cropW == srcW && cropH == srcH {
+		return b
+	}
+
+	work := imaging.Fit(src, smartCropAnalysisSize, smartCropAnalysisSize, imaging.Linear)
+	workW, workH := work.Bounds().Dx(), work.Bounds().Dy()
+	scale := float64(srcW) / float64(workW)
+
+	sat := scoreImage(work)
+
+	winW := clampInt(int(math.Round(float64(cropW)/scale)), 1, workW)
+	winH := clampInt(int(math.Round(float64(cropH)/scale)), 1, workH)
+	maxX, maxY := workW-winW, workH-winH
+
+	bestX, bestY := maxX/2, maxY/2
+	bestScore := math.Inf(-1)
+	for y := 0; y <= maxY; y++ {
+		for x := 0; x <= maxX; x++ {
+			score := sat.sum(x, y, x+winW, y+winH)
+			score *= 1 - smartCropCenterBias*centerDistance(x, maxX, y, maxY)
+			if score > bestScore {
+				bestScore, bestX, bestY = score, x, y
+			}
+		}
+	}
+
+	x0 := clampInt(int(math.Round(float64(bestX)*scale)), 0, srcW-cropW)
+	y0 := clampInt(int(math.Round(float64(bestY)*scale)), 0, srcH-cropH)
+	return image.Rect(b.Min.X+x0, b.Min.Y+y0, b.Min.X+x0+cropW, b.Min.Y+y0+cropH)
+}
+
+// coverCropSize returns the largest srcW x srcH sub-region with the aspect
+// ratio of w x h.
+func coverCropSize(srcW, srcH, w, h int) (int, int) {
+	if srcW*h > srcH*w {
+		return clampInt(int(math.Round(float64(srcH)*float64(w)/float64(h))), 1, srcW), srcH
+	}
+	return srcW, clampInt(int(math.Round(float64(srcW)*float64(h)/float64(w))), 1, srcH)
+}
+
+// summedArea is an integral image of per-pixel interest scores.
+type summedArea struct {
+	stride int
+	values []float64
+}
+
+func (s summedArea) sum(x0, y0, x1, y1 int) float64 {
+	at := func(x, y int) float64 { return s.values[y*s.stride+x] }
+	return at(x1, y1) - at(x0, y1) - at(x1, y0) + at(x0, y0)
+}
+
+// scoreImage scores every pixel of img and returns the integral image of the
+// scores, so any window can be summed in constant time.
+func scoreImage(img *image.NRGBA) summedArea {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	lum := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := img.PixOffset(x, y)
+			r, g, b := float64(img.Pix[i]), float64(img.Pix[i+1]), float64(img.Pix[i+2])
+			lum[y*w+x] = (0.2126*r + 0.7152*g + 0.0722*b) / 255
+		}
+	}
+
+	sat := summedArea{stride: w + 1, values: make([]float64, (w+1)*(h+1))}
+	for y := 0; y < h; y++ {
+		var row float64
+		for x := 0; x < w; x++ {
+			i := img.PixOffset(x, y)
+			r := float64(img.Pix[i]) / 255
+			g := float64(img.Pix[i+1]) / 255
+			b := float64(img.Pix[i+2]) / 255
+			l := lum[y*w+x]
+
+			score := smartCropEdgeWeight*edgeScore(lum, w, h, x, y) +
+				smartCropSkinWeight*skinScore(r, g, b, l) +
+				smartCropSaturationWeight*saturationScore(r, g, b, l)
+
+			row += score
+			sat.values[(y+1)*sat.stride+x+1] = sat.values[y*sat.stride+x+1] + row
+		}
+	}
+	return sat
+}
+
+// edgeScore is the magnitude of the 4-neighbour Laplacian at (x, y).
+func edgeScore(lum []float64, w, h, x, y int) float64 {
+	at := func(x, y int) float64 {
+		return lum[clampInt(y, 0, h-1)*w+clampInt(x, 0, w-1)]
+	}
+	return math.Abs(4*at(x, y) - at(x-1, y) - at(x+1, y) - at(x, y-1) - at(x, y+1))
+}
+
+// skinScore rates how close the pixel's chroma is to skinColor, ignoring
+// very dark and very bright pixels.
+func skinScore(r, g, b, l float64) float64 {
+	if l < 0.1 || l > 0.95 {
+		return 0
+	}
+	mag := math.Sqrt(r*r + g*g + b*b)
+	if mag == 0 {
+		return 0
+	}
+	dr := r/mag - skinColor[0]
+	dg := g/mag - skinColor[1]
+	db := b/mag - skinColor[2]
+	similarity := 1 - math.Sqrt(dr*dr+dg*dg+db*db)
+	if similarity < 0.8 {
+		return 0
+	}
+	return (similarity - 0.8) / 0.2
+}
+
+// saturationScore rates colourfulness in HSV terms, ignoring near-black and
+// near-white pixels where saturation is unstable.
+func saturationScore(r, g, b, l float64) float64 {
+	if l < 0.05 || l > 0.9 {
+		return 0
+	}
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	if max == 0 {
+		return 0
+	}
+	return (max - min) / max
+}
+
+// centerDistance returns how far (x, y) is from the middle of the
+// [0,maxX] x [0,maxY] range, normalised to [0, 1].
+func centerDistance(x, maxX, y, maxY int) float64 {
+	var dx, dy float64
+	if maxX > 0 {
+		dx = math.Abs(float64(x)/float64(maxX) - 0.5)
+	}
+	if maxY > 0 {
+		dy = math.Abs(float64(y)/float64(maxY) - 0.5)
+	}
+	return 2 * math.Max(dx, dy)
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,57 @@
+package img
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestSmartCropRectFollowsDetail(t *testing.T) {
+	// Flat grey canvas with a high-contrast checkerboard near the right edge.
+	src := image.NewNRGBA(image.Rect(0, 0, 600, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 600; x++ {
+			c := color.NRGBA{R: 128, G: 128, B: 128, A: 255}
+			if x >= 420 && x < 580 && (x/10+y/10)%2 == 0 {
+				c = color.NRGBA{R: 250, G: 30, B: 30, A: 255}
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+
+	crop := smartCropRect(src, 100, 100)
+	if crop.Dx() != 200 || crop.Dy() != 200 {
+		t.Fatalf("expected 200x200 crop, got %v", crop)
+	}
+	if crop.Min.X < 380 {
+		t.Fatalf("expected crop to move towards the detailed region, got %v", crop)
+	}
+}
+
+func TestSmartCropRectCentersFlatImage(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+	for i := range src.Pix {
+		src.Pix[i] = 200
+	}
+
+	crop := smartCropRect(src, 1, 1)
+	if crop != image.Rect(100, 0, 200, 100) {
+		t.Fatalf("expected centred crop, got %v", crop)
+	}
+}
+
+func TestResizeImageReportsFillCrop(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 400, 200))
+
+	thumb, crop := resizeImage(src, ThumbnailSpec{Width: 50, Height: 50, Mode: ResizeFill, Anchor: AnchorRight})
+	if thumb.Bounds().Dx() != 50 || thumb.Bounds().Dy() != 50 {
+		t.Fatalf("unexpected thumbnail size %v", thumb.Bounds())
+	}
+	if crop != image.Rect(200, 0, 400, 200) {
+		t.Fatalf("unexpected crop %v", crop)
+	}
+
+	if _, crop := resizeImage(src, ThumbnailSpec{Width: 50, Height: 50}); !crop.Empty() {
+		t.Fatalf("fit mode should not report a crop, got %v", crop)
+	}
+}
@@ -0,0 +1,177 @@
+package img
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"github.com/tendant/simple-thumbnailer/internal/converters"
+)
+
+// Variants of the storyboard outputs generated for specs that set Storyboard.
+const (
+	VariantStoryboard    = "storyboard"
+	VariantStoryboardVTT = "storyboard_vtt"
+)
+
+const (
+	// DefaultStoryboardFrames is used by the bare "storyboard" size option.
+	DefaultStoryboardFrames = 100
+	// DefaultStoryboardColumns is the sprite width in tiles when a spec does not set one.
+	DefaultStoryboardColumns = 10
+	// MaxStoryboardFrames caps the frames of a storyboard, whatever a spec asks for.
+	MaxStoryboardFrames = 400
+)
+
+// StoryboardLayout describes how frames are laid out in a storyboard sprite.
+// Frame i covers [i*Interval, (i+1)*Interval) of the video and sits at
+// column i%Columns, row i/Columns.
+type StoryboardLayout struct {
+	Frames     int
+	Columns    int
+	Rows       int
+	TileWidth  int
+	TileHeight int
+	Interval   time.Duration
+}
+
+// generateStoryboard tiles spec.Storyboard evenly spaced frames, at most
+// MaxStoryboardFrames, into a sprite in one FFmpeg pass and writes a WebVTT
+// file mapping each interval to its tile with a "#xywh=" media fragment. Cues reference the sprite by file name,
+// relative to the VTT file, until SetStoryboardSprite points them elsewhere.
+func (g *VideoGenerator) generateStoryboard(ctx context.Context, srcPath, baseDstPath string, spec ThumbnailSpec, info *converters.FileInfo) ([]ThumbnailOutput, error) {
+	name := spec.Name + "_" + VariantStoryboard
+	if info == nil || info.Width <= 0 || info.Height <= 0 || info.Duration <= 0 {
+		return nil, fmt.Errorf("generate %s: video dimensions or duration unknown", name)
+	}
+
+	frames := min(spec.Storyboard, MaxStoryboardFrames)
+	tileW, tileH := fitSize(info.Width, info.Height, spec.Width, spec.Height)
+	layout := StoryboardLayout{
+		Frames:     frames,
+		Columns:    min(spec.storyboardColumns(), frames),
+		TileWidth:  tileW,
+		TileHeight: tileH,
+		Interval:   time.Duration(info.Duration / float64(frames) * float64(time.Second)),
+	}
+	layout.Rows = (layout.Frames + layout.Columns - 1) / layout.Columns
+	if err := g.Limits.checkPixels(layout.Columns*tileW, layout.Rows*tileH); err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(baseDstPath), "storyboard-")
+	if err != nil {
+		return nil, fmt.Errorf("generate %s: %w", name, err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	tiledPath := filepath.Join(tmpDir, "sprite.png")
+	if err := g.converter.Storyboard(ctx, srcPath, tiledPath, converters.StoryboardOptions{
+		Frames:     layout.Frames,
+		Columns:    layout.Columns,
+		Rows:       layout.Rows,
+		TileWidth:  tileW,
+		TileHeight: tileH,
+		Interval:   layout.Interval.Seconds(),
+	}); err != nil {
+		return nil, fmt.Errorf("generate %s: %w", name, err)
+	}
+	sprite, err := imaging.Open(tiledPath)
+	if err != nil {
+		return nil, fmt.Errorf("generate %s: %w", name, err)
+	}
+
+	format := spec.outputFormat(FormatJPEG)
+	spritePath := thumbnailPath(baseDstPath, ThumbnailSpec{Name: name, Format: spec.Format}, FormatJPEG)
+	quality, err := saveImage(ctx, sprite, spritePath, spec)
+	if err != nil {
+		return nil, fmt.Errorf("save %s: %w", name, err)
+	}
+
+	vttPath := thumbnailPath(baseDstPath, ThumbnailSpec{Name: name}, FormatVTT)
+	if err := os.WriteFile(vttPath, []byte(storyboardVTT(layout, filepath.Base(spritePath))), 0o644); err != nil {
+		return nil, fmt.Errorf("save %s: %w", name, err)
+	}
+
+	duration := time.Duration(info.Duration * float64(time.Second))
+	return []ThumbnailOutput{
+		{
+			Name:         name,
+			Path:         spritePath,
+			Width:        sprite.Bounds().Dx(),
+			Height:       sprite.Bounds().Dy(),
+			SourceWidth:  info.Width,
+			SourceHeight: info.Height,
+			Mode:         ResizeFit,
+			Format:       format,
+			Quality:      quality,
+			Variant:      VariantStoryboard,
+			FrameCount:   layout.Frames,
+			Duration:     duration,
+			Storyboard:   &layout,
+		},
+		{
+			Name:         spec.Name + "_" + VariantStoryboardVTT,
+			Path:         vttPath,
+			SourceWidth:  info.Width,
+			SourceHeight: info.Height,
+			Format:       FormatVTT,
+			Variant:      VariantStoryboardVTT,
+			FrameCount:   layout.Frames,
+			Duration:     duration,
+			Storyboard:   &layout,
+		},
+	}, nil
+}
+
+func (s ThumbnailSpec) storyboardColumns() int {
+	if s.StoryboardColumns > 0 {
+		return s.StoryboardColumns
+	}
+	return DefaultStoryboardColumns
+}
+
+// storyboardVTT renders the WebVTT cues for layout, one per tile.
+func storyboardVTT(layout StoryboardLayout, spriteName string) string {
+	var sb strings.Builder
+	sb.WriteString("WEBVTT\n")
+	for i := 0; i < layout.Frames; i++ {
+		start := time.Duration(i) * layout.Interval
+		fmt.Fprintf(&sb, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+			vttTimestamp(start), vttTimestamp(start+layout.Interval), spriteName,
+			i%layout.Columns*layout.TileWidth, i/layout.Columns*layout.TileHeight,
+			layout.TileWidth, layout.TileHeight)
+	}
+	return sb.String()
+}
+
+// SetStoryboardSprite rewrites the cues of the WebVTT file at vttPath, which
+// reference the sprite by its generated file name spriteName, to reference it
+// as ref instead, such as the content ID it was uploaded as.
+func SetStoryboardSprite(vttPath, spriteName, ref string) error {
+	data, err := os.ReadFile(vttPath)
+	if err != nil {
+		return fmt.Errorf("read storyboard vtt: %w", err)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if fragment, ok := strings.CutPrefix(line, spriteName+"#xywh="); ok {
+			lines[i] = ref + "#xywh=" + fragment
+		}
+	}
+	if err := os.WriteFile(vttPath, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		return fmt.Errorf("write storyboard vtt: %w", err)
+	}
+	return nil
+}
+
+// vttTimestamp formats d as HH:MM:SS.mmm.
+func vttTimestamp(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3_600_000, ms/60_000%60, ms/1000%60, ms%1000)
+}
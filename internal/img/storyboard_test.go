@@ -0,0 +1,116 @@
+package img
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoryboardVTT(t *testing.T) {
+	layout := StoryboardLayout{Frames: 3, Columns: 2, Rows: 2, TileWidth: 160, TileHeight: 90, Interval: 61500 * time.Millisecond}
+
+	got := storyboardVTT(layout, "video_card_storyboard.jpg")
+	want := "WEBVTT\n" +
+		"\n00:00:00.000 --> 00:01:01.500\nvideo_card_storyboard.jpg#xywh=0,0,160,90\n" +
+		"\n00:01:01.500 --> 00:02:03.000\nvideo_card_storyboard.jpg#xywh=160,0,160,90\n" +
+		"\n00:02:03.000 --> 00:03:04.500\nvideo_card_storyboard.jpg#xywh=0,90,160,90\n"
+	if got != want {
+		t.Errorf("storyboardVTT =\n%s\nwant\n%s", got, want)
+	}
+
+	if ts := vttTimestamp(2*time.Hour + 3*time.Minute + 4*time.Second + 5*time.Millisecond); ts != "02:03:04.005" {
+		t.Errorf("vttTimestamp = %q, want 02:03:04.005", ts)
+	}
+}
+
+func TestSetStoryboardSprite(t *testing.T) {
+	layout := StoryboardLayout{Frames: 2, Columns: 2, Rows: 1, TileWidth: 160, TileHeight: 90, Interval: time.Second}
+	path := filepath.Join(t.TempDir(), "video_card_storyboard.vtt")
+	if err := os.WriteFile(path, []byte(storyboardVTT(layout, "video_card_storyboard.jpg")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetStoryboardSprite(path, "video_card_storyboard.jpg", "0b7e2c4a"); err != nil {
+		t.Fatalf("SetStoryboardSprite error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "WEBVTT\n" +
+		"\n00:00:00.000 --> 00:00:01.000\n0b7e2c4a#xywh=0,0,160,90\n" +
+		"\n00:00:01.000 --> 00:00:02.000\n0b7e2c4a#xywh=160,0,160,90\n"
+	if string(data) != want {
+		t.Errorf("rewritten vtt =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestApplySpecOptionStoryboard(t *testing.T) {
+	spec := ThumbnailSpec{Name: "scrub", Width: 160, Height: 90}
+	if err := ApplySpecOption(&spec, "storyboard"); err != nil {
+		t.Fatalf("ApplySpecOption(storyboard) returned error: %v", err)
+	}
+	if spec.Storyboard != DefaultStoryboardFrames || spec.storyboardColumns() != DefaultStoryboardColumns {
+		t.Errorf("got %d frames in %d columns, want defaults", spec.Storyboard, spec.storyboardColumns())
+	}
+
+	if err := ApplyHints(&spec, map[string]string{HintStoryboard: "48", HintStoryboardColumns: "8"}); err != nil {
+		t.Fatalf("ApplyHints returned error: %v", err)
+	}
+	if spec.Storyboard != 48 || spec.StoryboardColumns != 8 {
+		t.Errorf("got %d frames in %d columns, want 48 in 8", spec.Storyboard, spec.StoryboardColumns)
+	}
+
+	if err := ApplySpecOption(&spec, "storyboard=false"); err != nil || spec.Storyboard != 0 {
+		t.Errorf("storyboard=false: got %d frames, err %v", spec.Storyboard, err)
+	}
+	if err := ApplySpecOption(&spec, "storyboard=100000"); err != nil || spec.Storyboard != MaxStoryboardFrames {
+		t.Errorf("storyboard=100000: got %d frames, err %v, want %d", spec.Storyboard, err, MaxStoryboardFrames)
+	}
+	for _, opt := range []string{"storyboard=-3", "storyboard_columns=0"} {
+		if err := ApplySpecOption(&spec, opt); err == nil {
+			t.Errorf("ApplySpecOption(%q) expected error", opt)
+		}
+	}
+}
+
+func TestVideoGeneratorStoryboard(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not installed")
+	}
+	sample := "../../scripts/test-samples/sample.mp4"
+	if _, err := os.Stat(sample); err != nil {
+		t.Skipf("sample file not found: %s", sample)
+	}
+
+	results, err := NewVideoGenerator().Generate(context.Background(), sample, filepath.Join(t.TempDir(), "thumb.jpg"), []ThumbnailSpec{
+		{Name: "scrub", Width: 160, Height: 160, Storyboard: 6, StoryboardColumns: 4},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected poster, sprite and vtt, got %d outputs", len(results))
+	}
+
+	sprite, vtt := results[1], results[2]
+	layout := sprite.Storyboard
+	if sprite.Variant != VariantStoryboard || layout == nil || layout.Rows != 2 {
+		t.Fatalf("sprite: got variant=%q layout=%+v", sprite.Variant, layout)
+	}
+	if sprite.Width != 4*layout.TileWidth || sprite.Height != 2*layout.TileHeight {
+		t.Errorf("sprite is %dx%d, want 4x2 tiles of %dx%d", sprite.Width, sprite.Height, layout.TileWidth, layout.TileHeight)
+	}
+
+	data, err := os.ReadFile(vtt.Path)
+	if err != nil {
+		t.Fatalf("read vtt: %v", err)
+	}
+	if vtt.Variant != VariantStoryboardVTT || strings.Count(string(data), " --> ") != 6 {
+		t.Errorf("vtt: got variant=%q with %d cues, want 6", vtt.Variant, strings.Count(string(data), " --> "))
+	}
+}
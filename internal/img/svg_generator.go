@@ -0,0 +1,141 @@
+package img
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/tendant/simple-thumbnailer/internal/converters"
+)
+
+// SVGGenerator implements Generator for SVG images using librsvg.
+// Each size is rasterized at the resolution it needs instead of resampling
+// one bitmap, so small icons stay sharp at large sizes and vice versa.
+type SVGGenerator struct {
+	converter *converters.RSVGConverter
+
+	// Limits bounds the sources that will be processed. The zero value is unlimited.
+	Limits Limits
+}
+
+// NewSVGGenerator creates a new SVG thumbnail generator
+func NewSVGGenerator() *SVGGenerator {
+	return &SVGGenerator{
+		converter: converters.NewRSVGConverter(),
+		Limits:    DefaultLimits,
+	}
+}
+
+// svgRender identifies one rasterization of an SVG.
+type svgRender struct {
+	size       image.Point
+	background string
+}
+
+// Generate implements Generator.Generate for SVG images
+// Unlike bitmaps, SVGs are scaled up to fill their box. Documents with
+// external references are rejected with converters.ErrSVGUnsafe.
+func (g *SVGGenerator) Generate(ctx context.Context, srcPath string, baseDstPath string, specs []ThumbnailSpec) ([]ThumbnailOutput, error) {
+	if err := g.Limits.checkFileSize(srcPath); err != nil {
+		return nil, err
+	}
+
+	fileInfo, err := g.converter.Probe(ctx, srcPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(baseDstPath), 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir: %w", err)
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(baseDstPath), "svg-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	renders := make(map[svgRender]image.Image)
+	var sourceHash PerceptualHash
+	var palette Palette
+	var results []ThumbnailOutput
+	for _, spec := range specs {
+		key := svgRender{
+			size:       svgRenderSize(spec, fileInfo.Width, fileInfo.Height),
+			background: svgBackground(spec),
+		}
+		src, ok := renders[key]
+		if !ok {
+			if err := g.Limits.checkPixels(key.size.X, key.size.Y); err != nil {
+				return nil, err
+			}
+			renderPath := filepath.Join(tmpDir, fmt.Sprintf("%d.png", len(renders)))
+			if err := g.converter.Render(ctx, srcPath, renderPath, key.size.X, key.size.Y, key.background); err != nil {
+				return nil, fmt.Errorf("render %s: %w", spec.Name, err)
+			}
+			src, err = openImage(renderPath, Limits{MaxPixels: g.Limits.MaxPixels})
+			if err != nil {
+				return nil, fmt.Errorf("open render: %w", err)
+			}
+			renders[key] = src
+			// Analyse the first rendering; every output carries the same values
+			if len(renders) == 1 {
+				sourceHash = ComputePerceptualHash(src)
+				palette = ComputePalette(src)
+			}
+		}
+
+		output, err := pageThumbnail(ctx, src, baseDstPath, spec, spec.Name)
+		if err != nil {
+			return nil, err
+		}
+		output.SourceWidth = fileInfo.Width
+		output.SourceHeight = fileInfo.Height
+		output.SourceHash = sourceHash
+		output.Palette = palette
+		results = append(results, output)
+	}
+	return results, nil
+}
+
+// svgRenderSize returns the box to rasterize an SVG of the given intrinsic
+// size in for spec: scaled to fit the spec's box for fit and pad, or to cover
+// it for fill and stretch, which then crop or squeeze it like a bitmap. An
+// unknown size renders to fit the box.
+func svgRenderSize(spec ThumbnailSpec, width, height int) image.Point {
+	if width <= 0 || height <= 0 {
+		return image.Pt(spec.Width, spec.Height)
+	}
+	w, h := float64(width), float64(height)
+	sx, sy := float64(spec.Width)/w, float64(spec.Height)/h
+	scale := math.Min(sx, sy)
+	if mode := spec.resizeMode(); mode == ResizeFill || mode == ResizeStretch {
+		scale = math.Max(sx, sy)
+	}
+	return image.Pt(max(int(math.Round(w*scale)), 1), max(int(math.Round(h*scale)), 1))
+}
+
+// svgBackground returns the colour to render behind a transparent SVG: none,
+// unless the output format cannot store transparency.
+func svgBackground(spec ThumbnailSpec) string {
+	if spec.outputFormat(FormatPNG) != FormatJPEG {
+		return ""
+	}
+	bg := defaultBackground
+	if spec.Background != nil {
+		bg = color.NRGBAModel.Convert(spec.Background).(color.NRGBA)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", bg.R, bg.G, bg.B)
+}
+
+// Supports implements Generator.Supports for SVG images
+func (g *SVGGenerator) Supports(mimeType string) bool {
+	return g.converter.Supports(mimeType)
+}
+
+// Name implements Generator.Name
+func (g *SVGGenerator) Name() string {
+	return "svg"
+}
@@ -0,0 +1,74 @@
+package img
+
+import (
+	"context"
+	"errors"
+	"image"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/tendant/simple-thumbnailer/internal/converters"
+)
+
+func TestSVGRenderSize(t *testing.T) {
+	tests := []struct {
+		spec          ThumbnailSpec
+		width, height int
+		want          image.Point
+	}{
+		// Small icons are scaled up to the box
+		{ThumbnailSpec{Width: 256, Height: 256}, 24, 12, image.Pt(256, 128)},
+		{ThumbnailSpec{Width: 256, Height: 256, Mode: ResizeFill}, 24, 12, image.Pt(512, 256)},
+		{ThumbnailSpec{Width: 100, Height: 100, Mode: ResizePad}, 1000, 2000, image.Pt(50, 100)},
+		{ThumbnailSpec{Width: 100, Height: 50}, 0, 0, image.Pt(100, 50)},
+	}
+	for _, tt := range tests {
+		if got := svgRenderSize(tt.spec, tt.width, tt.height); got != tt.want {
+			t.Errorf("svgRenderSize(%+v, %d, %d) = %v, want %v", tt.spec, tt.width, tt.height, got, tt.want)
+		}
+	}
+
+	if got := svgBackground(ThumbnailSpec{}); got != "" {
+		t.Errorf("PNG background = %q, want transparent", got)
+	}
+	if got := svgBackground(ThumbnailSpec{Format: FormatJPEG}); got != "#ffffff" {
+		t.Errorf("JPEG background = %q, want white", got)
+	}
+}
+
+func TestSVGGeneratorGenerate(t *testing.T) {
+	if _, err := exec.LookPath("rsvg-convert"); err != nil {
+		t.Skip("rsvg-convert not installed")
+	}
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "icon.svg")
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 12"><rect width="24" height="12" fill="#c00"/></svg>`
+	if err := os.WriteFile(src, []byte(svg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := NewSVGGenerator().Generate(context.Background(), src, filepath.Join(tmp, "thumb.png"), []ThumbnailSpec{
+		{Name: "large", Width: 256, Height: 256},
+		{Name: "square", Width: 64, Height: 64, Mode: ResizeFill, Format: FormatJPEG},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if r := results[0]; r.Width != 256 || r.Height != 128 || r.SourceWidth != 24 || r.SourceHeight != 12 {
+		t.Errorf("large: got %dx%d from %dx%d", r.Width, r.Height, r.SourceWidth, r.SourceHeight)
+	}
+	if r := results[1]; r.Width != 64 || r.Height != 64 {
+		t.Errorf("square: got %dx%d", r.Width, r.Height)
+	}
+
+	unsafe := filepath.Join(tmp, "unsafe.svg")
+	if err := os.WriteFile(unsafe, []byte(`<svg width="10" height="10"><image href="file:///etc/hosts"/></svg>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewSVGGenerator().Generate(context.Background(), unsafe, filepath.Join(tmp, "unsafe.png"), []ThumbnailSpec{{Name: "small", Width: 64, Height: 64}})
+	if !errors.Is(err, converters.ErrSVGUnsafe) {
+		t.Errorf("unsafe svg: got %v, want ErrSVGUnsafe", err)
+	}
+}
@@ -0,0 +1,469 @@
+package img
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/disintegration/imaging"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/inconsolata"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// textColumns is the width of a text page in characters. Prose wraps at
+	// it; code and data are clipped.
+	textColumns = 80
+	// textMargin is the blank border around a text page, in pixels.
+	textMargin = 24
+	// textTabWidth is the number of columns between tab stops.
+	textTabWidth = 4
+	// textMaxBytes caps how much of a text file is read; a page holds far
+	// less, even when a minified file puts everything on one line.
+	textMaxBytes = 64 << 10
+)
+
+// Text pages are typeset in Inconsolata 8x16 on an A4-shaped canvas
+var (
+	textFace     = inconsolata.Regular8x16
+	textBoldFace = inconsolata.Bold8x16
+
+	textPageWidth  = 2*textMargin + textColumns*textFace.Advance
+	textPageHeight = textPageWidth * 297 / 210
+	textRows       = (textPageHeight - 2*textMargin) / textFace.Height
+)
+
+// Colours of a text page, after GitHub's light theme
+var (
+	textColor        = color.NRGBA{R: 0x24, G: 0x29, B: 0x2f, A: 0xff}
+	textKeywordColor = color.NRGBA{R: 0xcf, G: 0x22, B: 0x2e, A: 0xff}
+	textStringColor  = color.NRGBA{R: 0x0a, G: 0x30, B: 0x69, A: 0xff}
+	textNumberColor  = color.NRGBA{R: 0x05, G: 0x50, B: 0xae, A: 0xff}
+	textCommentColor = color.NRGBA{R: 0x6e, G: 0x77, B: 0x81, A: 0xff}
+	textCodeBlock    = color.NRGBA{R: 0xf6, G: 0xf8, B: 0xfa, A: 0xff}
+)
+
+// textLanguage describes how to colour a kind of source file. A nil
+// language is plain text.
+type textLanguage struct {
+	markdown     bool
+	lineComments []string
+	blockComment [2]string
+	quotes       string
+	keywords     map[string]bool
+}
+
+var (
+	goLanguage = &textLanguage{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+		keywords: words(`break case chan const continue default defer else fallthrough for func go goto if
+			import interface map package range return select struct switch type var true false nil`),
+	}
+	pythonLanguage = &textLanguage{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keywords: words(`and as assert async await break class continue def del elif else except False finally
+			for from global if import in is lambda None nonlocal not or pass raise return True try while with yield`),
+	}
+	javascriptLanguage = &textLanguage{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       "\"'`",
+		keywords: words(`async await break case catch class const continue debugger default delete do else export
+			extends false finally for function if import in instanceof interface let new null return super switch
+			this throw true try type typeof undefined var void while yield`),
+	}
+	cLanguage = &textLanguage{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+		keywords: words(`bool break case char class const continue default do double else enum extends extern
+			false final float for if implements import include int long namespace new null package private
+			protected public return short signed sizeof static struct switch this throw true try typedef
+			unsigned using void volatile while`),
+	}
+	rustLanguage = &textLanguage{
+		lineComments: []string{"//"},
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"`,
+		keywords: words(`as break const continue crate else enum extern false fn for if impl in let loop match
+			mod move mut pub ref return self Self static struct super trait true type unsafe use where while`),
+	}
+	shellLanguage = &textLanguage{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keywords:     words(`case do done elif else esac export fi for function if in local return then until while`),
+	}
+	cssLanguage = &textLanguage{
+		blockComment: [2]string{"/*", "*/"},
+		quotes:       `"'`,
+	}
+	dataLanguage = &textLanguage{
+		lineComments: []string{"#"},
+		quotes:       `"'`,
+		keywords:     words(`true false null`),
+	}
+	jsonLanguage = &textLanguage{
+		quotes:   `"`,
+		keywords: words(`true false null`),
+	}
+	markupLanguage = &textLanguage{
+		blockComment: [2]string{"<!--", "-->"},
+		quotes:       `"'`,
+	}
+	markdownLanguage = &textLanguage{markdown: true}
+)
+
+// textLanguages maps MIME types to how their text is coloured. Types mapped
+// to nil, and text/* types that are not listed, are plain text.
+var textLanguages = map[string]*textLanguage{
+	"text/markdown":            markdownLanguage,
+	"text/x-markdown":          markdownLanguage,
+	"text/x-go":                goLanguage,
+	"text/x-python":            pythonLanguage,
+	"text/x-script.python":     pythonLanguage,
+	"application/x-python":     pythonLanguage,
+	"text/javascript":          javascriptLanguage,
+	"application/javascript":   javascriptLanguage,
+	"application/x-javascript": javascriptLanguage,
+	"application/typescript":   javascriptLanguage,
+	"text/x-typescript":        javascriptLanguage,
+	"text/x-c":                 cLanguage,
+	"text/x-csrc":              cLanguage,
+	"text/x-chdr":              cLanguage,
+	"text/x-c++src":            cLanguage,
+	"text/x-c++hdr":            cLanguage,
+	"text/x-java":              cLanguage,
+	"text/x-java-source":       cLanguage,
+	"text/x-csharp":            cLanguage,
+	"text/x-kotlin":            cLanguage,
+	"text/x-rust":              rustLanguage,
+	"text/x-sh":                shellLanguage,
+	"text/x-shellscript":       shellLanguage,
+	"application/x-sh":         shellLanguage,
+	"text/css":                 cssLanguage,
+	"application/json":         jsonLanguage,
+	"text/json":                jsonLanguage,
+	"application/ld+json":      jsonLanguage,
+	"application/yaml":         dataLanguage,
+	"application/x-yaml":       dataLanguage,
+	"text/yaml":                dataLanguage,
+	"text/x-yaml":              dataLanguage,
+	"application/toml":         dataLanguage,
+	"text/x-toml":              dataLanguage,
+	"application/xml":          markupLanguage,
+	"text/xml":                 markupLanguage,
+	"text/html":                markupLanguage,
+	"application/x-httpd-php":  cLanguage,
+	"application/x-ndjson":     jsonLanguage,
+	"application/sql":          nil,
+	"application/x-subrip":     nil,
+}
+
+// words splits a space-separated keyword list into a set.
+func words(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+// isTextMimeType reports whether mimeType is rendered as text.
+func isTextMimeType(mimeType string) bool {
+	_, listed := textLanguages[mimeType]
+	return listed || strings.HasPrefix(mimeType, "text/")
+}
+
+// textLine is one row of a text page. colors, when set, holds a colour per rune.
+type textLine struct {
+	runes  []rune
+	colors []color.NRGBA
+	bold   bool
+	// scale is 2 for rows drawn at double size, which take two rows
+	scale int
+	// band draws the code block background behind the row
+	band bool
+}
+
+// layoutText splits content into the rows that fit on a page. Plain text and
+// Markdown wrap at textColumns; code is clipped and coloured by lang.
+func layoutText(content []byte, lang *textLanguage) []textLine {
+	var lines []textLine
+	rows := 0
+	add := func(line textLine) bool {
+		if line.scale == 0 {
+			line.scale = 1
+		}
+		if rows+line.scale > textRows {
+			return false
+		}
+		lines = append(lines, line)
+		rows += line.scale
+		return true
+	}
+
+	inBlockComment, inFence := false, false
+	for _, raw := range strings.Split(string(content), "\n") {
+		runes := expandTabs(strings.TrimRight(raw, "\r"))
+
+		switch {
+		case lang == nil:
+			for _, row := range wrapRunes(runes, textColumns) {
+				if !add(textLine{runes: row}) {
+					return lines
+				}
+			}
+
+		case lang.markdown:
+			if strings.HasPrefix(strings.TrimSpace(string(runes)), "```") {
+				inFence = !inFence
+				continue
+			}
+			line, width := textLine{}, textColumns
+			if !inFence {
+				if level, title := markdownHeading(runes); level > 0 {
+					runes, line.bold = title, true
+					if level == 1 {
+						line.scale, width = 2, textColumns/2
+					}
+				}
+			}
+			line.band = inFence
+			if inFence {
+				line.runes = clipRunes(runes, width)
+				if !add(line) {
+					return lines
+				}
+				continue
+			}
+			for _, row := range wrapRunes(runes, width) {
+				line.runes = row
+				if !add(line) {
+					return lines
+				}
+			}
+
+		default:
+			runes = clipRunes(runes, textColumns)
+			var colors []color.NRGBA
+			colors, inBlockComment = highlight(runes, lang, inBlockComment)
+			if !add(textLine{runes: runes, colors: colors}) {
+				return lines
+			}
+		}
+	}
+	return lines
+}
+
+// expandTabs replaces tabs with spaces up to the next tab stop and control
+// characters with spaces.
+func expandTabs(s string) []rune {
+	runes := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			for n := textTabWidth - len(runes)%textTabWidth; n > 0; n-- {
+				runes = append(runes, ' ')
+			}
+		case unicode.IsControl(r):
+			runes = append(runes, ' ')
+		default:
+			runes = append(runes, r)
+		}
+	}
+	return runes
+}
+
+// wrapRunes breaks a line into rows of at most width runes, after the last
+// space when there is one. An empty line is one empty row.
+func wrapRunes(runes []rune, width int) [][]rune {
+	var rows [][]rune
+	for len(runes) > width {
+		cut := width
+		for i := width; i > 0; i-- {
+			if runes[i-1] == ' ' {
+				cut = i
+				break
+			}
+		}
+		rows = append(rows, runes[:cut])
+		runes = runes[cut:]
+	}
+	return append(rows, runes)
+}
+
+func clipRunes(runes []rune, width int) []rune {
+	return runes[:min(len(runes), width)]
+}
+
+// markdownHeading returns the level and title of an ATX heading ("# Title"),
+// or zero when the line is not one.
+func markdownHeading(runes []rune) (int, []rune) {
+	level := 0
+	for level < len(runes) && runes[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(runes) && runes[level] != ' ') {
+		return 0, nil
+	}
+	return level, []rune(strings.TrimSpace(string(runes[level:])))
+}
+
+// highlight colours one line of code. It reports whether the line ends
+// inside a block comment, which the next line continues.
+func highlight(runes []rune, lang *textLanguage, inBlockComment bool) ([]color.NRGBA, bool) {
+	colors := make([]color.NRGBA, len(runes))
+	paint := func(from, to int, c color.NRGBA) {
+		for i := from; i < to; i++ {
+			colors[i] = c
+		}
+	}
+
+	for i := 0; i < len(runes); {
+		if inBlockComment {
+			end := indexRunes(runes, i, lang.blockComment[1])
+			if end < 0 {
+				paint(i, len(runes), textCommentColor)
+				return colors, true
+			}
+			end += utf8.RuneCountInString(lang.blockComment[1])
+			paint(i, end, textCommentColor)
+			i, inBlockComment = end, false
+			continue
+		}
+
+		r := runes[i]
+		switch {
+		case lang.blockComment[0] != "" && hasPrefixAt(runes, i, lang.blockComment[0]):
+			end := i + utf8.RuneCountInString(lang.blockComment[0])
+			paint(i, end, textCommentColor)
+			i, inBlockComment = end, true
+		case hasAnyPrefixAt(runes, i, lang.lineComments):
+			paint(i, len(runes), textCommentColor)
+			return colors, false
+		case strings.ContainsRune(lang.quotes, r):
+			// Strings end at the closing quote or the end of the line
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(runes))
+			paint(i, end, textStringColor)
+			i = end
+		case unicode.IsDigit(r):
+			end := i
+			for end < len(runes) && isWordRune(runes[end]) {
+				end++
+			}
+			paint(i, end, textNumberColor)
+			i = end
+		case isWordRune(r):
+			end := i
+			for end < len(runes) && isWordRune(runes[end]) {
+				end++
+			}
+			c := textColor
+			if lang.keywords[string(runes[i:end])] {
+				c = textKeywordColor
+			}
+			paint(i, end, c)
+			i = end
+		default:
+			colors[i] = textColor
+			i++
+		}
+	}
+	return colors, inBlockComment
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func hasPrefixAt(runes []rune, i int, prefix string) bool {
+	for _, p := range prefix {
+		if i >= len(runes) || runes[i] != p {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+func hasAnyPrefixAt(runes []rune, i int, prefixes []string) bool {
+	for _, p := range prefixes {
+		if hasPrefixAt(runes, i, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexRunes returns the index of the first s in runes at or after from, or -1.
+func indexRunes(runes []rune, from int, s string) int {
+	for i := from; i < len(runes); i++ {
+		if hasPrefixAt(runes, i, s) {
+			return i
+		}
+	}
+	return -1
+}
+
+// renderTextPage typesets lines on a white page of textPageWidth x
+// textPageHeight pixels. Each rune takes one cell, so columns line up even
+// for runes the font lacks, which are drawn as its replacement glyph.
+func renderTextPage(lines []textLine) *image.NRGBA {
+	page := imaging.New(textPageWidth, textPageHeight, color.White)
+	y := textMargin
+	for _, line := range lines {
+		height := textFace.Height * line.scale
+		if line.band {
+			band := image.Rect(textMargin/2, y, textPageWidth-textMargin/2, y+height)
+			draw.Draw(page, band, image.NewUniform(textCodeBlock), image.Point{}, draw.Src)
+		}
+
+		face := textFace
+		if line.bold {
+			face = textBoldFace
+		}
+		if line.scale == 1 {
+			drawRunes(page, image.Pt(textMargin, y), line, face)
+		} else {
+			// Draw at 1x and scale up; the bitmap font has a single size
+			row := image.NewNRGBA(image.Rect(0, 0, len(line.runes)*face.Advance, face.Height))
+			drawRunes(row, image.Point{}, line, face)
+			big := imaging.Resize(row, row.Bounds().Dx()*line.scale, 0, imaging.NearestNeighbor)
+			draw.Draw(page, big.Bounds().Add(image.Pt(textMargin, y)), big, image.Point{}, draw.Over)
+		}
+		y += height
+	}
+	return page
+}
+
+// drawRunes draws a row of text with its top-left corner at origin.
+func drawRunes(dst draw.Image, origin image.Point, line textLine, face *basicfont.Face) {
+	d := font.Drawer{Dst: dst, Face: face}
+	for i, r := range line.runes {
+		if r == ' ' {
+			continue
+		}
+		c := textColor
+		if line.colors != nil {
+			c = line.colors[i]
+		}
+		d.Src = image.NewUniform(c)
+		d.Dot = fixed.P(origin.X+i*face.Advance, origin.Y+face.Ascent)
+		d.DrawString(string(r))
+	}
+}
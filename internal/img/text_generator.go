@@ -0,0 +1,97 @@
+package img
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrBinaryContent is returned when a file sent as text holds binary data.
+// Retrying will not help.
+var ErrBinaryContent = errors.New("file is not text")
+
+// TextGenerator implements Generator for plain text, Markdown, JSON and
+// source code. It typesets the start of the file onto a page in Go, colouring
+// code by the language its MIME type names, and resizes the page like an
+// image.
+type TextGenerator struct {
+	mimeType string
+
+	// Limits bounds the sources that will be processed. The zero value is unlimited.
+	Limits Limits
+}
+
+// NewTextGenerator creates a new text thumbnail generator for files of the
+// given MIME type, which selects the syntax colouring.
+func NewTextGenerator(mimeType string) *TextGenerator {
+	return &TextGenerator{
+		mimeType: baseMimeType(mimeType),
+		Limits:   DefaultLimits,
+	}
+}
+
+// Generate implements Generator.Generate for text
+// Only the first textMaxBytes of the file are read.
+func (g *TextGenerator) Generate(ctx context.Context, srcPath string, baseDstPath string, specs []ThumbnailSpec) ([]ThumbnailOutput, error) {
+	if err := g.Limits.checkFileSize(srcPath); err != nil {
+		return nil, err
+	}
+
+	content, err := readTextHead(srcPath)
+	if err != nil {
+		return nil, err
+	}
+	page := renderTextPage(layoutText(content, textLanguages[g.mimeType]))
+	sourceHash := ComputePerceptualHash(page)
+	palette := ComputePalette(page)
+
+	var results []ThumbnailOutput
+	for _, spec := range specs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		output, err := pageThumbnail(ctx, page, baseDstPath, spec, spec.Name)
+		if err != nil {
+			return nil, err
+		}
+		output.SourceWidth = textPageWidth
+		output.SourceHeight = textPageHeight
+		output.SourceHash = sourceHash
+		output.Palette = palette
+		results = append(results, output)
+	}
+	return results, nil
+}
+
+// readTextHead reads the start of a text file, rejecting binary data.
+func readTextHead(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(io.LimitReader(f, textMaxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	// UTF-16 and binary formats are full of NULs; UTF-8 text never has any
+	if bytes.IndexByte(content, 0) >= 0 {
+		return nil, ErrBinaryContent
+	}
+	// Strip a UTF-8 byte order mark
+	return bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")), nil
+}
+
+// Supports implements Generator.Supports for text
+func (g *TextGenerator) Supports(mimeType string) bool {
+	return isTextMimeType(baseMimeType(mimeType))
+}
+
+// Name implements Generator.Name
+func (g *TextGenerator) Name() string {
+	return "text"
+}
@@ -0,0 +1,130 @@
+package img
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLayoutTextWrapsPlainText(t *testing.T) {
+	long := strings.Repeat("word ", 30) // 150 columns
+	lines := layoutText([]byte("short\n\tindented\n"+long), nil)
+
+	if len(lines) != 4 {
+		t.Fatalf("got %d rows, want 4", len(lines))
+	}
+	if got := string(lines[1].runes); got != "    indented" {
+		t.Errorf("tab expanded to %q", got)
+	}
+	if got := len(lines[2].runes); got > textColumns {
+		t.Errorf("wrapped row has %d columns, want at most %d", got, textColumns)
+	}
+	if !strings.HasSuffix(string(lines[2].runes), " ") {
+		t.Errorf("row %q should break after a space", string(lines[2].runes))
+	}
+
+	// A page holds textRows rows however long the file is
+	many := strings.Repeat("line\n", 500)
+	if got := len(layoutText([]byte(many), nil)); got != textRows {
+		t.Errorf("got %d rows, want a full page of %d", got, textRows)
+	}
+}
+
+func TestLayoutTextMarkdown(t *testing.T) {
+	md := "# Title\n## Section\nbody\n```\n# not a heading\n```\n#hashtag"
+	lines := layoutText([]byte(md), markdownLanguage)
+
+	if len(lines) != 5 {
+		t.Fatalf("got %d rows, want 5 (fences are not drawn)", len(lines))
+	}
+	if l := lines[0]; string(l.runes) != "Title" || !l.bold || l.scale != 2 {
+		t.Errorf("h1 = %q bold=%v scale=%d", string(l.runes), l.bold, l.scale)
+	}
+	if l := lines[1]; string(l.runes) != "Section" || !l.bold || l.scale != 1 {
+		t.Errorf("h2 = %q bold=%v scale=%d", string(l.runes), l.bold, l.scale)
+	}
+	if l := lines[3]; string(l.runes) != "# not a heading" || l.bold || !l.band {
+		t.Errorf("fenced line = %q bold=%v band=%v", string(l.runes), l.bold, l.band)
+	}
+	if l := lines[4]; l.bold {
+		t.Error("#hashtag should not be a heading")
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	line := []rune(`x := "a" // note`)
+	colors, open := highlight(line, goLanguage, false)
+	if open {
+		t.Error("line comment should not continue")
+	}
+	if colors[0] != textColor || colors[5] != textStringColor || colors[9] != textCommentColor {
+		t.Errorf("got %v", colors)
+	}
+
+	colors, _ = highlight([]rune("return 42"), goLanguage, false)
+	if colors[0] != textKeywordColor || colors[7] != textNumberColor {
+		t.Errorf("got %v", colors)
+	}
+
+	// Block comments carry over to the next line
+	if _, open := highlight([]rune("a /* b"), goLanguage, false); !open {
+		t.Error("unterminated block comment should continue")
+	}
+	colors, open = highlight([]rune("b */ c"), goLanguage, true)
+	if open || colors[0] != textCommentColor || colors[5] != textColor {
+		t.Errorf("closing block comment: open=%v colors=%v", open, colors)
+	}
+}
+
+func TestTextGeneratorGenerate(t *testing.T) {
+	tmp := t.TempDir()
+	src := filepath.Join(tmp, "main.go")
+	if err := os.WriteFile(src, []byte("package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	gen, err := GetGenerator("text/x-go")
+	if err != nil {
+		t.Fatalf("GetGenerator returned error: %v", err)
+	}
+	results, err := gen.Generate(context.Background(), src, filepath.Join(tmp, "thumb.png"), []ThumbnailSpec{
+		{Name: "small", Width: 100, Height: 100},
+		{Name: "card", Width: 200, Height: 200, Mode: ResizeFill, Format: FormatJPEG},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 outputs, got %d", len(results))
+	}
+	for _, r := range results {
+		width, height, err := imageSize(r.Path)
+		if err != nil {
+			t.Errorf("%s: read dimensions: %v", r.Name, err)
+		} else if r.Width != width || r.Height != height {
+			t.Errorf("%s: reported %dx%d, file is %dx%d", r.Name, r.Width, r.Height, width, height)
+		}
+		if r.SourceWidth != textPageWidth || r.SourceHeight != textPageHeight || r.SourceHash.PHash == "" {
+			t.Errorf("%s: source %dx%d hash %+v", r.Name, r.SourceWidth, r.SourceHeight, r.SourceHash)
+		}
+	}
+	// The page is portrait, so fitting it in a square keeps the full height
+	if r := results[0]; r.Height != 100 || r.Width >= 100 {
+		t.Errorf("small: got %dx%d, want a portrait page 100 high", r.Width, r.Height)
+	}
+	if r := results[1]; r.Width != 200 || r.Height != 200 || r.Format != FormatJPEG {
+		t.Errorf("card: got %dx%d %s", r.Width, r.Height, r.Format)
+	}
+
+	binary := filepath.Join(tmp, "data.txt")
+	if err := os.WriteFile(binary, []byte("PK\x03\x04\x00\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewTextGenerator("text/plain").Generate(context.Background(), binary, filepath.Join(tmp, "bin.png"), []ThumbnailSpec{{Name: "small", Width: 100, Height: 100}})
+	if !errors.Is(err, ErrBinaryContent) {
+		t.Errorf("binary file: got %v, want ErrBinaryContent", err)
+	}
+}
@@ -2,9 +2,15 @@
 package img
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"image"
+	"image/color"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/disintegration/imaging"
 )
@@ -13,6 +19,69 @@ type ThumbnailSpec struct {
 	Name   string
 	Width  int
 	Height int
+
+	// Mode controls how the source is mapped onto the Width x Height box.
+	// The zero value behaves like ResizeFit.
+	Mode ResizeMode
+	// Anchor selects the region kept by ResizeFill. Defaults to AnchorCenter;
+	// AnchorSmart picks the region by content.
+	Anchor Anchor
+	// Background is the letterbox colour for ResizePad. Defaults to white.
+	Background color.Color
+
+	// Format selects the encoder. When empty, generators keep their default
+	// (the source extension for images, JPEG for video, PNG for PDF).
+	Format OutputFormat
+	// Quality is the lossy encoder quality (1-100). Zero uses the format's
+	// default (DefaultJPEGQuality or DefaultWebPQuality).
+	Quality int
+	// Progressive writes progressive JPEGs (requires jpegtran).
+	Progressive bool
+	// Lossless writes lossless WebP instead of lossy WebP.
+	Lossless bool
+
+	// Animated also writes an animated thumbnail, named Name+"_animated", for
+	// multi-frame GIF and WebP sources. The output named Name stays a static
+	// poster of the first frame. The animation is a GIF unless Format is
+	// FormatWebP.
+	Animated bool
+	// MaxFrames and MaxDuration cap the animated output. Zero keeps every frame.
+	MaxFrames   int
+	MaxDuration time.Duration
+
+	// Preview also writes a short looping clip, named Name+"_preview", for
+	// video sources. PreviewFormat defaults to FormatMP4, PreviewDuration to
+	// DefaultPreviewDuration and PreviewSegments to DefaultPreviewSegments.
+	Preview         bool
+	PreviewFormat   OutputFormat
+	PreviewDuration time.Duration
+	PreviewSegments int
+
+	// Storyboard, when positive, also writes a sprite of that many evenly
+	// spaced video frames, each fitting inside Width x Height, named
+	// Name+"_storyboard", and a WebVTT file mapping the timeline to its
+	// tiles, named Name+"_storyboard_vtt". StoryboardColumns defaults to
+	// DefaultStoryboardColumns.
+	Storyboard        int
+	StoryboardColumns int
+
+	// Page selects the 1-based page of a PDF source used for the thumbnail.
+	// Zero means the first page. Generate fails if the document is shorter.
+	Page int
+	// Pages, when above one, also writes each of the first Pages pages other
+	// than Page, named Name+"_"+PageVariant(n). ContactSheet, when positive,
+	// also writes a grid of the first ContactSheet pages inside the
+	// Width x Height box, named Name+"_contact_sheet"; ContactSheetColumns
+	// defaults to a square grid. Both are capped to the document's length.
+	Pages               int
+	ContactSheet        int
+	ContactSheetColumns int
+
+	// Seek selects the frame of a video source used for the thumbnail.
+	Seek SeekPosition
+	// BestFrame, when positive, scores that many candidate frames spread over
+	// a video source with ScoreFrame and uses the best one instead of Seek.
+	BestFrame int
 }
 
 type ThumbnailOutput struct {
@@ -22,13 +91,46 @@ type ThumbnailOutput struct {
 	Height       int
 	SourceWidth  int
 	SourceHeight int
+	Mode         ResizeMode
+	// Crop is the region of the source kept by ResizeFill, in source pixel
+	// coordinates. It is empty for modes that do not crop.
+	Crop image.Rectangle
+	// Format and Quality describe how the file at Path was encoded.
+	// Quality is zero for lossless formats or when the encoder default was used.
+	Format  OutputFormat
+	Quality int
+	// SourceHash is the perceptual hash of the source image, video frame or
+	// PDF page. It is the same on every output of one Generate call and empty
+	// if it could not be computed.
+	SourceHash PerceptualHash
+	// Palette holds the dominant colours of the same source image, frame or
+	// page, and is likewise shared by every output of one Generate call.
+	Palette Palette
+	// Variant is empty for the output named after its spec. Extra outputs
+	// are VariantAnimated (Animated specs), VariantPreview (Preview specs),
+	// VariantStoryboard and VariantStoryboardVTT (Storyboard specs),
+	// PageVariant(n) (Pages specs) or VariantContactSheet (ContactSheet specs).
+	Variant string
+	// Animated, FrameCount and Duration describe an animated output.
+	Animated   bool
+	FrameCount int
+	Duration   time.Duration
+	// FrameTime is the timestamp of the video frame used, when known.
+	FrameTime time.Duration
+	// Page is the 1-based PDF page shown, and zero for other sources and
+	// contact sheets. PageCount is the number of pages on a contact sheet.
+	Page      int
+	PageCount int
+	// Storyboard is the tile layout shared by a storyboard sprite and its
+	// WebVTT file, and nil for every other output.
+	Storyboard *StoryboardLayout
 }
 
 // GenerateThumbnail loads an image from srcPath, creates a thumbnail with the
 // given bounding box, and writes it to dstPath. If the source is smaller than
 // the box, it will not upscale.
 func GenerateThumbnail(srcPath, dstPath string, boxW, boxH int) (w int, h int, _ error) {
-	src, err := imaging.Open(srcPath, imaging.AutoOrientation(true))
+	src, err := openImage(srcPath, DefaultLimits, imaging.AutoOrientation(true))
 	if err != nil {
 		return 0, 0, fmt.Errorf("open: %w", err)
 	}
@@ -48,11 +150,58 @@ func GenerateThumbnail(srcPath, dstPath string, boxW, boxH int) (w int, h int, _
 	return b.Dx(), b.Dy(), nil
 }
 
-// GenerateThumbnails creates multiple thumbnail sizes from a source image
+// GenerateThumbnails creates multiple thumbnail sizes from a source image,
+// rejecting sources that exceed DefaultLimits.
 func GenerateThumbnails(srcPath, baseDstPath string, specs []ThumbnailSpec) ([]ThumbnailOutput, error) {
-	src, err := imaging.Open(srcPath, imaging.AutoOrientation(true))
-	if err != nil {
-		return nil, fmt.Errorf("open: %w", err)
+	return generateThumbnails(context.Background(), srcPath, baseDstPath, specs, DefaultLimits)
+}
+
+func generateThumbnails(ctx context.Context, srcPath, baseDstPath string, specs []ThumbnailSpec, limits Limits) ([]ThumbnailOutput, error) {
+	// Animated specs need their frames; the poster is then the first
+	// composited frame rather than the raw first frame. Animated WebPs can
+	// only be read frame by frame, so their poster is decoded that way even
+	// when no spec is animated. An animation too large for limits.MaxPixels
+	// only loses its animated outputs.
+	var src image.Image
+	var anim *animation
+	var err error
+	if isAnimatedWebP(srcPath) {
+		maxFrames := 1
+		if wantsAnimation(specs) {
+			maxFrames = animationFrames(specs)
+		}
+		anim, err = decodeAnimatedWebP(srcPath, limits, maxFrames)
+		if errors.Is(err, ErrLimitExceeded) && maxFrames != 1 {
+			if poster, posterErr := decodeAnimatedWebP(srcPath, limits, 1); posterErr == nil {
+				slog.Warn("skipping animated thumbnails", "path", srcPath, "err", err)
+				anim, err = poster, nil
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode animation: %w", err)
+		}
+		src = anim.frames[0]
+		if len(anim.frames) < 2 {
+			anim = nil
+		}
+	} else {
+		src, err = openImage(srcPath, limits, imaging.AutoOrientation(true))
+		if err != nil {
+			return nil, fmt.Errorf("open: %w", err)
+		}
+		if wantsAnimation(specs) && isGIF(srcPath) {
+			anim, err = decodeAnimation(srcPath, limits, animationFrames(specs))
+			if errors.Is(err, ErrLimitExceeded) {
+				slog.Warn("skipping animated thumbnails", "path", srcPath, "err", err)
+				anim, err = nil, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("decode animation: %w", err)
+			}
+			if anim != nil {
+				src = anim.frames[0]
+			}
+		}
 	}
 
 	// Get source dimensions
@@ -60,20 +209,31 @@ func GenerateThumbnails(srcPath, baseDstPath string, specs []ThumbnailSpec) ([]T
 	sourceWidth := srcBounds.Dx()
 	sourceHeight := srcBounds.Dy()
 
+	// Analyse the decoded source once; every output carries the same values
+	sourceHash := ComputePerceptualHash(src)
+	palette := ComputePalette(src)
+
+	// Keep the source's format unless a spec asks for another one
+	defaultFormat, ok := formatFromPath(baseDstPath)
+	if !ok {
+		defaultFormat = FormatPNG
+	}
+
 	var results []ThumbnailOutput
 
 	for _, spec := range specs {
-		thumb := imaging.Fit(src, spec.Width, spec.Height, imaging.Lanczos)
+		thumb, crop := resizeImage(src, spec)
 
-		dstPath := fmt.Sprintf("%s_%s%s", baseDstPath[:len(baseDstPath)-len(filepath.Ext(baseDstPath))],
-			spec.Name, filepath.Ext(baseDstPath))
+		format := spec.outputFormat(defaultFormat)
+		dstPath := thumbnailPath(baseDstPath, spec, defaultFormat)
 
 		dstDir := filepath.Dir(dstPath)
 		if err := os.MkdirAll(dstDir, 0o755); err != nil {
 			return nil, fmt.Errorf("mkdir for %s: %w", spec.Name, err)
 		}
 
-		if err := imaging.Save(thumb, dstPath); err != nil {
+		quality, err := saveImage(ctx, thumb, dstPath, spec)
+		if err != nil {
 			return nil, fmt.Errorf("save %s: %w", spec.Name, err)
 		}
 
@@ -85,8 +245,71 @@ func GenerateThumbnails(srcPath, baseDstPath string, specs []ThumbnailSpec) ([]T
 			Height:       b.Dy(),
 			SourceWidth:  sourceWidth,
 			SourceHeight: sourceHeight,
+			Mode:         spec.resizeMode(),
+			Crop:         crop,
+			Format:       format,
+			Quality:      quality,
+			SourceHash:   sourceHash,
+			Palette:      palette,
 		})
+
+		if spec.Animated && anim != nil {
+			animated, err := generateAnimated(ctx, anim, baseDstPath, spec, crop)
+			if err != nil {
+				return nil, err
+			}
+			animated.SourceWidth = sourceWidth
+			animated.SourceHeight = sourceHeight
+			animated.SourceHash = sourceHash
+			animated.Palette = palette
+			results = append(results, animated)
+		}
 	}
 
 	return results, nil
 }
+
+// decodedThumbnails resizes an image decoded by a converter, such as cover
+// art or a RAW preview, for every spec, in defaultFormat unless the spec asks
+// for another format.
+func decodedThumbnails(ctx context.Context, src image.Image, baseDstPath string, specs []ThumbnailSpec, defaultFormat OutputFormat) ([]ThumbnailOutput, error) {
+	sourceHash := ComputePerceptualHash(src)
+	palette := ComputePalette(src)
+	b := src.Bounds()
+
+	var results []ThumbnailOutput
+	for _, spec := range specs {
+		outputPath := thumbnailPath(baseDstPath, spec, defaultFormat)
+		thumb, crop := resizeImage(src, spec)
+		quality, err := saveImage(ctx, thumb, outputPath, spec)
+		if err != nil {
+			return nil, fmt.Errorf("save %s: %w", spec.Name, err)
+		}
+
+		tb := thumb.Bounds()
+		results = append(results, ThumbnailOutput{
+			Name:         spec.Name,
+			Path:         outputPath,
+			Width:        tb.Dx(),
+			Height:       tb.Dy(),
+			SourceWidth:  b.Dx(),
+			SourceHeight: b.Dy(),
+			Mode:         spec.resizeMode(),
+			Crop:         crop,
+			Format:       spec.outputFormat(defaultFormat),
+			Quality:      quality,
+			SourceHash:   sourceHash,
+			Palette:      palette,
+		})
+	}
+	return results, nil
+}
+
+func wantsAnimation(specs []ThumbnailSpec) bool {
+	for _, spec := range specs {
+		if spec.Animated {
+			return true
+		}
+	}
+	return false
+}
@@ -5,9 +5,12 @@ import (
 	"image/color"
 	"image/png"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/disintegration/imaging"
 )
 
 func TestGenerateThumbnailCreatesOutput(t *testing.T) {
@@ -42,6 +45,116 @@ func TestGenerateThumbnailMissingSource(t *testing.T) {
 	}
 }
 
+func TestGenerateThumbnailsResizeModes(t *testing.T) {
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "source.png")
+	createTestImage(t, srcPath, 400, 200)
+
+	specs := []ThumbnailSpec{
+		{Name: "fit", Width: 100, Height: 100},
+		{Name: "fill", Width: 100, Height: 100, Mode: ResizeFill, Anchor: AnchorLeft},
+		{Name: "stretch", Width: 100, Height: 100, Mode: ResizeStretch},
+		{Name: "pad", Width: 100, Height: 100, Mode: ResizePad, Background: color.Black},
+	}
+
+	results, err := GenerateThumbnails(srcPath, filepath.Join(tmp, "thumb.png"), specs)
+	if err != nil {
+		t.Fatalf("GenerateThumbnails returned error: %v", err)
+	}
+
+	want := map[string][2]int{
+		"fit":     {100, 50},
+		"fill":    {100, 100},
+		"stretch": {100, 100},
+		"pad":     {100, 100},
+	}
+	for _, result := range results {
+		size := want[result.Name]
+		if result.Width != size[0] || result.Height != size[1] {
+			t.Errorf("%s: got %dx%d, want %dx%d", result.Name, result.Width, result.Height, size[0], size[1])
+		}
+		if result.Mode == "" {
+			t.Errorf("%s: resize mode not reported", result.Name)
+		}
+	}
+
+	padded, err := imaging.Open(results[3].Path)
+	if err != nil {
+		t.Fatalf("open padded thumbnail: %v", err)
+	}
+	if r, g, b, _ := padded.At(50, 0).RGBA(); r != 0 || g != 0 || b != 0 {
+		t.Errorf("expected black letterbox at top edge, got %v", padded.At(50, 0))
+	}
+}
+
+func TestGenerateThumbnailsOutputFormat(t *testing.T) {
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "source.png")
+	createTestImage(t, srcPath, 400, 200)
+
+	specs := []ThumbnailSpec{
+		{Name: "default", Width: 100, Height: 100},
+		{Name: "jpeg", Width: 100, Height: 100, Format: FormatJPEG, Quality: 60},
+	}
+
+	results, err := GenerateThumbnails(srcPath, filepath.Join(tmp, "thumb.png"), specs)
+	if err != nil {
+		t.Fatalf("GenerateThumbnails returned error: %v", err)
+	}
+
+	if filepath.Ext(results[0].Path) != ".png" || results[0].Format != FormatPNG || results[0].Quality != 0 {
+		t.Errorf("default output: got %s format=%s quality=%d, want .png/png/0", results[0].Path, results[0].Format, results[0].Quality)
+	}
+	if filepath.Ext(results[1].Path) != ".jpg" || results[1].Format != FormatJPEG || results[1].Quality != 60 {
+		t.Errorf("jpeg output: got %s format=%s quality=%d, want .jpg/jpeg/60", results[1].Path, results[1].Format, results[1].Quality)
+	}
+
+	f, err := os.Open(results[1].Path)
+	if err != nil {
+		t.Fatalf("open jpeg thumbnail: %v", err)
+	}
+	defer f.Close()
+	if _, format, err := image.DecodeConfig(f); err != nil || format != "jpeg" {
+		t.Errorf("expected jpeg-encoded file, got format=%q err=%v", format, err)
+	}
+}
+
+func TestGenerateThumbnailsWebP(t *testing.T) {
+	if _, err := exec.LookPath("cwebp"); err != nil {
+		t.Skipf("cwebp not installed: %v", err)
+	}
+
+	tmp := t.TempDir()
+	srcPath := filepath.Join(tmp, "source.png")
+	createTestImage(t, srcPath, 400, 200)
+
+	specs := []ThumbnailSpec{
+		{Name: "lossy", Width: 100, Height: 100, Format: FormatWebP, Quality: 70},
+		{Name: "lossless", Width: 100, Height: 100, Format: FormatWebP, Lossless: true},
+	}
+
+	results, err := GenerateThumbnails(srcPath, filepath.Join(tmp, "thumb.png"), specs)
+	if err != nil {
+		t.Fatalf("GenerateThumbnails returned error: %v", err)
+	}
+
+	for _, res := range results {
+		if filepath.Ext(res.Path) != ".webp" || res.Format != FormatWebP {
+			t.Errorf("%s: got %s format=%s, want .webp", res.Name, res.Path, res.Format)
+		}
+		decoded, err := imaging.Open(res.Path)
+		if err != nil {
+			t.Fatalf("%s: decode webp: %v", res.Name, err)
+		}
+		if decoded.Bounds().Dx() != 100 || decoded.Bounds().Dy() != 50 {
+			t.Errorf("%s: got %v, want 100x50", res.Name, decoded.Bounds().Size())
+		}
+	}
+	if results[0].Quality != 70 || results[1].Quality != 0 {
+		t.Errorf("got qualities %d/%d, want 70/0", results[0].Quality, results[1].Quality)
+	}
+}
+
 func createTestImage(t *testing.T, path string, w, h int) {
 	t.Helper()
 
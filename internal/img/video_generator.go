@@ -3,8 +3,12 @@ package img
 import (
 	"context"
 	"fmt"
+	"image"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/tendant/simple-thumbnailer/internal/converters"
 )
@@ -13,35 +17,94 @@ import (
 // It adapts the converters.FFmpegConverter to the img.Generator interface.
 type VideoGenerator struct {
 	converter *converters.FFmpegConverter
+
+	// Limits bounds the sources that will be processed. The zero value is unlimited.
+	Limits Limits
+}
+
+// SeekPosition selects the video frame a thumbnail is taken from, either in
+// seconds or as a percentage of the duration. The zero value uses the
+// converter's default of converters.DefaultSeekPercent.
+type SeekPosition struct {
+	Seconds float64
+	Percent float64
+}
+
+// ParseSeekPosition parses "25%", seconds ("12" or "12.5") or a Go duration
+// ("1m30s"). Positions past the end of a clip are pulled back to its last
+// frames, so only the lower bound is checked here.
+func ParseSeekPosition(value string) (SeekPosition, error) {
+	value = strings.TrimSpace(value)
+	if pct, ok := strings.CutSuffix(value, "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(pct), 64)
+		if err != nil || percent <= 0 || percent >= 100 {
+			return SeekPosition{}, fmt.Errorf("invalid seek percentage %q (expected 0-100%%, exclusive)", value)
+		}
+		return SeekPosition{Percent: percent}, nil
+	}
+	d, err := parseDuration(value)
+	if err != nil {
+		return SeekPosition{}, fmt.Errorf("invalid seek position %q (expected seconds, a duration or a percentage)", value)
+	}
+	return SeekPosition{Seconds: d.Seconds()}, nil
 }
 
 // NewVideoGenerator creates a new video thumbnail generator
 func NewVideoGenerator() *VideoGenerator {
 	return &VideoGenerator{
 		converter: converters.NewFFmpegConverter(),
+		Limits:    DefaultLimits,
 	}
 }
 
 // Generate implements Generator.Generate for videos
-// It generates thumbnails using FFmpeg's smart frame selection
+// It extracts the poster frame once at full resolution and derives every size
+// from it in Go, through the same resampling path as images. Specs only cause
+// another extraction when they select a different frame (Seek or BestFrame).
 func (g *VideoGenerator) Generate(ctx context.Context, srcPath string, baseDstPath string, specs []ThumbnailSpec) ([]ThumbnailOutput, error) {
 	var results []ThumbnailOutput
 
-	// Get source dimensions for output metadata
+	if err := g.Limits.checkFileSize(srcPath); err != nil {
+		return nil, err
+	}
+
+	// Get source dimensions for output metadata and enforce limits before
+	// handing the file to the external tool
 	fileInfo, err := g.converter.Probe(ctx, srcPath)
-	sourceWidth := 0
-	sourceHeight := 0
-	if err == nil {
-		sourceWidth = fileInfo.Width
-		sourceHeight = fileInfo.Height
+	if err != nil {
+		return nil, fmt.Errorf("probe: %w", err)
 	}
+	if err := g.Limits.checkPixels(fileInfo.Width, fileInfo.Height); err != nil {
+		return nil, err
+	}
+	if err := g.Limits.checkDuration(fileInfo.Duration); err != nil {
+		return nil, err
+	}
+
+	frames := make(map[frameSelection]*videoFrame)
+	var sourceHash PerceptualHash
+	var palette Palette
 
 	// Generate thumbnail for each size specification
 	for _, spec := range specs {
-		// Build output path: base_sizename.jpg
-		ext := filepath.Ext(baseDstPath)
-		base := baseDstPath[:len(baseDstPath)-len(ext)]
-		outputPath := fmt.Sprintf("%s_%s.jpg", base, spec.Name)
+		sel := frameSelection{seek: spec.Seek, bestFrame: spec.BestFrame}
+		frame, ok := frames[sel]
+		if !ok {
+			frame, err = g.extractFrame(ctx, srcPath, baseDstPath, spec, fileInfo)
+			if err != nil {
+				return nil, fmt.Errorf("generate thumbnail %s: %w", spec.Name, err)
+			}
+			frames[sel] = frame
+			// Analyse the first frame extracted; every output carries the same values
+			if len(frames) == 1 {
+				sourceHash = ComputePerceptualHash(frame.img)
+				palette = ComputePalette(frame.img)
+			}
+		}
+
+		// Build output path: base_sizename.jpg (or the spec's format)
+		format := spec.outputFormat(FormatJPEG)
+		outputPath := thumbnailPath(baseDstPath, spec, FormatJPEG)
 
 		// Ensure output directory exists
 		outputDir := filepath.Dir(outputPath)
@@ -49,30 +112,118 @@ func (g *VideoGenerator) Generate(ctx context.Context, srcPath string, baseDstPa
 			return nil, fmt.Errorf("mkdir for %s: %w", spec.Name, err)
 		}
 
-		// Convert video to thumbnail
-		err := g.converter.Convert(ctx, srcPath, outputPath, spec.Width, spec.Height)
+		thumb, crop := resizeImage(frame.img, spec)
+		quality, err := saveImage(ctx, thumb, outputPath, spec)
 		if err != nil {
-			return nil, fmt.Errorf("generate thumbnail %s: %w", spec.Name, err)
+			return nil, fmt.Errorf("save %s: %w", spec.Name, err)
 		}
 
-		// Get actual output dimensions by checking the file
-		// (FFmpeg may produce different dimensions due to aspect ratio preservation)
-		actualWidth := spec.Width
-		actualHeight := spec.Height
-
+		b := thumb.Bounds()
 		results = append(results, ThumbnailOutput{
 			Name:         spec.Name,
 			Path:         outputPath,
-			Width:        actualWidth,
-			Height:       actualHeight,
-			SourceWidth:  sourceWidth,
-			SourceHeight: sourceHeight,
+			Width:        b.Dx(),
+			Height:       b.Dy(),
+			SourceWidth:  frame.img.Bounds().Dx(),
+			SourceHeight: frame.img.Bounds().Dy(),
+			Mode:         spec.resizeMode(),
+			Crop:         crop,
+			Format:       format,
+			Quality:      quality,
+			FrameTime:    frame.at,
 		})
+
+		if spec.Preview {
+			preview, err := g.generatePreview(ctx, srcPath, baseDstPath, spec, fileInfo)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, preview)
+		}
+		if spec.Storyboard > 0 {
+			storyboard, err := g.generateStoryboard(ctx, srcPath, baseDstPath, spec, fileInfo)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, storyboard...)
+		}
+	}
+
+	for i := range results {
+		results[i].SourceHash = sourceHash
+		results[i].Palette = palette
 	}
 
 	return results, nil
 }
 
+// frameSelection identifies the spec settings that choose a video frame.
+type frameSelection struct {
+	seek      SeekPosition
+	bestFrame int
+}
+
+// videoFrame is a decoded full-resolution frame and its timestamp, which is
+// zero when FFmpeg's thumbnail filter chose the frame.
+type videoFrame struct {
+	img image.Image
+	at  time.Duration
+}
+
+// extractFrame decodes the frame selected by spec. With BestFrame it scores
+// candidate frames first; without a duration, or when no candidate can be
+// extracted, it falls back to the thumbnail filter at the spec's seek
+// position.
+func (g *VideoGenerator) extractFrame(ctx context.Context, srcPath, baseDstPath string, spec ThumbnailSpec, info *converters.FileInfo) (*videoFrame, error) {
+	if err := os.MkdirAll(filepath.Dir(baseDstPath), 0o755); err != nil {
+		return nil, err
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(baseDstPath), "frame-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	framePath := filepath.Join(tmpDir, "frame.png")
+
+	var at time.Duration
+	extracted := false
+	if spec.BestFrame > 0 {
+		best, err := g.bestFrameTime(ctx, srcPath, baseDstPath, spec.BestFrame, info)
+		if err == nil {
+			if err := g.converter.ExtractFrame(ctx, srcPath, framePath, best.Seconds(), 0, 0); err != nil {
+				return nil, err
+			}
+			at, extracted = best, true
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	if !extracted {
+		if err := g.converter.ConvertWithOptions(ctx, srcPath, framePath, 0, 0, seekOptions(spec, info)); err != nil {
+			return nil, err
+		}
+	}
+
+	img, err := openImage(framePath, Limits{MaxPixels: g.Limits.MaxPixels})
+	if err != nil {
+		return nil, fmt.Errorf("open frame: %w", err)
+	}
+	return &videoFrame{img: img, at: at}, nil
+}
+
+// seekOptions passes the spec's seek position and the probed duration, if
+// any, so the converter does not probe again.
+func seekOptions(spec ThumbnailSpec, info *converters.FileInfo) converters.ConversionOptions {
+	opts := converters.ConversionOptions{
+		SeekTime:    spec.Seek.Seconds,
+		SeekPercent: spec.Seek.Percent,
+	}
+	if info != nil {
+		opts.Duration = info.Duration
+	}
+	return opts
+}
+
 // Supports implements Generator.Supports for videos
 func (g *VideoGenerator) Supports(mimeType string) bool {
 	return g.converter.Supports(mimeType)
@@ -0,0 +1,62 @@
+package img
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestVideoGeneratorMultipleSizes(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not installed")
+	}
+	sample := "../../scripts/test-samples/sample.mp4"
+	if _, err := os.Stat(sample); err != nil {
+		t.Skipf("sample file not found: %s", sample)
+	}
+
+	tmpDir := t.TempDir()
+	results, err := NewVideoGenerator().Generate(context.Background(), sample, filepath.Join(tmpDir, "thumb.jpg"), []ThumbnailSpec{
+		{Name: "small", Width: 64, Height: 64},
+		{Name: "medium", Width: 128, Height: 128, Mode: ResizeFill},
+		{Name: "large", Width: 256, Height: 256, Format: FormatPNG},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 outputs, got %d", len(results))
+	}
+
+	for _, r := range results {
+		width, height, err := imageSize(r.Path)
+		if err != nil {
+			t.Errorf("%s: read dimensions: %v", r.Name, err)
+		} else if r.Width != width || r.Height != height {
+			t.Errorf("%s: reported %dx%d, file is %dx%d", r.Name, r.Width, r.Height, width, height)
+		}
+		if r.SourceWidth != results[0].SourceWidth || r.SourceHeight != results[0].SourceHeight {
+			t.Errorf("%s: source %dx%d differs from %dx%d", r.Name, r.SourceWidth, r.SourceHeight, results[0].SourceWidth, results[0].SourceHeight)
+		}
+		if r.SourceHash != results[0].SourceHash || r.SourceHash.PHash == "" {
+			t.Errorf("%s: source hash %+v, want shared non-empty hash", r.Name, r.SourceHash)
+		}
+	}
+	if r := results[1]; r.Width != 128 || r.Height != 128 {
+		t.Errorf("medium: got %dx%d, want 128x128", r.Width, r.Height)
+	}
+	if results[2].Format != FormatPNG {
+		t.Errorf("large: got format %q, want png", results[2].Format)
+	}
+
+	// The intermediate full-resolution frame must not be left behind
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("read output dir: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected 3 files in output dir, got %d", len(entries))
+	}
+}
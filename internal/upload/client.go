@@ -5,9 +5,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/google/uuid"
 	simplecontent "github.com/tendant/simple-content/pkg/simplecontent"
@@ -34,6 +36,9 @@ type Source struct {
 // UploadResult captures information about a stored thumbnail.
 type UploadResult struct {
 	Content *simplecontent.Content
+	// MetadataErr reports that the thumbnail was stored but its dimensions
+	// could not be recorded. The upload itself succeeded.
+	MetadataErr error
 }
 
 // FetchSource downloads the latest content into a temporary file using the simplified API.
@@ -145,12 +150,9 @@ func (c *Client) UploadThumbnail(ctx context.Context, parent *simplecontent.Cont
 
 // UploadThumbnailObject uploads a thumbnail to pre-created derived content.
 // This is used for async workflows where content records are created before processing.
+// A failure to record the dimensions does not fail the upload; it is
+// returned in UploadResult.MetadataErr.
 func (c *Client) UploadThumbnailObject(ctx context.Context, contentID uuid.UUID, thumbPath string, opts UploadOptions) (*UploadResult, error) {
-	info, err := os.Stat(thumbPath)
-	if err != nil {
-		return nil, fmt.Errorf("stat thumbnail: %w", err)
-	}
-
 	fileName := opts.FileName
 	if fileName == "" {
 		fileName = filepath.Base(thumbPath)
@@ -172,7 +174,7 @@ func (c *Client) UploadThumbnailObject(ctx context.Context, contentID uuid.UUID,
 	defer file.Close()
 
 	// Upload object to existing derived content
-	obj, err := c.svc.UploadObjectForContent(ctx, simplecontent.UploadObjectForContentRequest{
+	_, err = c.svc.UploadObjectForContent(ctx, simplecontent.UploadObjectForContentRequest{
 		ContentID:          contentID,
 		StorageBackendName: c.backend,
 		Reader:             file,
@@ -183,17 +185,62 @@ func (c *Client) UploadThumbnailObject(ctx context.Context, contentID uuid.UUID,
 		return nil, fmt.Errorf("upload object for content: %w", err)
 	}
 
+	// The placeholder was created with the requested box; record the real
+	// dimensions of the generated file now that they are known.
+	var metadataErr error
+	if opts.Width > 0 && opts.Height > 0 {
+		if err := c.UpdateContentMetadata(ctx, contentID, map[string]interface{}{
+			"width":  opts.Width,
+			"height": opts.Height,
+		}); err != nil {
+			metadataErr = fmt.Errorf("record thumbnail dimensions: %w", err)
+		}
+	}
+
 	// Get the content to return consistent result
 	content, err := c.svc.GetContent(ctx, contentID)
 	if err != nil {
 		return nil, fmt.Errorf("get content after upload: %w", err)
 	}
 
-	// Store filesize in object metadata if needed
-	_ = obj
-	_ = info
+	return &UploadResult{Content: content, MetadataErr: metadataErr}, nil
+}
 
-	return &UploadResult{Content: content}, nil
+// UpdateContentMetadata merges fields into the custom metadata of a content.
+// SetContentMetadata replaces the whole record, so the current metadata is
+// read first and carried over.
+func (c *Client) UpdateContentMetadata(ctx context.Context, contentID uuid.UUID, fields map[string]interface{}) error {
+	meta, err := c.svc.GetContentMetadata(ctx, contentID)
+	if err != nil {
+		return fmt.Errorf("get content metadata: %w", err)
+	}
+
+	merged := make(map[string]interface{}, len(meta.Metadata)+len(fields))
+	for k, v := range meta.Metadata {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	if err := c.svc.SetContentMetadata(ctx, simplecontent.SetContentMetadataRequest{
+		ContentID:      contentID,
+		ContentType:    meta.MimeType,
+		Tags:           meta.Tags,
+		FileName:       meta.FileName,
+		FileSize:       meta.FileSize,
+		CustomMetadata: merged,
+	}); err != nil {
+		return fmt.Errorf("set content metadata: %w", err)
+	}
+	return nil
+}
+
+// generatedMimeTypes covers extensions of generated files that Go's built-in
+// table, and the mime.types of some systems, do not know.
+var generatedMimeTypes = map[string]string{
+	".mp4": "video/mp4",
+	".vtt": "text/vtt",
 }
 
 func detectMime(path string) (string, error) {
@@ -208,7 +255,19 @@ func detectMime(path string) (string, error) {
 	if err != nil && err != io.EOF {
 		return "", fmt.Errorf("read for mime detect: %w", err)
 	}
-	return http.DetectContentType(buf[:n]), nil
+	mimeType := http.DetectContentType(buf[:n])
+	if mimeType == "application/octet-stream" || strings.HasPrefix(mimeType, "text/plain") {
+		// Content sniffing only knows a handful of signatures and sees any text
+		// as plain; trust the extension the generator chose instead.
+		ext := filepath.Ext(path)
+		if byExt := mime.TypeByExtension(ext); byExt != "" {
+			return byExt, nil
+		}
+		if byExt, ok := generatedMimeTypes[ext]; ok {
+			return byExt, nil
+		}
+	}
+	return mimeType, nil
 }
 
 // GetThumbnailsBySize retrieves thumbnails of specific sizes for a parent content using the new API.
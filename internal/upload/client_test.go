@@ -2,6 +2,8 @@ package upload
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -150,6 +152,137 @@ func TestUploadThumbnailWorkflow(t *testing.T) {
 	}
 }
 
+func TestUploadThumbnailObjectCorrectsDimensions(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	derived, err := env.svc.CreateDerivedContent(ctx, simplecontent.CreateDerivedContentRequest{
+		ParentID:       env.content.ID,
+		OwnerID:        env.content.OwnerID,
+		TenantID:       env.content.TenantID,
+		DerivationType: "thumbnail",
+		Variant:        "thumbnail_256",
+		Metadata:       map[string]interface{}{"width": 256, "height": 256, "resize_mode": "fit"},
+		InitialStatus:  simplecontent.ContentStatusCreated,
+	})
+	if err != nil {
+		t.Fatalf("create derived content: %v", err)
+	}
+
+	thumbPath := filepath.Join(t.TempDir(), "thumb.png")
+	if err := os.WriteFile(thumbPath, []byte("png-data"), 0o644); err != nil {
+		t.Fatalf("write thumb: %v", err)
+	}
+
+	if _, err := env.client.UploadThumbnailObject(ctx, derived.ID, thumbPath, UploadOptions{
+		FileName: "thumb.png",
+		MimeType: "image/png",
+		Width:    256,
+		Height:   144,
+	}); err != nil {
+		t.Fatalf("UploadThumbnailObject error: %v", err)
+	}
+
+	meta, err := env.svc.GetContentMetadata(ctx, derived.ID)
+	if err != nil {
+		t.Fatalf("get derived metadata: %v", err)
+	}
+	for key, want := range map[string]string{"width": "256", "height": "144", "resize_mode": "fit"} {
+		if got := fmt.Sprint(meta.Metadata[key]); got != want {
+			t.Errorf("metadata %s = %s, want %s", key, got, want)
+		}
+	}
+}
+
+// failingMetadataService fails every metadata write.
+type failingMetadataService struct {
+	simplecontent.Service
+}
+
+func (failingMetadataService) SetContentMetadata(context.Context, simplecontent.SetContentMetadataRequest) error {
+	return errors.New("metadata store unavailable")
+}
+
+func TestUploadThumbnailObjectMetadataFailureIsNotFatal(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	derived, err := env.svc.CreateDerivedContent(ctx, simplecontent.CreateDerivedContentRequest{
+		ParentID:       env.content.ID,
+		OwnerID:        env.content.OwnerID,
+		TenantID:       env.content.TenantID,
+		DerivationType: "thumbnail",
+		Variant:        "thumbnail_256",
+		InitialStatus:  simplecontent.ContentStatusCreated,
+	})
+	if err != nil {
+		t.Fatalf("create derived content: %v", err)
+	}
+
+	thumbPath := filepath.Join(t.TempDir(), "thumb.png")
+	if err := os.WriteFile(thumbPath, []byte("png-data"), 0o644); err != nil {
+		t.Fatalf("write thumb: %v", err)
+	}
+
+	client := NewClient(failingMetadataService{env.svc}, "memory")
+	result, err := client.UploadThumbnailObject(ctx, derived.ID, thumbPath, UploadOptions{
+		FileName: "thumb.png",
+		MimeType: "image/png",
+		Width:    256,
+		Height:   144,
+	})
+	if err != nil {
+		t.Fatalf("UploadThumbnailObject error: %v", err)
+	}
+	if result.Content == nil || result.Content.ID != derived.ID {
+		t.Fatalf("expected the derived content back, got %+v", result.Content)
+	}
+	if result.MetadataErr == nil {
+		t.Error("expected MetadataErr for the failed metadata write")
+	}
+}
+
+func TestDetectMimeWebP(t *testing.T) {
+	dir := t.TempDir()
+
+	// A lossless WebP header is recognised by content sniffing.
+	sniffed := filepath.Join(dir, "thumb")
+	if err := os.WriteFile(sniffed, []byte("RIFF\x1a\x00\x00\x00WEBPVP8L\x0d\x00\x00\x00"), 0o644); err != nil {
+		t.Fatalf("write webp: %v", err)
+	}
+	// Anything unrecognisable falls back to the extension.
+	byExt := filepath.Join(dir, "thumb.webp")
+	if err := os.WriteFile(byExt, []byte{0x00, 0x01, 0x02}, 0o644); err != nil {
+		t.Fatalf("write webp: %v", err)
+	}
+
+	for _, path := range []string{sniffed, byExt} {
+		got, err := detectMime(path)
+		if err != nil {
+			t.Fatalf("detectMime(%s) error: %v", path, err)
+		}
+		if got != "image/webp" {
+			t.Fatalf("detectMime(%s) = %q, want image/webp", path, got)
+		}
+	}
+}
+
+func TestDetectMimeStoryboardVTT(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "video_card_storyboard.vtt")
+	if err := os.WriteFile(path, []byte("WEBVTT\n\n00:00:00.000 --> 00:00:05.000\nsprite.jpg#xywh=0,0,160,90\n"), 0o644); err != nil {
+		t.Fatalf("write vtt: %v", err)
+	}
+
+	got, err := detectMime(path)
+	if err != nil {
+		t.Fatalf("detectMime error: %v", err)
+	}
+	// Sniffing sees plain text; the extension knows better
+	if !strings.HasPrefix(got, "text/vtt") {
+		t.Fatalf("detectMime = %q, want text/vtt", got)
+	}
+}
+
 func TestGetThumbnailsBySize(t *testing.T) {
 	env := newTestEnv(t)
 	ctx := context.Background()
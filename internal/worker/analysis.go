@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"github.com/tendant/simple-thumbnailer/internal/img"
+	"github.com/tendant/simple-thumbnailer/internal/upload"
+	"github.com/tendant/simple-thumbnailer/pkg/schema"
+)
+
+// AnalyzeThumbnails collects the per-source placeholders, palette and
+// perceptual hash from the generated thumbnails. Failures are logged and leave
+// the fields empty.
+func AnalyzeThumbnails(thumbnails []img.ThumbnailOutput, state *ProcessingState, logger *slog.Logger) {
+	// Generators hash the source and extract its palette themselves; every
+	// output carries the same values
+	if len(thumbnails) > 0 {
+		state.SourceHash = thumbnails[0].SourceHash
+		state.Palette = thumbnails[0].Palette
+	}
+
+	if placeholders, err := img.ComputePlaceholders(thumbnails); err != nil {
+		logger.Warn("compute placeholders failed", "err", err)
+	} else {
+		state.Placeholders = placeholders
+	}
+}
+
+// SourceMetadataFields returns the per-source analysis results to store in
+// content metadata, omitting any that could not be computed.
+func SourceMetadataFields(state *ProcessingState) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if state.Placeholders.BlurHash != "" {
+		fields["blurhash"] = state.Placeholders.BlurHash
+		fields["thumbhash"] = state.Placeholders.ThumbHash
+	}
+	if state.Palette.Dominant != "" {
+		fields["dominant_color"] = state.Palette.Dominant
+		fields["palette"] = state.Palette.Colors
+	}
+	if state.SourceHash.PHash != "" {
+		fields["phash"] = state.SourceHash.PHash
+		fields["dhash"] = state.SourceHash.DHash
+	}
+	return fields
+}
+
+// RecordSourceMetadata stores the per-source analysis results in the
+// metadata of the parent and of every processed thumbnail. Failures are
+// logged, not fatal.
+func RecordSourceMetadata(ctx context.Context, parentID uuid.UUID, results []schema.ThumbnailResult, state *ProcessingState, uploader *upload.Client, logger *slog.Logger) {
+	fields := SourceMetadataFields(state)
+	if len(fields) == 0 {
+		return
+	}
+
+	if err := uploader.UpdateContentMetadata(ctx, parentID, fields); err != nil {
+		logger.Warn("record source metadata on parent failed", "content_id", parentID, "err", err)
+	}
+	for _, result := range results {
+		if result.Status != "processed" {
+			continue
+		}
+		derivedContentID := state.DerivedContentIDs[result.Size]
+		if err := uploader.UpdateContentMetadata(ctx, derivedContentID, fields); err != nil {
+			logger.Warn("record source metadata on thumbnail failed", "size", result.Size, "content_id", derivedContentID, "err", err)
+		}
+	}
+}
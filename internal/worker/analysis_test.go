@@ -0,0 +1,28 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/tendant/simple-thumbnailer/internal/img"
+)
+
+func TestSourceMetadataFields(t *testing.T) {
+	state := &ProcessingState{}
+	if fields := SourceMetadataFields(state); len(fields) != 0 {
+		t.Fatalf("expected no fields before analysis, got %v", fields)
+	}
+
+	state.Placeholders = img.Placeholders{BlurHash: "L0TSUA", ThumbHash: "PwgCBwA="}
+	state.Palette = img.Palette{Dominant: "#c86432", Colors: []string{"#c86432", "#ffffff"}}
+	state.SourceHash = img.PerceptualHash{PHash: "d1c4b0a090807060", DHash: "0f0f0f0f0f0f0f0f"}
+	fields := SourceMetadataFields(state)
+	if fields["blurhash"] != "L0TSUA" || fields["thumbhash"] != "PwgCBwA=" || fields["dominant_color"] != "#c86432" {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+	if fields["phash"] != "d1c4b0a090807060" || fields["dhash"] != "0f0f0f0f0f0f0f0f" {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+	if palette, ok := fields["palette"].([]string); !ok || len(palette) != 2 {
+		t.Errorf("expected palette of 2 colours, got %v", fields["palette"])
+	}
+}
@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/tendant/simple-thumbnailer/internal/converters"
+	"github.com/tendant/simple-thumbnailer/internal/img"
+	"github.com/tendant/simple-thumbnailer/pkg/schema"
+)
+
+// ValidationError is a failure of the job itself rather than of processing.
+type ValidationError struct {
+	Type    schema.FailureType
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+// ErrorCodeFor identifies known failure causes for ThumbnailDone.ErrorCode.
+func ErrorCodeFor(err error) schema.ErrorCode {
+	switch {
+	case errors.Is(err, converters.ErrPDFEncrypted):
+		return schema.ErrorCodePDFEncrypted
+	case errors.Is(err, converters.ErrPDFMalformed):
+		return schema.ErrorCodePDFMalformed
+	default:
+		return ""
+	}
+}
+
+// ClassifyError decides whether a failed job may be retried.
+func ClassifyError(err error) schema.FailureType {
+	if err == nil {
+		return ""
+	}
+
+	// Check for validation errors
+	var validationErr ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Type
+	}
+
+	// Oversized sources will never fit within the limits, and sources of
+	// unknown size cannot be checked against them, so do not retry
+	if errors.Is(err, img.ErrLimitExceeded) || errors.Is(err, img.ErrSizeUnknown) {
+		return schema.FailureTypePermanent
+	}
+
+	// Neither will a page the document does not have
+	if errors.Is(err, img.ErrPageOutOfRange) {
+		return schema.FailureTypePermanent
+	}
+
+	// Nor a PDF that cannot be opened with the password given, or parsed
+	if errors.Is(err, converters.ErrPDFEncrypted) || errors.Is(err, converters.ErrPDFMalformed) {
+		return schema.FailureTypePermanent
+	}
+
+	// Or a text upload that holds binary data, or an SVG that reaches outside itself
+	if errors.Is(err, img.ErrBinaryContent) || errors.Is(err, converters.ErrSVGUnsafe) {
+		return schema.FailureTypePermanent
+	}
+
+	// Check for network/temporary errors
+	errStr := err.Error()
+	if strings.Contains(errStr, "connection refused") ||
+		strings.Contains(errStr, "timeout") ||
+		strings.Contains(errStr, "temporary failure") ||
+		strings.Contains(errStr, "context deadline exceeded") {
+		return schema.FailureTypeRetryable
+	}
+
+	// Check for file system errors
+	if strings.Contains(errStr, "no such file") ||
+		strings.Contains(errStr, "permission denied") ||
+		strings.Contains(errStr, "invalid image format") ||
+		strings.Contains(errStr, "unsupported") {
+		return schema.FailureTypePermanent
+	}
+
+	// Default to retryable for unknown errors
+	return schema.FailureTypeRetryable
+}
@@ -0,0 +1,45 @@
+package worker
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tendant/simple-thumbnailer/internal/converters"
+	"github.com/tendant/simple-thumbnailer/internal/img"
+	"github.com/tendant/simple-thumbnailer/pkg/schema"
+)
+
+func TestClassifyPDFErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		code schema.ErrorCode
+	}{
+		{fmt.Errorf("render page 1: %w", converters.ErrPDFEncrypted), schema.ErrorCodePDFEncrypted},
+		{fmt.Errorf("render page 1: %w", converters.ErrPDFMalformed), schema.ErrorCodePDFMalformed},
+	}
+	for _, tt := range tests {
+		if got := ClassifyError(tt.err); got != schema.FailureTypePermanent {
+			t.Errorf("ClassifyError(%v) = %q, want permanent", tt.err, got)
+		}
+		if got := ErrorCodeFor(tt.err); got != tt.code {
+			t.Errorf("ErrorCodeFor(%v) = %q, want %q", tt.err, got, tt.code)
+		}
+	}
+	if got := ErrorCodeFor(fmt.Errorf("pdftoppm failed: exit status 99")); got != "" {
+		t.Errorf("unknown failure got code %q", got)
+	}
+}
+
+func TestClassifyValidationError(t *testing.T) {
+	err := fmt.Errorf("check parent: %w", ValidationError{Type: schema.FailureTypeValidation, Message: "not uploaded"})
+	if got := ClassifyError(err); got != schema.FailureTypeValidation {
+		t.Fatalf("ClassifyError = %q, want validation", got)
+	}
+}
+
+func TestClassifySizeUnknown(t *testing.T) {
+	err := fmt.Errorf("generate: %w", img.ErrSizeUnknown)
+	if got := ClassifyError(err); got != schema.FailureTypePermanent {
+		t.Fatalf("ClassifyError = %q, want permanent", got)
+	}
+}
@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/tendant/simple-thumbnailer/internal/img"
+)
+
+// LoadLimits reads source limits from the environment, starting from
+// img.DefaultLimits. A value of 0 disables that limit.
+func LoadLimits() (img.Limits, error) {
+	limits := img.DefaultLimits
+
+	if v := os.Getenv("MAX_SOURCE_PIXELS"); v != "" {
+		n, err := parseNonNegativeInt64(v, "MAX_SOURCE_PIXELS")
+		if err != nil {
+			return img.Limits{}, err
+		}
+		limits.MaxPixels = n
+	}
+	if v := os.Getenv("MAX_SOURCE_FILE_SIZE"); v != "" {
+		n, err := parseNonNegativeInt64(v, "MAX_SOURCE_FILE_SIZE")
+		if err != nil {
+			return img.Limits{}, err
+		}
+		limits.MaxFileSize = n
+	}
+	if v := os.Getenv("MAX_VIDEO_DURATION"); v != "" {
+		n, err := parseNonNegativeInt64(v, "MAX_VIDEO_DURATION")
+		if err != nil {
+			return img.Limits{}, err
+		}
+		limits.MaxDuration = float64(n)
+	}
+	if v := os.Getenv("MAX_PDF_PAGES"); v != "" {
+		n, err := parseNonNegativeInt64(v, "MAX_PDF_PAGES")
+		if err != nil {
+			return img.Limits{}, err
+		}
+		limits.MaxPages = int(n)
+	}
+
+	return limits, nil
+}
+
+func parseNonNegativeInt64(value string, name string) (int64, error) {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if v < 0 {
+		return 0, fmt.Errorf("%s must not be negative (got %d)", name, v)
+	}
+	return v, nil
+}
@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/tendant/simple-thumbnailer/internal/img"
+)
+
+func TestLoadLimits(t *testing.T) {
+	t.Setenv("MAX_SOURCE_PIXELS", "")
+	t.Setenv("MAX_PDF_PAGES", "")
+
+	limits, err := LoadLimits()
+	if err != nil {
+		t.Fatalf("LoadLimits returned error: %v", err)
+	}
+	if limits != img.DefaultLimits {
+		t.Errorf("expected default limits, got %+v", limits)
+	}
+
+	t.Setenv("MAX_SOURCE_PIXELS", "1000")
+	t.Setenv("MAX_PDF_PAGES", "0")
+	limits, err = LoadLimits()
+	if err != nil {
+		t.Fatalf("LoadLimits returned error: %v", err)
+	}
+	if limits.MaxPixels != 1000 || limits.MaxPages != 0 {
+		t.Errorf("expected overridden limits, got %+v", limits)
+	}
+
+	t.Setenv("MAX_SOURCE_PIXELS", "-1")
+	if _, err := LoadLimits(); err == nil {
+		t.Fatal("expected error for negative MAX_SOURCE_PIXELS")
+	}
+}
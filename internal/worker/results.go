@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tendant/simple-thumbnailer/internal/img"
+	"github.com/tendant/simple-thumbnailer/pkg/schema"
+)
+
+// DeriveSizeVariant creates a variant string from width and height
+func DeriveSizeVariant(width, height int) string {
+	if width == height {
+		return fmt.Sprintf("thumbnail_%d", width)
+	}
+	return fmt.Sprintf("thumbnail_%dx%d", width, height)
+}
+
+// VariantMetadata describes an extra output on its derived content record.
+func VariantMetadata(thumb img.ThumbnailOutput) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"width":       thumb.Width,
+		"height":      thumb.Height,
+		"resize_mode": string(thumb.Mode),
+		"animated":    thumb.Animated,
+	}
+	if thumb.Page > 0 {
+		metadata["page"] = thumb.Page
+	}
+	if thumb.PageCount > 0 {
+		metadata["page_count"] = thumb.PageCount
+	}
+	return metadata
+}
+
+// DerivationParamsFor describes how a thumbnail was derived from its source.
+func DerivationParamsFor(thumb img.ThumbnailOutput, processingTime int64) *schema.DerivationParams {
+	params := &schema.DerivationParams{
+		SourceWidth:    thumb.SourceWidth,
+		SourceHeight:   thumb.SourceHeight,
+		TargetWidth:    thumb.Width,
+		TargetHeight:   thumb.Height,
+		Algorithm:      "lanczos",
+		ResizeMode:     string(thumb.Mode),
+		Quality:        thumb.Quality,
+		FrameTimeMs:    thumb.FrameTime.Milliseconds(),
+		ProcessingTime: processingTime,
+		GeneratedAt:    time.Now().Unix(),
+	}
+	if !thumb.Crop.Empty() {
+		params.CropBox = &schema.CropBox{
+			X:      thumb.Crop.Min.X,
+			Y:      thumb.Crop.Min.Y,
+			Width:  thumb.Crop.Dx(),
+			Height: thumb.Crop.Dy(),
+		}
+	}
+	return params
+}
+
+// ThumbnailResultFor fills the result fields that describe the output itself.
+func ThumbnailResultFor(thumb img.ThumbnailOutput) schema.ThumbnailResult {
+	return schema.ThumbnailResult{
+		Size:       thumb.Name,
+		Width:      thumb.Width,
+		Height:     thumb.Height,
+		Variant:    thumb.Variant,
+		Animated:   thumb.Animated,
+		FrameCount: thumb.FrameCount,
+		DurationMs: thumb.Duration.Milliseconds(),
+		Page:       thumb.Page,
+		PageCount:  thumb.PageCount,
+	}
+}
+
+// StoryboardsFor pairs each uploaded storyboard sprite with its WebVTT file.
+func StoryboardsFor(thumbnails []img.ThumbnailOutput, results []schema.ThumbnailResult) []schema.Storyboard {
+	contentIDs := make(map[string]string, len(results))
+	for _, result := range results {
+		contentIDs[result.Size] = result.ContentID
+	}
+
+	var storyboards []schema.Storyboard
+	for _, thumb := range thumbnails {
+		if thumb.Variant != img.VariantStoryboard || thumb.Storyboard == nil {
+			continue
+		}
+		size := strings.TrimSuffix(thumb.Name, "_"+img.VariantStoryboard)
+		layout := thumb.Storyboard
+		storyboards = append(storyboards, schema.Storyboard{
+			Size:            size,
+			SpriteContentID: contentIDs[thumb.Name],
+			VTTContentID:    contentIDs[size+"_"+img.VariantStoryboardVTT],
+			Frames:          layout.Frames,
+			Columns:         layout.Columns,
+			Rows:            layout.Rows,
+			TileWidth:       layout.TileWidth,
+			TileHeight:      layout.TileHeight,
+			IntervalMs:      layout.Interval.Milliseconds(),
+		})
+	}
+	return storyboards
+}
@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-thumbnailer/internal/img"
+	"github.com/tendant/simple-thumbnailer/pkg/schema"
+)
+
+func TestStoryboardsFor(t *testing.T) {
+	layout := &img.StoryboardLayout{Frames: 20, Columns: 10, Rows: 2, TileWidth: 160, TileHeight: 90, Interval: 3 * time.Second}
+	thumbnails := []img.ThumbnailOutput{
+		{Name: "scrub"},
+		{Name: "scrub_storyboard", Variant: img.VariantStoryboard, Storyboard: layout},
+		{Name: "scrub_storyboard_vtt", Variant: img.VariantStoryboardVTT, Storyboard: layout},
+	}
+	results := []schema.ThumbnailResult{
+		{Size: "scrub", ContentID: "poster"},
+		{Size: "scrub_storyboard", ContentID: "sprite"},
+		{Size: "scrub_storyboard_vtt", ContentID: "cues"},
+	}
+
+	got := StoryboardsFor(thumbnails, results)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 storyboard, got %d", len(got))
+	}
+	sb := got[0]
+	if sb.Size != "scrub" || sb.SpriteContentID != "sprite" || sb.VTTContentID != "cues" {
+		t.Errorf("got size=%q sprite=%q vtt=%q", sb.Size, sb.SpriteContentID, sb.VTTContentID)
+	}
+	if sb.Frames != 20 || sb.Columns != 10 || sb.Rows != 2 || sb.TileWidth != 160 || sb.IntervalMs != 3000 {
+		t.Errorf("unexpected layout %+v", sb)
+	}
+}
+
+func TestDerivationParamsForCrop(t *testing.T) {
+	thumb := img.ThumbnailOutput{Width: 100, Height: 100, SourceWidth: 400, SourceHeight: 200, Mode: img.ResizeFill, Crop: image.Rect(100, 0, 300, 200)}
+	params := DerivationParamsFor(thumb, 5)
+	if params.SourceWidth != 400 || params.TargetWidth != 100 || params.ResizeMode != "fill" {
+		t.Errorf("unexpected params %+v", params)
+	}
+	if params.CropBox == nil || *params.CropBox != (schema.CropBox{X: 100, Y: 0, Width: 200, Height: 200}) {
+		t.Errorf("unexpected crop box %+v", params.CropBox)
+	}
+
+	thumb.Crop = image.Rectangle{}
+	if params := DerivationParamsFor(thumb, 5); params.CropBox != nil {
+		t.Errorf("expected no crop box, got %+v", params.CropBox)
+	}
+}
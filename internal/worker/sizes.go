@@ -0,0 +1,139 @@
+// Package worker holds the thumbnail job pipeline shared by the worker
+// binaries: size presets, source limits, error classification, the content
+// record and upload steps, and the per-source analyses.
+package worker
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tendant/simple-thumbnailer/internal/img"
+)
+
+// SizeConfig is a named thumbnail size preset.
+type SizeConfig struct {
+	Name    string
+	Width   int
+	Height  int
+	Options []string // Extra preset options, e.g. "fill", "anchor=top" (see img.ApplySpecOption)
+}
+
+// DefaultSizes are used when THUMBNAIL_SIZES is not set.
+var DefaultSizes = []SizeConfig{
+	{Name: "small", Width: 150, Height: 150},
+	{Name: "medium", Width: 512, Height: 512},
+	{Name: "large", Width: 1024, Height: 1024},
+}
+
+// ParseThumbnailSizes parses a THUMBNAIL_SIZES value such as
+// "small:150x150,avatar:128x128:fill:anchor=top".
+func ParseThumbnailSizes(sizesEnv string) ([]SizeConfig, error) {
+	var sizes []SizeConfig
+	pairs := strings.Split(sizesEnv, ",")
+
+	for _, pair := range pairs {
+		parts := strings.Split(strings.TrimSpace(pair), ":")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid size format '%s', expected 'name:widthxheight[:option...]'", pair)
+		}
+
+		name := strings.TrimSpace(parts[0])
+		dimParts := strings.Split(parts[1], "x")
+		if len(dimParts) != 2 {
+			return nil, fmt.Errorf("invalid dimensions '%s', expected 'widthxheight'", parts[1])
+		}
+
+		width, err := strconv.Atoi(strings.TrimSpace(dimParts[0]))
+		if err != nil || width <= 0 {
+			return nil, fmt.Errorf("invalid width in '%s'", pair)
+		}
+
+		height, err := strconv.Atoi(strings.TrimSpace(dimParts[1]))
+		if err != nil || height <= 0 {
+			return nil, fmt.Errorf("invalid height in '%s'", pair)
+		}
+
+		options := parts[2:]
+		for _, opt := range options {
+			if err := img.ApplySpecOption(&img.ThumbnailSpec{}, opt); err != nil {
+				return nil, fmt.Errorf("invalid option in '%s': %w", pair, err)
+			}
+		}
+
+		sizes = append(sizes, SizeConfig{
+			Name:    name,
+			Width:   width,
+			Height:  height,
+			Options: options,
+		})
+	}
+
+	return sizes, nil
+}
+
+// ParseThumbnailSizesHint selects the sizes named in the "thumbnail_sizes"
+// job hint, or all available sizes when the hint names none of them.
+func ParseThumbnailSizesHint(hints map[string]string, availableSizes []SizeConfig) []SizeConfig {
+	if hints == nil {
+		return availableSizes
+	}
+
+	sizesHint := hints["thumbnail_sizes"]
+	if sizesHint == "" {
+		return availableSizes
+	}
+
+	requestedSizes := strings.Split(sizesHint, ",")
+	var selectedSizes []SizeConfig
+
+	for _, requested := range requestedSizes {
+		requested = strings.TrimSpace(requested)
+		for _, available := range availableSizes {
+			if available.Name == requested {
+				selectedSizes = append(selectedSizes, available)
+				break
+			}
+		}
+	}
+
+	if len(selectedSizes) == 0 {
+		return availableSizes
+	}
+
+	return selectedSizes
+}
+
+// BuildThumbnailSpecs turns the selected size presets into generator specs,
+// applying preset options first and job hints on top.
+func BuildThumbnailSpecs(sizes []SizeConfig, hints map[string]string) ([]img.ThumbnailSpec, error) {
+	specs := make([]img.ThumbnailSpec, len(sizes))
+	for i, size := range sizes {
+		spec := img.ThumbnailSpec{
+			Name:   size.Name,
+			Width:  size.Width,
+			Height: size.Height,
+			Mode:   img.ResizeFit,
+		}
+		for _, opt := range size.Options {
+			if err := img.ApplySpecOption(&spec, opt); err != nil {
+				return nil, fmt.Errorf("size %s: %w", size.Name, err)
+			}
+		}
+		if err := img.ApplyHints(&spec, hints); err != nil {
+			return nil, err
+		}
+		specs[i] = spec
+	}
+	return specs, nil
+}
+
+// BuildThumbPath returns the base path generated thumbnails are named after.
+func BuildThumbPath(baseDir, contentID, name string) string {
+	base := filepath.Base(name)
+	if base == "" || base == "." {
+		base = "source"
+	}
+	return filepath.Join(baseDir, contentID+"_thumb_"+base)
+}
@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tendant/simple-thumbnailer/internal/img"
+)
+
+func TestBuildThumbPath(t *testing.T) {
+	thumb := BuildThumbPath("/data/thumbs", "abc", filepath.Join("/tmp", "photo.jpg"))
+	expected := filepath.Join("/data/thumbs", "abc_thumb_photo.jpg")
+	if thumb != expected {
+		t.Fatalf("BuildThumbPath mismatch: got %s want %s", thumb, expected)
+	}
+
+	thumb = BuildThumbPath("/data/thumbs", "abc", "")
+	if filepath.Base(thumb) != "abc_thumb_source" {
+		t.Fatalf("expected fallback filename, got %s", thumb)
+	}
+}
+
+func TestParseThumbnailSizesWithOptions(t *testing.T) {
+	sizes, err := ParseThumbnailSizes("avatar:128x128:fill:anchor=top,large:1024x1024")
+	if err != nil {
+		t.Fatalf("ParseThumbnailSizes returned error: %v", err)
+	}
+	if len(sizes) != 2 {
+		t.Fatalf("expected 2 sizes, got %d", len(sizes))
+	}
+
+	specs, err := BuildThumbnailSpecs(sizes, nil)
+	if err != nil {
+		t.Fatalf("BuildThumbnailSpecs returned error: %v", err)
+	}
+	if specs[0].Mode != img.ResizeFill || specs[0].Anchor != img.AnchorTop {
+		t.Fatalf("unexpected avatar spec: mode=%s anchor=%s", specs[0].Mode, specs[0].Anchor)
+	}
+	if specs[1].Mode != img.ResizeFit {
+		t.Fatalf("expected default fit mode for large, got %s", specs[1].Mode)
+	}
+
+	if _, err := ParseThumbnailSizes("avatar:128x128:zoom"); err == nil {
+		t.Fatal("expected error for unknown size option")
+	}
+}
+
+func TestParseThumbnailSizesHint(t *testing.T) {
+	got := ParseThumbnailSizesHint(map[string]string{"thumbnail_sizes": "large, small"}, DefaultSizes)
+	if len(got) != 2 || got[0].Name != "large" || got[1].Name != "small" {
+		t.Fatalf("unexpected sizes %+v", got)
+	}
+	if got := ParseThumbnailSizesHint(map[string]string{"thumbnail_sizes": "huge"}, DefaultSizes); len(got) != len(DefaultSizes) {
+		t.Fatalf("expected all sizes for unknown hint, got %+v", got)
+	}
+}
+
+func TestBuildThumbnailSpecsAppliesHints(t *testing.T) {
+	sizes := []SizeConfig{{Name: "small", Width: 150, Height: 150}}
+
+	specs, err := BuildThumbnailSpecs(sizes, map[string]string{"resize_mode": "pad", "resize_background": "#000"})
+	if err != nil {
+		t.Fatalf("BuildThumbnailSpecs returned error: %v", err)
+	}
+	if specs[0].Mode != img.ResizePad || specs[0].Background == nil {
+		t.Fatalf("expected pad mode with background from hints, got %+v", specs[0])
+	}
+
+	if _, err := BuildThumbnailSpecs(sizes, map[string]string{"resize_mode": "zoom"}); err == nil {
+		t.Fatal("expected error for invalid resize_mode hint")
+	}
+}
@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/tendant/simple-thumbnailer/internal/img"
+	"github.com/tendant/simple-thumbnailer/pkg/schema"
+)
+
+// ProcessingState accumulates what a job has done so far, for its lifecycle
+// events and its final ThumbnailDone event.
+type ProcessingState struct {
+	JobID             string
+	ParentContentID   string
+	ParentStatus      string
+	ThumbnailSizes    []string
+	DerivedContentIDs map[string]uuid.UUID // size name -> derived content ID
+	StartTime         time.Time
+	Lifecycle         []schema.ThumbnailLifecycleEvent
+	Placeholders      img.Placeholders
+	Palette           img.Palette
+	SourceHash        img.PerceptualHash
+	Storyboards       []schema.Storyboard
+}
+
+func (ps *ProcessingState) AddLifecycleEvent(stage schema.ProcessingStage, err error, failureType schema.FailureType) {
+	event := schema.ThumbnailLifecycleEvent{
+		JobID:           ps.JobID,
+		ParentContentID: ps.ParentContentID,
+		ParentStatus:    ps.ParentStatus,
+		Stage:           stage,
+		ThumbnailSizes:  ps.ThumbnailSizes,
+		HappenedAt:      time.Now().Unix(),
+	}
+
+	if stage == schema.StageProcessing {
+		event.ProcessingStart = ps.StartTime.UnixMilli()
+	} else if stage == schema.StageCompleted || stage == schema.StageFailed {
+		event.ProcessingStart = ps.StartTime.UnixMilli()
+		event.ProcessingEnd = time.Now().UnixMilli()
+	}
+
+	if err != nil {
+		event.Error = err.Error()
+		event.FailureType = failureType
+	}
+
+	ps.Lifecycle = append(ps.Lifecycle, event)
+}
+
+func (ps *ProcessingState) GetProcessingDuration() int64 {
+	if ps.StartTime.IsZero() {
+		return 0
+	}
+	return time.Since(ps.StartTime).Milliseconds()
+}
+
+// Done builds the ThumbnailDone event for the job. cause and failureType
+// describe a failed job.
+func (ps *ProcessingState) Done(results []schema.ThumbnailResult, sourcePath string, cause error, failureType schema.FailureType) schema.ThumbnailDone {
+	totalFailed := 0
+	for _, result := range results {
+		if result.Status != "processed" {
+			totalFailed++
+		}
+	}
+
+	done := schema.ThumbnailDone{
+		ID:               ps.JobID,
+		SourcePath:       sourcePath,
+		ParentContentID:  ps.ParentContentID,
+		ParentStatus:     ps.ParentStatus,
+		TotalProcessed:   len(results),
+		TotalFailed:      totalFailed,
+		ProcessingTimeMs: ps.GetProcessingDuration(),
+		Results:          results,
+		Lifecycle:        ps.Lifecycle,
+		BlurHash:         ps.Placeholders.BlurHash,
+		ThumbHash:        ps.Placeholders.ThumbHash,
+		DominantColor:    ps.Palette.Dominant,
+		Palette:          ps.Palette.Colors,
+		PHash:            ps.SourceHash.PHash,
+		DHash:            ps.SourceHash.DHash,
+		Storyboards:      ps.Storyboards,
+		HappenedAt:       time.Now().Unix(),
+	}
+
+	if cause != nil {
+		done.Error = cause.Error()
+		done.FailureType = failureType
+		done.ErrorCode = ErrorCodeFor(cause)
+	}
+	return done
+}
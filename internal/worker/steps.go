@@ -0,0 +1,251 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	simplecontent "github.com/tendant/simple-content/pkg/simplecontent"
+
+	"github.com/tendant/simple-thumbnailer/internal/img"
+	"github.com/tendant/simple-thumbnailer/internal/upload"
+	"github.com/tendant/simple-thumbnailer/pkg/schema"
+)
+
+// ValidateParentContent checks that the parent content is ready to derive from.
+func ValidateParentContent(parent *simplecontent.Content, logger *slog.Logger) error {
+	requiredStatus := simplecontent.ContentStatusUploaded
+	if parent.Status != string(requiredStatus) {
+		logger.Warn("parent content not ready for derivation", "status", parent.Status, "required", requiredStatus)
+		return ValidationError{
+			Type:    schema.FailureTypeValidation,
+			Message: fmt.Sprintf("parent content status is '%s', expected '%s'", parent.Status, requiredStatus),
+		}
+	}
+
+	logger.Info("parent content validation passed", "content_id", parent.ID, "status", parent.Status)
+	return nil
+}
+
+// CreateDerivedContentRecords creates placeholder records for each thumbnail size
+// before processing begins. This allows tracking of both download and generation phases.
+func CreateDerivedContentRecords(ctx context.Context, parent *simplecontent.Content, specs []img.ThumbnailSpec, contentSvc simplecontent.Service, logger *slog.Logger) (map[string]uuid.UUID, error) {
+	derivedContentIDs := make(map[string]uuid.UUID, len(specs))
+
+	for _, size := range specs {
+		variant := DeriveSizeVariant(size.Width, size.Height)
+		metadata := map[string]interface{}{
+			"width":       size.Width,
+			"height":      size.Height,
+			"resize_mode": string(size.Mode),
+		}
+
+		derived, err := contentSvc.CreateDerivedContent(ctx, simplecontent.CreateDerivedContentRequest{
+			ParentID:       parent.ID,
+			OwnerID:        parent.OwnerID,
+			TenantID:       parent.TenantID,
+			DerivationType: "thumbnail",
+			Variant:        variant,
+			Metadata:       metadata,
+			InitialStatus:  simplecontent.ContentStatusCreated,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create derived content for size %s: %w", size.Name, err)
+		}
+
+		derivedContentIDs[size.Name] = derived.ID
+		logger.Info("created derived content placeholder",
+			"size", size.Name,
+			"content_id", derived.ID,
+			"status", derived.Status)
+	}
+
+	return derivedContentIDs, nil
+}
+
+// CreateVariantContentRecords creates derived content for extra outputs, such
+// as animated thumbnails, video preview clips and further PDF pages, which
+// only exist once the source has been inspected. They are created in
+// "processing" since the source is downloaded.
+func CreateVariantContentRecords(ctx context.Context, parent *simplecontent.Content, specs []img.ThumbnailSpec, thumbnails []img.ThumbnailOutput, state *ProcessingState, contentSvc simplecontent.Service, logger *slog.Logger) error {
+	for _, thumb := range thumbnails {
+		if thumb.Variant == "" {
+			continue
+		}
+		if _, ok := state.DerivedContentIDs[thumb.Name]; ok {
+			continue
+		}
+
+		// Name the variant after its size, like the size's own record
+		sizeVariant := DeriveSizeVariant(thumb.Width, thumb.Height)
+		for _, spec := range specs {
+			if spec.Name+"_"+thumb.Variant == thumb.Name {
+				sizeVariant = DeriveSizeVariant(spec.Width, spec.Height)
+				break
+			}
+		}
+
+		derived, err := contentSvc.CreateDerivedContent(ctx, simplecontent.CreateDerivedContentRequest{
+			ParentID:       parent.ID,
+			OwnerID:        parent.OwnerID,
+			TenantID:       parent.TenantID,
+			DerivationType: "thumbnail",
+			Variant:        sizeVariant + "_" + thumb.Variant,
+			Metadata:       VariantMetadata(thumb),
+			InitialStatus:  simplecontent.ContentStatusProcessing,
+		})
+		if err != nil {
+			return fmt.Errorf("create derived content for %s: %w", thumb.Name, err)
+		}
+
+		state.DerivedContentIDs[thumb.Name] = derived.ID
+		logger.Info("created derived content for variant",
+			"size", thumb.Name,
+			"variant", thumb.Variant,
+			"content_id", derived.ID)
+	}
+	return nil
+}
+
+// UpdateDerivedContentStatusAfterDownload updates all derived content to "processing"
+// after the parent content has been successfully downloaded.
+func UpdateDerivedContentStatusAfterDownload(ctx context.Context, derivedContentIDs map[string]uuid.UUID, contentSvc simplecontent.Service, logger *slog.Logger) error {
+	for sizeName, contentID := range derivedContentIDs {
+		if err := contentSvc.UpdateContentStatus(ctx, contentID, simplecontent.ContentStatusProcessing); err != nil {
+			return fmt.Errorf("update status for size %s (content_id=%s): %w", sizeName, contentID, err)
+		}
+		logger.Info("updated derived content status to processing",
+			"size", sizeName,
+			"content_id", contentID)
+	}
+	return nil
+}
+
+// GenerateThumbnails picks a generator by MIME type, or by extension for
+// camera RAW files. Sources of unknown or unsupported type are tried as
+// images. password opens encrypted PDFs and is ignored for other sources.
+func GenerateThumbnails(ctx context.Context, source *upload.Source, basePath string, specs []img.ThumbnailSpec, limits img.Limits, password string) ([]img.ThumbnailOutput, error) {
+	if source == nil {
+		return nil, errors.New("source is required")
+	}
+
+	// RAW photos often arrive without a specific MIME type
+	mimeType := img.MimeTypeForFile(strings.TrimSpace(source.MimeType), source.Filename)
+	generator, err := img.GetGeneratorWithLimits(mimeType, limits)
+	if err != nil {
+		generator = &img.ImageGenerator{Limits: limits}
+	}
+	if pdf, ok := generator.(*img.PDFGenerator); ok {
+		pdf.Password = password
+	}
+	return generator.Generate(ctx, source.Path, basePath, specs)
+}
+
+// UploadMimeType returns the MIME type to store a thumbnail under: that of
+// its output format or extension, never the source's unless neither is known.
+func UploadMimeType(thumb img.ThumbnailOutput, source *upload.Source) string {
+	if thumb.Format != "" {
+		return thumb.Format.MimeType()
+	}
+	if ext := filepath.Ext(thumb.Path); ext != "" {
+		if mimeType := mime.TypeByExtension(ext); mimeType != "" {
+			return mimeType
+		}
+	}
+	if source != nil {
+		return source.MimeType
+	}
+	return ""
+}
+
+// UploadResults uploads every generated thumbnail to its derived content and
+// marks it processed. A failed upload is reported in its result rather than
+// failing the job. Storyboard WebVTT files are rewritten to reference their
+// sprite by its content ID, so a sprite must upload before its cues do.
+func UploadResults(ctx context.Context, thumbnails []img.ThumbnailOutput, source *upload.Source, uploader *upload.Client, state *ProcessingState, contentSvc simplecontent.Service, logger *slog.Logger) ([]schema.ThumbnailResult, error) {
+	var results []schema.ThumbnailResult
+	uploaded := make(map[string]img.ThumbnailOutput, len(thumbnails))
+
+	for _, thumb := range thumbnails {
+		processingStart := time.Now()
+
+		derivedContentID, ok := state.DerivedContentIDs[thumb.Name]
+		if !ok {
+			logger.Error("derived content ID not found for size", "size", thumb.Name)
+			return nil, fmt.Errorf("derived content ID not found for size %s", thumb.Name)
+		}
+
+		if thumb.Variant == img.VariantStoryboardVTT {
+			if err := linkStoryboardSprite(thumb, uploaded, state); err != nil {
+				logger.Error("link storyboard sprite failed", "size", thumb.Name, "err", err)
+				result := ThumbnailResultFor(thumb)
+				result.Status = "failed"
+				result.DerivationParams = DerivationParamsFor(thumb, time.Since(processingStart).Milliseconds())
+				results = append(results, result)
+				continue
+			}
+		}
+
+		stored, err := uploader.UploadThumbnailObject(ctx, derivedContentID, thumb.Path, upload.UploadOptions{
+			FileName: source.Filename,
+			MimeType: UploadMimeType(thumb, source),
+			Width:    thumb.Width,
+			Height:   thumb.Height,
+		})
+
+		processingTime := time.Since(processingStart).Milliseconds()
+
+		if err != nil {
+			logger.Error("upload thumbnail failed", "size", thumb.Name, "err", err)
+			result := ThumbnailResultFor(thumb)
+			result.Status = "failed"
+			result.DerivationParams = DerivationParamsFor(thumb, processingTime)
+			results = append(results, result)
+			continue
+		}
+		if stored.MetadataErr != nil {
+			logger.Warn("record thumbnail dimensions failed", "size", thumb.Name, "content_id", derivedContentID, "err", stored.MetadataErr)
+		}
+
+		if err := contentSvc.UpdateContentStatus(ctx, derivedContentID, simplecontent.ContentStatusProcessed); err != nil {
+			logger.Error("update content status to processed failed", "size", thumb.Name, "content_id", derivedContentID, "err", err)
+			result := ThumbnailResultFor(thumb)
+			result.Status = "failed"
+			result.DerivationParams = DerivationParamsFor(thumb, processingTime)
+			results = append(results, result)
+			continue
+		}
+
+		result := ThumbnailResultFor(thumb)
+		result.ContentID = derivedContentID.String() // URL generation handled by content service
+		result.Status = "processed"
+		result.DerivationParams = DerivationParamsFor(thumb, processingTime)
+		results = append(results, result)
+		uploaded[thumb.Name] = thumb
+
+		logger.Info("thumbnail uploaded successfully", "size", thumb.Name, "content_id", derivedContentID, "processing_time_ms", processingTime)
+		if err := os.Remove(thumb.Path); err != nil {
+			logger.Warn("failed to cleanup thumbnail file", "path", thumb.Path, "err", err)
+		}
+	}
+
+	return results, nil
+}
+
+// linkStoryboardSprite points the cues of a storyboard WebVTT output at the
+// content ID of its sprite, which must be among the uploaded outputs.
+func linkStoryboardSprite(vtt img.ThumbnailOutput, uploaded map[string]img.ThumbnailOutput, state *ProcessingState) error {
+	spriteName := strings.TrimSuffix(vtt.Name, "_"+img.VariantStoryboardVTT) + "_" + img.VariantStoryboard
+	sprite, ok := uploaded[spriteName]
+	if !ok {
+		return fmt.Errorf("storyboard sprite %s was not uploaded", spriteName)
+	}
+	return img.SetStoryboardSprite(vtt.Path, filepath.Base(sprite.Path), state.DerivedContentIDs[spriteName].String())
+}
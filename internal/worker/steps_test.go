@@ -0,0 +1,223 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	simplecontent "github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/repo/memory"
+	memorystorage "github.com/tendant/simple-content/pkg/simplecontent/storage/memory"
+
+	"github.com/tendant/simple-thumbnailer/internal/img"
+	"github.com/tendant/simple-thumbnailer/internal/upload"
+	"github.com/tendant/simple-thumbnailer/pkg/schema"
+)
+
+func TestGenerateThumbnailsUsesVideoMimeType(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skipf("ffmpeg not installed: %v", err)
+	}
+
+	sourcePath := filepath.Join("..", "..", "scripts", "test-samples", "sample.mp4")
+	if _, err := os.Stat(sourcePath); err != nil {
+		t.Fatalf("video sample missing: %v", err)
+	}
+
+	basePath := filepath.Join(t.TempDir(), "thumb.jpg")
+	source := &upload.Source{
+		Path:     sourcePath,
+		Filename: "sample.mp4",
+		MimeType: "video/mp4",
+	}
+
+	thumbnails, err := GenerateThumbnails(context.Background(), source, basePath, []img.ThumbnailSpec{
+		{Name: "small", Width: 150, Height: 150},
+	}, img.DefaultLimits, "")
+	if err != nil {
+		t.Fatalf("generate video thumbnail: %v", err)
+	}
+	if len(thumbnails) != 1 {
+		t.Fatalf("expected 1 thumbnail, got %d", len(thumbnails))
+	}
+	assertNonEmptyFile(t, thumbnails[0].Path)
+}
+
+func TestGenerateThumbnailsFallsBackToImagePathWithoutMimeType(t *testing.T) {
+	tmp := t.TempDir()
+	sourcePath := filepath.Join(tmp, "source.png")
+	writeTestPNG(t, sourcePath)
+
+	basePath := filepath.Join(tmp, "thumb.png")
+	source := &upload.Source{
+		Path:     sourcePath,
+		Filename: "source.png",
+	}
+
+	thumbnails, err := GenerateThumbnails(context.Background(), source, basePath, []img.ThumbnailSpec{
+		{Name: "small", Width: 50, Height: 50},
+	}, img.DefaultLimits, "")
+	if err != nil {
+		t.Fatalf("generate image thumbnail: %v", err)
+	}
+	if len(thumbnails) != 1 {
+		t.Fatalf("expected 1 thumbnail, got %d", len(thumbnails))
+	}
+	assertNonEmptyFile(t, thumbnails[0].Path)
+}
+
+func TestGenerateThumbnailsRejectsOversizedImage(t *testing.T) {
+	tmp := t.TempDir()
+	sourcePath := filepath.Join(tmp, "source.png")
+	writeTestPNG(t, sourcePath)
+
+	source := &upload.Source{Path: sourcePath, Filename: "source.png", MimeType: "image/png"}
+	_, err := GenerateThumbnails(context.Background(), source, filepath.Join(tmp, "thumb.png"), []img.ThumbnailSpec{
+		{Name: "small", Width: 50, Height: 50},
+	}, img.Limits{MaxPixels: 10}, "")
+	if !errors.Is(err, img.ErrLimitExceeded) {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+	if got := ClassifyError(err); got != schema.FailureTypePermanent {
+		t.Fatalf("expected permanent failure, got %q", got)
+	}
+}
+
+func TestUploadMimeTypeUsesGeneratedThumbnailPath(t *testing.T) {
+	got := UploadMimeType(img.ThumbnailOutput{Path: "/tmp/thumb.jpg"}, &upload.Source{MimeType: "video/mp4"})
+	if got != "image/jpeg" {
+		t.Fatalf("expected generated thumbnail MIME image/jpeg, got %q", got)
+	}
+}
+
+func TestUploadMimeTypeWebP(t *testing.T) {
+	got := UploadMimeType(img.ThumbnailOutput{Path: "/tmp/thumb_grid.webp"}, &upload.Source{MimeType: "image/jpeg"})
+	if got != "image/webp" {
+		t.Fatalf("expected generated thumbnail MIME image/webp, got %q", got)
+	}
+}
+
+func TestUploadMimeTypeUsesOutputFormat(t *testing.T) {
+	got := UploadMimeType(img.ThumbnailOutput{Path: "/tmp/thumb_card_storyboard.vtt", Format: img.FormatVTT}, &upload.Source{MimeType: "video/mp4"})
+	if got != "text/vtt" {
+		t.Fatalf("expected storyboard MIME text/vtt, got %q", got)
+	}
+}
+
+func TestUploadMimeTypeFallsBackToSourceMimeType(t *testing.T) {
+	got := UploadMimeType(img.ThumbnailOutput{Path: "/tmp/thumb"}, &upload.Source{MimeType: "image/png"})
+	if got != "image/png" {
+		t.Fatalf("expected source MIME fallback image/png, got %q", got)
+	}
+}
+
+func TestUploadResultsLinksStoryboardSprite(t *testing.T) {
+	ctx := context.Background()
+	svc, err := simplecontent.New(
+		simplecontent.WithRepository(memory.New()),
+		simplecontent.WithBlobStore("memory", memorystorage.New()),
+	)
+	if err != nil {
+		t.Fatalf("create service: %v", err)
+	}
+	parent, err := svc.UploadContent(ctx, simplecontent.UploadContentRequest{
+		OwnerID:            uuid.New(),
+		TenantID:           uuid.New(),
+		Name:               "video",
+		DocumentType:       "video",
+		StorageBackendName: "memory",
+		Reader:             strings.NewReader("video-data"),
+		FileName:           "video.mp4",
+	})
+	if err != nil {
+		t.Fatalf("upload content: %v", err)
+	}
+
+	// The generator names the sprite by file in the cues
+	tmp := t.TempDir()
+	spritePath := filepath.Join(tmp, "video_scrub_storyboard.png")
+	writeTestPNG(t, spritePath)
+	vttPath := filepath.Join(tmp, "video_scrub_storyboard.vtt")
+	vtt := "WEBVTT\n\n00:00:00.000 --> 00:00:03.000\nvideo_scrub_storyboard.png#xywh=0,0,160,90\n"
+	if err := os.WriteFile(vttPath, []byte(vtt), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	thumbnails := []img.ThumbnailOutput{
+		{Name: "scrub_storyboard", Path: spritePath, Width: 100, Height: 50, Variant: img.VariantStoryboard},
+		{Name: "scrub_storyboard_vtt", Path: vttPath, Format: img.FormatVTT, Variant: img.VariantStoryboardVTT},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	state := &ProcessingState{DerivedContentIDs: make(map[string]uuid.UUID)}
+	if err := CreateVariantContentRecords(ctx, parent, nil, thumbnails, state, svc, logger); err != nil {
+		t.Fatalf("create variant records: %v", err)
+	}
+
+	source := &upload.Source{Filename: "video.mp4", MimeType: "video/mp4"}
+	results, err := UploadResults(ctx, thumbnails, source, upload.NewClient(svc, "memory"), state, svc, logger)
+	if err != nil {
+		t.Fatalf("UploadResults error: %v", err)
+	}
+	for _, result := range results {
+		if result.Status != "processed" {
+			t.Fatalf("%s: status %q", result.Size, result.Status)
+		}
+	}
+
+	reader, err := svc.DownloadContent(ctx, state.DerivedContentIDs["scrub_storyboard_vtt"])
+	if err != nil {
+		t.Fatalf("download vtt: %v", err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := state.DerivedContentIDs["scrub_storyboard"].String() + "#xywh=0,0,160,90"
+	if !strings.Contains(string(data), "\n"+want+"\n") {
+		t.Errorf("uploaded vtt does not target the sprite %s:\n%s", want, data)
+	}
+}
+
+func writeTestPNG(t *testing.T, path string) {
+	t.Helper()
+
+	picture := image.NewRGBA(image.Rect(0, 0, 100, 50))
+	for y := 0; y < 50; y++ {
+		for x := 0; x < 100; x++ {
+			picture.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create png: %v", err)
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, picture); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+}
+
+func assertNonEmptyFile(t *testing.T, path string) {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("thumbnail missing: %v", err)
+	}
+	if info.Size() == 0 {
+		t.Fatalf("thumbnail is empty: %s", path)
+	}
+}
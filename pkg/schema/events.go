@@ -26,15 +26,36 @@ const (
 	FailureTypeValidation  FailureType = "validation"
 )
 
+// ErrorCode identifies a known failure cause so consumers can react to it
+// without parsing the error message.
+type ErrorCode string
+
+const (
+	ErrorCodePDFEncrypted  ErrorCode = "pdf_encrypted"
+	ErrorCodePDFMalformed  ErrorCode = "pdf_malformed"
+)
+
+// CropBox is the region of the source image, in source pixels, that a
+// cropping resize mode kept.
+type CropBox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
 type DerivationParams struct {
-	SourceWidth     int     `json:"source_width"`
-	SourceHeight    int     `json:"source_height"`
-	TargetWidth     int     `json:"target_width"`
-	TargetHeight    int     `json:"target_height"`
-	Algorithm       string  `json:"algorithm"`
-	Quality         int     `json:"quality,omitempty"`
-	ProcessingTime  int64   `json:"processing_time_ms"`
-	GeneratedAt     int64   `json:"generated_at"`
+	SourceWidth     int      `json:"source_width"`
+	SourceHeight    int      `json:"source_height"`
+	TargetWidth     int      `json:"target_width"`
+	TargetHeight    int      `json:"target_height"`
+	Algorithm       string   `json:"algorithm"`
+	ResizeMode      string   `json:"resize_mode,omitempty"`
+	CropBox         *CropBox `json:"crop_box,omitempty"`
+	Quality         int      `json:"quality,omitempty"`
+	FrameTimeMs     int64    `json:"frame_time_ms,omitempty"`
+	ProcessingTime  int64    `json:"processing_time_ms"`
+	GeneratedAt     int64    `json:"generated_at"`
 }
 
 type ThumbnailResult struct {
@@ -44,9 +65,31 @@ type ThumbnailResult struct {
 	Width            int               `json:"width"`
 	Height           int               `json:"height"`
 	Status           string            `json:"status"`
+	Variant          string            `json:"variant,omitempty"`
+	Animated         bool              `json:"animated,omitempty"`
+	FrameCount       int               `json:"frame_count,omitempty"`
+	DurationMs       int64             `json:"duration_ms,omitempty"`
+	Page             int               `json:"page,omitempty"`
+	PageCount        int               `json:"page_count,omitempty"`
 	DerivationParams *DerivationParams `json:"derivation_params,omitempty"`
 }
 
+// Storyboard locates a video storyboard: a sprite of evenly spaced frames and
+// a WebVTT file mapping each interval of the timeline to a tile. Frame i sits
+// at column i%Columns, row i/Columns. The cues name the sprite by
+// SpriteContentID.
+type Storyboard struct {
+	Size            string `json:"size"`
+	SpriteContentID string `json:"sprite_content_id,omitempty"`
+	VTTContentID    string `json:"vtt_content_id,omitempty"`
+	Frames          int    `json:"frames"`
+	Columns         int    `json:"columns"`
+	Rows            int    `json:"rows"`
+	TileWidth       int    `json:"tile_width"`
+	TileHeight      int    `json:"tile_height"`
+	IntervalMs      int64  `json:"interval_ms"`
+}
+
 type ThumbnailLifecycleEvent struct {
 	JobID            string          `json:"job_id"`
 	ParentContentID  string          `json:"parent_content_id"`
@@ -70,7 +113,15 @@ type ThumbnailDone struct {
 	ProcessingTimeMs int64                    `json:"processing_time_ms"`
 	Results          []ThumbnailResult        `json:"results,omitempty"`
 	Lifecycle        []ThumbnailLifecycleEvent `json:"lifecycle,omitempty"`
+	BlurHash         string                   `json:"blurhash,omitempty"`
+	ThumbHash        string                   `json:"thumbhash,omitempty"`
+	DominantColor    string                   `json:"dominant_color,omitempty"`
+	Palette          []string                 `json:"palette,omitempty"`
+	PHash            string                   `json:"phash,omitempty"`
+	DHash            string                   `json:"dhash,omitempty"`
+	Storyboards      []Storyboard             `json:"storyboards,omitempty"`
 	Error            string                   `json:"error,omitempty"`
 	FailureType      FailureType              `json:"failure_type,omitempty"`
+	ErrorCode        ErrorCode                `json:"error_code,omitempty"`
 	HappenedAt       int64                    `json:"happened_at"`
 }
@@ -22,6 +22,18 @@ if [[ "$OSTYPE" == "darwin"* ]]; then
     echo "Installing Poppler..."
     brew install poppler || echo "Poppler already installed"
 
+    echo "Installing LibreOffice (for office documents)..."
+    brew install --cask libreoffice || echo "LibreOffice already installed"
+
+    echo "Installing librsvg (for SVG images)..."
+    brew install librsvg || echo "librsvg already installed"
+
+    echo "Installing libheif (for HEIC and AVIF images)..."
+    brew install libheif || echo "libheif already installed"
+
+    echo "Installing LibRaw (for camera RAW files)..."
+    brew install libraw || echo "LibRaw already installed"
+
     echo "Installing libvips (optional, for fast image processing)..."
     brew install vips || echo "libvips already installed"
 
@@ -31,14 +43,18 @@ elif [[ "$OSTYPE" == "linux-gnu"* ]]; then
     if command -v apt-get &> /dev/null; then
         echo "Using apt-get..."
         sudo apt-get update
-        sudo apt-get install -y ffmpeg poppler-utils libvips-tools
+        sudo apt-get install -y ffmpeg poppler-utils libreoffice-nogui librsvg2-bin libheif-examples libraw-bin libvips-tools
     elif command -v yum &> /dev/null; then
         echo "Using yum..."
-        sudo yum install -y ffmpeg poppler-utils vips-tools
+        sudo yum install -y ffmpeg poppler-utils libreoffice-headless librsvg2-tools libheif-tools dcraw vips-tools
     else
         echo "❌ Unsupported package manager. Please install manually:"
         echo "  - ffmpeg"
         echo "  - poppler-utils"
+        echo "  - libreoffice"
+        echo "  - librsvg (rsvg-convert)"
+        echo "  - libheif (heif-convert)"
+        echo "  - LibRaw (dcraw_emu) or dcraw"
         echo "  - libvips-tools"
         exit 1
     fi
@@ -59,6 +75,22 @@ echo ""
 echo "Poppler version:"
 pdftoppm -v 2>&1 | head -1 || echo "Warning: pdftoppm not found in PATH"
 
+echo ""
+echo "LibreOffice version:"
+soffice --version 2>&1 | head -1 || echo "Warning: soffice not found in PATH"
+
+echo ""
+echo "librsvg version:"
+rsvg-convert --version 2>&1 | head -1 || echo "Warning: rsvg-convert not found in PATH"
+
+echo ""
+echo "libheif version:"
+heif-convert --version 2>&1 | head -1 || echo "Warning: heif-convert not found in PATH"
+
+echo ""
+echo "LibRaw:"
+command -v dcraw_emu || command -v dcraw || echo "Warning: dcraw_emu not found in PATH"
+
 echo ""
 echo "libvips version:"
 vips --version 2>&1 | head -1 || echo "Warning: vips not found in PATH"